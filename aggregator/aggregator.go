@@ -0,0 +1,273 @@
+package aggregator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/logging"
+	"github.com/mauriciogm/dokploy/apps/monitoring/push"
+	"github.com/mauriciogm/dokploy/apps/monitoring/telemetry"
+)
+
+var logger = logging.New("aggregator")
+
+const (
+	defaultPullInterval = 60
+	pullBatchSize       = 500
+	// maxPullsPerCycle bounds how much backlog is fetched from one agent in
+	// one interval.
+	maxPullsPerCycle = 20
+)
+
+type AgentStatus struct {
+	Host        string      `json:"host"`
+	URL         string      `json:"url"`
+	Cursor      push.Cursor `json:"cursor"`
+	LastSuccess string      `json:"lastSuccess,omitempty"`
+	LastError   string      `json:"lastError,omitempty"`
+}
+
+// Aggregator stores the samples of other agents, either received on the
+// push endpoint or pulled from the agents listed in the configuration.
+type Aggregator struct {
+	db     *database.DB
+	client *http.Client
+
+	mu     sync.Mutex
+	agents map[string]*AgentStatus
+
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	// ctx is cancelled on Stop to abort the pulls in progress.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewAggregator(db *database.DB) (*Aggregator, error) {
+	if err := db.InitFleetTables(); err != nil {
+		return nil, fmt.Errorf("failed to initialize fleet tables: %v", err)
+	}
+	if err := db.InitCursorsTable(); err != nil {
+		return nil, fmt.Errorf("failed to initialize cursors table: %v", err)
+	}
+
+	agents := make(map[string]*AgentStatus)
+	for _, agent := range config.GetMetricsConfig().Aggregator.Agents {
+		agents[agent.Host] = &AgentStatus{Host: agent.Host, URL: agent.URL}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Aggregator{
+		db:       db,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		agents:   agents,
+		stopChan: make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+func (a *Aggregator) Start() error {
+	cfg := config.GetMetricsConfig().Aggregator
+	if !cfg.Enabled || len(cfg.Agents) == 0 {
+		return nil
+	}
+
+	for _, agent := range cfg.Agents {
+		if agent.Host == "" || agent.URL == "" {
+			return fmt.Errorf("aggregator agents require a host and a url")
+		}
+	}
+
+	interval := cfg.PullInterval
+	if interval <= 0 {
+		interval = defaultPullInterval
+	}
+
+	telemetry.ExpectRemote("aggregator", time.Duration(interval)*time.Second)
+
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		// The first pull runs here too, so unreachable agents don't delay
+		// the startup of the other components.
+		a.pull()
+		for {
+			select {
+			case <-ticker.C:
+				a.pull()
+			case <-a.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	logger.Info("Pulling metrics from agents", "agents", len(cfg.Agents), "interval", time.Duration(interval)*time.Second)
+	return nil
+}
+
+func (a *Aggregator) Stop() {
+	close(a.stopChan)
+	a.cancel()
+	a.wg.Wait()
+}
+
+// Receive stores a batch sent by an agent in push mode. It returns
+// push.ErrBatchTooLarge for batches over push.MaxBatchSize.
+func (a *Aggregator) Receive(data []byte) error {
+	batch, err := push.Decode(data)
+	if err == push.ErrBatchTooLarge {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("invalid batch: %v", err)
+	}
+	if batch.Host == "" {
+		return fmt.Errorf("batch host is required")
+	}
+	return a.db.SaveFleetSamples(batch.Host, "push", batch.Server, batch.Containers, nil)
+}
+
+// Agents returns the pull status of the configured agents.
+func (a *Aggregator) Agents() []AgentStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	result := make([]AgentStatus, 0, len(a.agents))
+	for _, agent := range config.GetMetricsConfig().Aggregator.Agents {
+		if status, ok := a.agents[agent.Host]; ok {
+			result = append(result, *status)
+		}
+	}
+	return result
+}
+
+func (a *Aggregator) pull() {
+	cycle := telemetry.StartCycle("aggregator")
+	defer cycle.Done()
+
+	var wg sync.WaitGroup
+	for _, agent := range config.GetMetricsConfig().Aggregator.Agents {
+		wg.Add(1)
+		go func(agent config.AgentConfig) {
+			defer wg.Done()
+			if err := a.pullAgent(agent); err != nil {
+				if a.ctx.Err() != nil {
+					// Interrupted by Stop, the agent is not known to fail.
+					return
+				}
+				logger.Error("Error pulling metrics", "host", agent.Host, "error", err)
+				cycle.Error()
+				a.setStatus(agent.Host, func(s *AgentStatus) { s.LastError = err.Error() })
+			}
+		}(agent)
+	}
+	wg.Wait()
+}
+
+// pullAgent fetches the rows stored on an agent since the last pull. The
+// cursor is saved with every batch, so an interrupted pull resumes where it
+// stopped.
+func (a *Aggregator) pullAgent(agent config.AgentConfig) error {
+	cursor, err := a.cursor(agent.Host)
+	if err != nil {
+		return fmt.Errorf("error reading cursor: %v", err)
+	}
+
+	for i := 0; i < maxPullsPerCycle; i++ {
+		batch, err := a.fetch(agent, cursor)
+		if err != nil {
+			return err
+		}
+		if batch.Empty() {
+			break
+		}
+
+		cursors := map[string]int64{
+			cursorName(agent.Host, "server"):     batch.Cursor.Server,
+			cursorName(agent.Host, "containers"): batch.Cursor.Containers,
+		}
+		if err := a.db.SaveFleetSamples(agent.Host, "pull", batch.Server, batch.Containers, cursors); err != nil {
+			return fmt.Errorf("error saving samples: %v", err)
+		}
+		cursor = batch.Cursor
+	}
+
+	a.setStatus(agent.Host, func(s *AgentStatus) {
+		s.Cursor = cursor
+		s.LastSuccess = time.Now().UTC().Format(time.RFC3339Nano)
+		s.LastError = ""
+	})
+	return nil
+}
+
+func (a *Aggregator) fetch(agent config.AgentConfig, cursor push.Cursor) (*push.Batch, error) {
+	query := url.Values{}
+	query.Set("server", strconv.FormatInt(cursor.Server, 10))
+	query.Set("containers", strconv.FormatInt(cursor.Containers, 10))
+	query.Set("limit", strconv.Itoa(pullBatchSize))
+
+	req, err := http.NewRequestWithContext(a.ctx, http.MethodGet, strings.TrimSuffix(agent.URL, "/")+"/push/export?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+agent.Token)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, push.MaxBatchSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if len(body) > 1024 {
+			body = body[:1024]
+		}
+		return nil, fmt.Errorf("agent returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	batch, err := push.Decode(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid batch: %v", err)
+	}
+	return batch, nil
+}
+
+func (a *Aggregator) cursor(host string) (push.Cursor, error) {
+	server, err := a.db.GetCursor(cursorName(host, "server"))
+	if err != nil {
+		return push.Cursor{}, err
+	}
+	containers, err := a.db.GetCursor(cursorName(host, "containers"))
+	if err != nil {
+		return push.Cursor{}, err
+	}
+	return push.Cursor{Server: server, Containers: containers}, nil
+}
+
+func (a *Aggregator) setStatus(host string, update func(*AgentStatus)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if status, ok := a.agents[host]; ok {
+		update(status)
+	}
+}
+
+func cursorName(host, table string) string {
+	return "aggregator." + host + "." + table
+}
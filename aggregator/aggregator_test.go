@@ -0,0 +1,237 @@
+package aggregator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/push"
+)
+
+// testAgent serves the rows of a new agent database two at a time on
+// /push/export. Once it answered serve requests, the next ones fail.
+type testAgent struct {
+	db *database.DB
+
+	mu       sync.Mutex
+	serve    int
+	requests []push.Cursor
+}
+
+// reset lets the agent answer n more requests and forgets the previous ones.
+func (a *testAgent) reset(n int) {
+	a.mu.Lock()
+	a.serve = n
+	a.requests = nil
+	a.mu.Unlock()
+}
+
+func newTestAgent(t *testing.T, rows int) (*testAgent, *httptest.Server) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	db, err := database.InitDB()
+	os.Chdir(wd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.InitContainerMetricsTable(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < rows; i++ {
+		timestamp := fmt.Sprintf("2026-10-15T10:00:%02dZ", i)
+		if err := db.SaveMetric(database.ServerMetric{Timestamp: timestamp, CPU: float64(i)}); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.SaveContainerMetric(&database.ContainerMetric{Timestamp: timestamp, Name: "app-api-1", CPU: float64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	agent := &testAgent{db: db, serve: 1000}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server, _ := strconv.ParseInt(r.URL.Query().Get("server"), 10, 64)
+		containers, _ := strconv.ParseInt(r.URL.Query().Get("containers"), 10, 64)
+		after := push.Cursor{Server: server, Containers: containers}
+
+		agent.mu.Lock()
+		agent.requests = append(agent.requests, after)
+		broken := len(agent.requests) > agent.serve
+		agent.mu.Unlock()
+		if broken {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		batch, err := push.BuildBatch(db, "web-1", after, 2)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(batch)
+	}))
+	t.Cleanup(server.Close)
+	return agent, server
+}
+
+func testAggregator(t *testing.T) *Aggregator {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "monitoring.db")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := database.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.InitFleetTables(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.InitCursorsTable(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &Aggregator{
+		db:     db,
+		client: http.DefaultClient,
+		agents: map[string]*AgentStatus{"web-1": {Host: "web-1"}},
+		ctx:    context.Background(),
+	}
+}
+
+func countRows(t *testing.T, db *database.DB, table string) int {
+	t.Helper()
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestPullResumesFromCursor(t *testing.T) {
+	agent, server := newTestAgent(t, 5)
+	a := testAggregator(t)
+	cfg := config.AgentConfig{Host: "web-1", URL: server.URL}
+
+	if err := a.pullAgent(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := countRows(t, a.db, "fleet_server_metrics"); got != 5 {
+		t.Fatalf("got %d server samples, want 5", got)
+	}
+	cursor, err := a.cursor("web-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (push.Cursor{Server: 5, Containers: 5}); cursor != want || a.agents["web-1"].Cursor != want {
+		t.Errorf("got cursor %+v, want %+v", cursor, want)
+	}
+
+	// New rows are pulled after the saved cursor, also by a new aggregator
+	// over the same database.
+	for i := 5; i < 8; i++ {
+		timestamp := fmt.Sprintf("2026-10-15T10:00:%02dZ", i)
+		if err := agent.db.SaveMetric(database.ServerMetric{Timestamp: timestamp, CPU: float64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	agent.reset(1000)
+
+	restarted := &Aggregator{db: a.db, client: http.DefaultClient, agents: map[string]*AgentStatus{}, ctx: context.Background()}
+	if err := restarted.pullAgent(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := countRows(t, a.db, "fleet_server_metrics"); got != 8 {
+		t.Errorf("got %d server samples, want 8", got)
+	}
+	if got := countRows(t, a.db, "fleet_container_metrics"); got != 5 {
+		t.Errorf("got %d container samples, want 5", got)
+	}
+	if len(agent.requests) == 0 || agent.requests[0] != (push.Cursor{Server: 5, Containers: 5}) {
+		t.Errorf("got requests %+v, want the first one after the saved cursor", agent.requests)
+	}
+}
+
+func TestPullKeepsCursorOfStoredBatches(t *testing.T) {
+	agent, server := newTestAgent(t, 5)
+	a := testAggregator(t)
+	cfg := config.AgentConfig{Host: "web-1", URL: server.URL}
+
+	agent.reset(0)
+	if err := a.pullAgent(cfg); err == nil {
+		t.Fatal("got no error from an unavailable agent")
+	}
+	if cursor, _ := a.cursor("web-1"); cursor != (push.Cursor{}) {
+		t.Errorf("got cursor %+v after a failed pull, want none", cursor)
+	}
+
+	// The agent fails after the first batch: its rows and cursor are kept,
+	// and the next pull continues after them.
+	agent.reset(1)
+	if err := a.pullAgent(cfg); err == nil {
+		t.Fatal("got no error from an agent failing after one batch")
+	}
+	if cursor, _ := a.cursor("web-1"); cursor != (push.Cursor{Server: 2, Containers: 2}) {
+		t.Errorf("got cursor %+v, want the position of the first batch", cursor)
+	}
+	if got := countRows(t, a.db, "fleet_server_metrics"); got != 2 {
+		t.Errorf("got %d server samples, want the 2 of the first batch", got)
+	}
+
+	agent.reset(1000)
+	if err := a.pullAgent(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if agent.requests[0] != (push.Cursor{Server: 2, Containers: 2}) {
+		t.Errorf("got first request after %+v, want after the saved cursor", agent.requests[0])
+	}
+	if got := countRows(t, a.db, "fleet_server_metrics"); got != 5 {
+		t.Errorf("got %d server samples, want 5", got)
+	}
+}
+
+func TestReceive(t *testing.T) {
+	a := testAggregator(t)
+	data, err := push.Encode(&push.Batch{
+		Host:   "web-2",
+		Server: []database.ServerMetric{{Timestamp: "2026-10-15T10:00:00Z", CPU: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A retried push stores its samples once.
+	for i := 0; i < 2; i++ {
+		if err := a.Receive(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := countRows(t, a.db, "fleet_server_metrics"); got != 1 {
+		t.Errorf("got %d server samples, want 1", got)
+	}
+
+	large, err := push.Encode(&push.Batch{Host: strings.Repeat("a", push.MaxBatchSize)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Receive(large); err != push.ErrBatchTooLarge {
+		t.Errorf("got error %v, want ErrBatchTooLarge", err)
+	}
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mauriciogm/dokploy/apps/monitoring/aggregator"
+	"github.com/mauriciogm/dokploy/apps/monitoring/checks"
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/containers"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/health"
+	"github.com/mauriciogm/dokploy/apps/monitoring/logging"
+	"github.com/mauriciogm/dokploy/apps/monitoring/probes"
+	"github.com/mauriciogm/dokploy/apps/monitoring/push"
+	"github.com/mauriciogm/dokploy/apps/monitoring/reports"
+	"github.com/mauriciogm/dokploy/apps/monitoring/scrape"
+)
+
+// Prefix is the path the versioned API is served under.
+const Prefix = "/api/v1"
+
+var logger = logging.New("api")
+
+// Server holds the components the API reads from.
+type Server struct {
+	DB           *database.DB
+	Config       *config.Config
+	Health       *health.Checker
+	LogMonitor   *containers.LogMonitor
+	ProbeMonitor *probes.ProbeMonitor
+	CheckRunner  *checks.CheckRunner
+	Scraper      *scrape.Scraper
+	Pusher       *push.Pusher
+	Aggregator   *aggregator.Aggregator
+	Digests      *reports.Scheduler
+	RowCounter   *database.RowCounter
+
+	spec []byte
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+type StatusResponse struct {
+	Status string `json:"status"`
+}
+
+type RenderLinkResponse struct {
+	URL     string `json:"url"`
+	Expires string `json:"expires"`
+}
+
+type IngestResponse struct {
+	Accepted int `json:"accepted"`
+}
+
+// IngestSample documents the JSON accepted by the ingest endpoint. The
+// timestamp is an RFC3339 string or unix seconds and defaults to now.
+type IngestSample struct {
+	Name      string            `json:"name"`
+	Value     float64           `json:"value"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Timestamp interface{}       `json:"timestamp,omitempty"`
+}
+
+// ServerMetricResponse is a host sample with the configured bandwidth, in Mbps.
+type ServerMetricResponse struct {
+	database.ServerMetric
+	Bandwidth int `json:"bandwidth"`
+}
+
+func fail(c *fiber.Ctx, status int, message string) error {
+	return c.Status(status).JSON(ErrorResponse{Error: message})
+}
+
+// Register adds the versioned routes under Prefix and the unversioned routes
+// of earlier releases, which answer with Deprecation and Link headers
+// pointing to their successor. Routes that are not public go through auth.
+func (s *Server) Register(app *fiber.App, auth fiber.Handler) error {
+	var routes []route
+	for _, r := range s.routes() {
+		if r.aggregator && !s.Config.Aggregator.Enabled {
+			continue
+		}
+		routes = append(routes, r)
+	}
+
+	spec, err := json.Marshal(document(routes))
+	if err != nil {
+		return err
+	}
+	s.spec = spec
+
+	v1 := app.Group(Prefix)
+	for _, r := range routes {
+		v1.Add(r.method, r.path, s.withAuth(r, auth, r.handler)...)
+	}
+
+	for _, r := range routes {
+		if r.versionOnly {
+			continue
+		}
+		handler := r.handler
+		if r.legacy != nil {
+			handler = r.legacy
+		}
+		handlers := append([]fiber.Handler{deprecated(Prefix + r.path)}, s.withAuth(r, auth, handler)...)
+		app.Add(r.method, r.path, handlers...)
+	}
+	return nil
+}
+
+func (s *Server) withAuth(r route, auth fiber.Handler, handler fiber.Handler) []fiber.Handler {
+	if r.public {
+		return []fiber.Handler{handler}
+	}
+	if r.signed {
+		return []fiber.Handler{s.signedOrAuth(auth), handler}
+	}
+	return []fiber.Handler{auth, handler}
+}
+
+// deprecated marks the responses of an unversioned route, as described in
+// RFC 8594 and the Deprecation header draft.
+func deprecated(successor string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set("Deprecation", "true")
+		c.Set(fiber.HeaderLink, "<"+successor+`>; rel="successor-version"`)
+		return c.Next()
+	}
+}
@@ -0,0 +1,564 @@
+package api
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mauriciogm/dokploy/apps/monitoring/charts"
+	"github.com/mauriciogm/dokploy/apps/monitoring/containers"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/health"
+	"github.com/mauriciogm/dokploy/apps/monitoring/ingest"
+	"github.com/mauriciogm/dokploy/apps/monitoring/logging"
+	"github.com/mauriciogm/dokploy/apps/monitoring/monitoring"
+	"github.com/mauriciogm/dokploy/apps/monitoring/push"
+	"github.com/mauriciogm/dokploy/apps/monitoring/reports"
+	"github.com/mauriciogm/dokploy/apps/monitoring/telemetry"
+)
+
+func (s *Server) health(c *fiber.Ctx) error {
+	return c.JSON(StatusResponse{Status: "ok"})
+}
+
+func (s *Server) ready(c *fiber.Ctx) error {
+	report := s.Health.Ready(c.UserContext())
+	if report.Status == health.StatusFail {
+		return c.Status(503).JSON(report)
+	}
+	return c.JSON(report)
+}
+
+func (s *Server) openAPI(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	return c.Send(s.spec)
+}
+
+// queryLimit returns the limit parameter, which is a number of rows or all.
+func queryLimit(c *fiber.Ctx) (n int, all bool) {
+	limit := c.Query("limit", "50")
+	if limit == "all" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil {
+		n = 50
+	}
+	return n, false
+}
+
+func (s *Server) serverMetrics(c *fiber.Ctx) error {
+	return list(c, "metrics", s.DB.GetMetricsPage, s.DB.GetLastNMetrics, func(m database.ServerMetric) interface{} {
+		return ServerMetricResponse{ServerMetric: m, Bandwidth: s.Config.Server.Bandwidth}
+	})
+}
+
+// legacyServerMetrics answers with the numbers formatted as strings.
+func (s *Server) legacyServerMetrics(c *fiber.Ctx) error {
+	return list(c, "metrics", s.DB.GetMetricsPage, s.DB.GetLastNMetrics, func(m database.ServerMetric) interface{} {
+		item := monitoring.ConvertToSystemMetrics(m)
+		item.Bandwidth = s.Config.Server.Bandwidth
+		return item
+	})
+}
+
+func (s *Server) listContainerMetrics(c *fiber.Ctx, convert func(database.ContainerMetric) interface{}) error {
+	appName := c.Query("appName", "")
+	if appName == "" {
+		return c.JSON([]database.ContainerMetric{})
+	}
+
+	page := func(p database.Page) ([]database.ContainerMetric, string, error) {
+		return s.DB.GetContainerMetricsPage(appName, p)
+	}
+	latest := func(limit int) ([]database.ContainerMetric, error) {
+		return s.DB.GetLastNContainerMetrics(appName, limit)
+	}
+	return list(c, "container metrics", page, latest, convert)
+}
+
+func (s *Server) containerMetrics(c *fiber.Ctx) error {
+	return s.listContainerMetrics(c, func(m database.ContainerMetric) interface{} {
+		return containers.NewSample(m)
+	})
+}
+
+// legacyContainerMetrics answers with the values and units reported by
+// docker stats.
+func (s *Server) legacyContainerMetrics(c *fiber.Ctx) error {
+	return s.listContainerMetrics(c, nil)
+}
+
+func (s *Server) containerSeries(c *fiber.Ctx) error {
+	tr, err := database.ParseTimeRange(c.Query("from"), c.Query("to"), c.Query("step"), time.Now())
+	if err != nil {
+		return fail(c, 400, err.Error())
+	}
+
+	services := addServices(nil, strings.Split(c.Query("services"), ",")...)
+
+	if project := c.Query("project"); project != "" {
+		projectServices, err := containers.ResolveProjectServices(s.DB, project)
+		if err != nil {
+			return fail(c, 500, "Error resolving project services: "+err.Error())
+		}
+		services = addServices(services, projectServices...)
+	}
+
+	if len(services) == 0 {
+		return fail(c, 400, "services or project is required")
+	}
+
+	metrics, err := s.DB.GetContainerMetricsInRange(services, tr.Start, tr.End)
+	if err != nil {
+		return fail(c, 500, "Error getting container metrics: "+err.Error())
+	}
+
+	return c.JSON(containers.BuildServiceSeries(metrics, services, tr))
+}
+
+// addServices appends the services not already listed, skipping blanks, so a
+// service given twice or also part of the project is only returned once.
+func addServices(services []string, add ...string) []string {
+	seen := make(map[string]bool, len(services))
+	for _, svc := range services {
+		seen[svc] = true
+	}
+	for _, svc := range add {
+		if svc = strings.TrimSpace(svc); svc != "" && !seen[svc] {
+			seen[svc] = true
+			services = append(services, svc)
+		}
+	}
+	return services
+}
+
+func (s *Server) containerLogMetrics(c *fiber.Ctx) error {
+	appName := c.Query("appName", "")
+	if appName == "" {
+		return c.JSON([]database.ContainerLogMetric{})
+	}
+
+	tr, err := database.ParseTimeRange(c.Query("from"), c.Query("to"), "", time.Now())
+	if err != nil {
+		return fail(c, 400, err.Error())
+	}
+
+	p, paged, err := pageParams(c)
+	if err != nil {
+		return fail(c, 400, err.Error())
+	}
+	if paged {
+		metrics, next, err := s.DB.GetContainerLogMetricsPage(appName, tr.Start, tr.End, p)
+		if err != nil {
+			return fail(c, 500, "Error getting container log metrics: "+err.Error())
+		}
+		return c.JSON(PageResponse{Items: metrics, NextCursor: next})
+	}
+
+	metrics, err := s.DB.GetContainerLogMetricsInRange(appName, tr.Start, tr.End)
+	if err != nil {
+		return fail(c, 500, "Error getting container log metrics: "+err.Error())
+	}
+
+	return c.JSON(metrics)
+}
+
+func (s *Server) logMatches(c *fiber.Ctx) error {
+	return c.JSON(s.LogMonitor.RecentMatches(c.Query("appName", "")))
+}
+
+func (s *Server) containers(c *fiber.Ctx) error {
+	list, err := containers.ListContainers(s.DB)
+	if err != nil {
+		return fail(c, 500, "Error listing containers: "+err.Error())
+	}
+
+	return c.JSON(list)
+}
+
+func (s *Server) services(c *fiber.Ctx) error {
+	list, err := containers.ListContainers(s.DB)
+	if err != nil {
+		return fail(c, 500, "Error listing containers: "+err.Error())
+	}
+
+	return c.JSON(containers.SummarizeServices(list))
+}
+
+func (s *Server) probes(c *fiber.Ctx) error {
+	statuses, err := s.ProbeMonitor.Statuses()
+	if err != nil {
+		return fail(c, 500, "Error getting probe status: "+err.Error())
+	}
+
+	return c.JSON(statuses)
+}
+
+func (s *Server) probeResults(c *fiber.Ctx) error {
+	name := c.Query("name", "")
+	if name == "" {
+		return c.JSON([]database.ProbeResult{})
+	}
+
+	page := func(p database.Page) ([]database.ProbeResult, string, error) {
+		return s.DB.GetProbeResultsPage(name, p)
+	}
+	latest := func(limit int) ([]database.ProbeResult, error) {
+		return s.DB.GetLastNProbeResults(name, limit)
+	}
+	return list(c, "probe results", page, latest, nil)
+}
+
+func (s *Server) certificates(c *fiber.Ctx) error {
+	checks, err := s.DB.GetLatestCertificateChecks()
+	if err != nil {
+		return fail(c, 500, "Error getting certificates: "+err.Error())
+	}
+
+	return c.JSON(checks)
+}
+
+func (s *Server) checks(c *fiber.Ctx) error {
+	return c.JSON(s.CheckRunner.Results())
+}
+
+func (s *Server) checkResults(c *fiber.Ctx) error {
+	name := c.Query("name", "")
+	if name == "" {
+		return c.JSON([]database.CheckResult{})
+	}
+
+	page := func(p database.Page) ([]database.CheckResult, string, error) {
+		return s.DB.GetCheckResultsPage(name, p)
+	}
+	latest := func(limit int) ([]database.CheckResult, error) {
+		return s.DB.GetLastNCheckResults(name, limit)
+	}
+	return list(c, "check results", page, latest, nil)
+}
+
+func (s *Server) activeAlerts(c *fiber.Ctx) error {
+	return c.JSON(monitoring.ActiveAlerts())
+}
+
+func (s *Server) reportSummary(c *fiber.Ctx) error {
+	d, err := database.ParseDuration(c.Query("range", "30d"))
+	if err != nil || d <= 0 {
+		return fail(c, 400, "invalid range "+strconv.Quote(c.Query("range")))
+	}
+
+	end := time.Now()
+	summary, err := reports.Summarize(s.DB, end.Add(-d), end, c.QueryInt("top", reports.DefaultTop))
+	if err != nil {
+		return fail(c, 500, "Error computing report: "+err.Error())
+	}
+
+	return c.JSON(summary)
+}
+
+func (s *Server) reportDigest(c *fiber.Ctx) error {
+	r := c.Query("range", "24h")
+	d, err := database.ParseDuration(r)
+	if err != nil || d <= 0 {
+		return fail(c, 400, "invalid range "+strconv.Quote(r))
+	}
+
+	end := time.Now()
+	digest, err := reports.BuildDigest(s.DB, r, end.Add(-d), end, c.QueryInt("top", reports.DefaultTop))
+	if err != nil {
+		return fail(c, 500, "Error building digest: "+err.Error())
+	}
+	text, html, err := digest.Render()
+	if err != nil {
+		return fail(c, 500, err.Error())
+	}
+
+	switch c.Query("format", "json") {
+	case "text":
+		c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+		return c.SendString(text)
+	case "html":
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.SendString(html)
+	case "json":
+		return c.JSON(reports.WebhookPayload{Name: r, Subject: digest.Subject, Text: text, HTML: html, Summary: digest.Summary})
+	default:
+		return fail(c, 400, "format must be json, text or html")
+	}
+}
+
+func (s *Server) sendDigest(c *fiber.Ctx) error {
+	name := c.Query("name")
+	if name == "" {
+		return fail(c, 400, "name is required")
+	}
+	if !s.Digests.Has(name) {
+		return fail(c, 404, "unknown digest "+strconv.Quote(name))
+	}
+	if err := s.Digests.Send(name); err != nil {
+		return fail(c, 500, "Error sending digest: "+err.Error())
+	}
+	return c.SendStatus(204)
+}
+
+func (s *Server) render(c *fiber.Ctx) error {
+	metric := c.Query("metric")
+	if metric == "" {
+		return fail(c, 400, "metric is required")
+	}
+
+	tr, err := database.ParseTimeRange(c.Query("from"), c.Query("to"), c.Query("step"), time.Now())
+	if err != nil {
+		return fail(c, 400, err.Error())
+	}
+
+	services := addServices(nil, strings.Split(c.Query("services"), ",")...)
+
+	var chart *charts.Chart
+	if len(services) > 0 {
+		chart, err = charts.ContainerChart(s.DB, metric, services, tr)
+	} else {
+		chart, err = charts.ServerChart(s.DB, metric, tr)
+	}
+	if err != nil {
+		return fail(c, 400, err.Error())
+	}
+	chart.Width = clamp(c.QueryInt("width", charts.DefaultWidth), charts.MinWidth, charts.MaxWidth)
+	chart.Height = clamp(c.QueryInt("height", charts.DefaultHeight), charts.MinHeight, charts.MaxHeight)
+
+	switch c.Query("format", "png") {
+	case "png":
+		image, err := chart.PNG()
+		if err != nil {
+			return fail(c, 500, "Error rendering chart: "+err.Error())
+		}
+		c.Set(fiber.HeaderContentType, "image/png")
+		return c.Send(image)
+	case "svg":
+		c.Set(fiber.HeaderContentType, "image/svg+xml")
+		return c.Send(chart.SVG())
+	default:
+		return fail(c, 400, "format must be png or svg")
+	}
+}
+
+func (s *Server) renderLink(c *fiber.Ctx) error {
+	ttl, err := database.ParseDuration(c.Query("ttl", "7d"))
+	if err != nil || ttl <= 0 {
+		return fail(c, 400, "invalid ttl "+strconv.Quote(c.Query("ttl")))
+	}
+	if c.Query("metric") == "" {
+		return fail(c, 400, "metric is required")
+	}
+
+	query := url.Values{}
+	c.Context().QueryArgs().VisitAll(func(k, v []byte) {
+		query.Add(string(k), string(v))
+	})
+	for _, name := range []string{"ttl", "expires", "signature"} {
+		query.Del(name)
+	}
+
+	expires := time.Now().Add(ttl)
+	return c.JSON(RenderLinkResponse{
+		URL:     s.signedLink(Prefix+"/render", query, expires),
+		Expires: expires.UTC().Format(time.RFC3339),
+	})
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+func (s *Server) ingest(c *fiber.Ctx) error {
+	var samples []database.CustomMetric
+	var err error
+	if strings.HasPrefix(c.Get("Content-Type"), "application/json") {
+		samples, err = ingest.ParseJSON(c.Body())
+	} else {
+		samples, err = ingest.ParseLineProtocol(c.Body(), c.Query("precision"))
+	}
+	if err != nil {
+		return fail(c, 400, err.Error())
+	}
+
+	if err := s.DB.SaveCustomMetrics(samples); err != nil {
+		return fail(c, 500, "Error saving metrics: "+err.Error())
+	}
+
+	return c.JSON(IngestResponse{Accepted: len(samples)})
+}
+
+func (s *Server) customMetricNames(c *fiber.Ctx) error {
+	names, err := s.DB.GetCustomMetricNames()
+	if err != nil {
+		return fail(c, 500, "Error getting custom metrics: "+err.Error())
+	}
+	if names == nil {
+		names = []string{}
+	}
+	return c.JSON(names)
+}
+
+func (s *Server) customMetricSeries(c *fiber.Ctx) error {
+	name := c.Query("name", "")
+	if name == "" {
+		return fail(c, 400, "name is required")
+	}
+
+	tr, err := database.ParseTimeRange(c.Query("from"), c.Query("to"), c.Query("step"), time.Now())
+	if err != nil {
+		return fail(c, 400, err.Error())
+	}
+
+	labels := make(map[string]string)
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		if k := string(key); strings.HasPrefix(k, "label.") {
+			labels[strings.TrimPrefix(k, "label.")] = string(value)
+		}
+	})
+
+	metrics, err := s.DB.GetCustomMetricsInRange(name, labels, tr.Start, tr.End)
+	if err != nil {
+		return fail(c, 500, "Error getting custom metrics: "+err.Error())
+	}
+
+	return c.JSON(ingest.BuildSeries(name, metrics, tr))
+}
+
+// legacyCustomMetrics lists the names without the name parameter and
+// answers the series of a metric with it.
+func (s *Server) legacyCustomMetrics(c *fiber.Ctx) error {
+	if c.Query("name", "") == "" {
+		return s.customMetricNames(c)
+	}
+	return s.customMetricSeries(c)
+}
+
+func (s *Server) scrapeTargets(c *fiber.Ctx) error {
+	return c.JSON(s.Scraper.Targets())
+}
+
+func (s *Server) pushStatus(c *fiber.Ctx) error {
+	status, err := s.Pusher.Status()
+	if err != nil {
+		return fail(c, 500, "Error getting push status: "+err.Error())
+	}
+
+	return c.JSON(status)
+}
+
+func (s *Server) pushExport(c *fiber.Ctx) error {
+	after := push.Cursor{
+		Server:     int64(c.QueryInt("server", 0)),
+		Containers: int64(c.QueryInt("containers", 0)),
+	}
+
+	batch, err := s.Pusher.Export(after, c.QueryInt("limit", 0))
+	if err != nil {
+		return fail(c, 500, "Error exporting metrics: "+err.Error())
+	}
+
+	return c.JSON(batch)
+}
+
+func (s *Server) aggregatorPush(c *fiber.Ctx) error {
+	if err := s.Aggregator.Receive(c.BodyRaw()); err != nil {
+		if err == push.ErrBatchTooLarge {
+			return fail(c, 413, err.Error())
+		}
+		return fail(c, 400, err.Error())
+	}
+
+	return c.SendStatus(204)
+}
+
+func (s *Server) aggregatorAgents(c *fiber.Ctx) error {
+	return c.JSON(s.Aggregator.Agents())
+}
+
+func (s *Server) fleetHosts(c *fiber.Ctx) error {
+	hosts, err := s.DB.GetFleetHosts()
+	if err != nil {
+		return fail(c, 500, "Error getting fleet hosts: "+err.Error())
+	}
+
+	return c.JSON(hosts)
+}
+
+func (s *Server) fleetTop(c *fiber.Ctx) error {
+	tr, err := database.ParseTimeRange(c.Query("from"), c.Query("to"), "", time.Now())
+	if err != nil {
+		return fail(c, 400, err.Error())
+	}
+
+	limit := c.QueryInt("limit", 5)
+	if limit <= 0 {
+		limit = 5
+	}
+
+	top, err := s.DB.GetFleetTopHosts(c.Query("metric", "cpu"), tr.Start, tr.End, limit)
+	if err != nil {
+		return fail(c, 400, err.Error())
+	}
+
+	return c.JSON(top)
+}
+
+func (s *Server) fleetServices(c *fiber.Ctx) error {
+	name := c.Query("name")
+	if name == "" {
+		return fail(c, 400, "name is required")
+	}
+
+	tr, err := database.ParseTimeRange(c.Query("from"), "", "", time.Now())
+	if err != nil {
+		return fail(c, 400, err.Error())
+	}
+
+	hosts, err := s.DB.GetFleetServiceHosts(name, tr.Start)
+	if err != nil {
+		return fail(c, 500, "Error getting fleet services: "+err.Error())
+	}
+
+	return c.JSON(hosts)
+}
+
+func (s *Server) agentMetrics(c *fiber.Ctx) error {
+	snapshot := telemetry.Collect(s.RowCounter)
+	if c.Query("format") != "prometheus" {
+		return c.JSON(snapshot)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	if err := telemetry.WritePrometheus(c.Response().BodyWriter(), snapshot); err != nil {
+		return fail(c, 500, "Error writing agent metrics: "+err.Error())
+	}
+	return nil
+}
+
+func (s *Server) getLogging(c *fiber.Ctx) error {
+	return c.JSON(logging.GetLevels())
+}
+
+func (s *Server) putLogging(c *fiber.Ctx) error {
+	var levels logging.Levels
+	if err := c.BodyParser(&levels); err != nil {
+		return fail(c, 400, "Invalid body: "+err.Error())
+	}
+	if err := logging.SetLevels(levels.Level, levels.Components); err != nil {
+		return fail(c, 400, err.Error())
+	}
+
+	logger.Info("Log levels changed", "level", levels.Level, "components", levels.Components)
+	return c.JSON(logging.GetLevels())
+}
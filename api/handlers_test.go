@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/monitoring"
+)
+
+// testServer registers the routes over a new database in a temporary
+// directory, without authentication.
+func testServer(t *testing.T) (*fiber.App, *database.DB) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	db, err := database.InitDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	cfg := &config.Config{}
+	cfg.Server.Bandwidth = 100
+	s := &Server{DB: db, Config: cfg}
+	app := fiber.New()
+	if err := s.Register(app, func(c *fiber.Ctx) error { return c.Next() }); err != nil {
+		t.Fatal(err)
+	}
+	return app, db
+}
+
+func TestLegacyServerMetrics(t *testing.T) {
+	app, db := testServer(t)
+	start := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		metric := database.ServerMetric{
+			Timestamp: start.Add(time.Duration(i) * time.Minute).Format(time.RFC3339Nano),
+			CPU:       float64(i) + 0.5,
+		}
+		if err := db.SaveMetric(metric); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		limit string
+		cpu   []string
+	}{
+		{"all", []string{"0.50", "1.50", "2.50"}},
+		{"2", []string{"1.50", "2.50"}},
+	} {
+		resp, err := app.Test(httptest.NewRequest("GET", "/metrics?limit="+tc.limit, nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var metrics []monitoring.SystemMetrics
+		err = json.NewDecoder(resp.Body).Decode(&metrics)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("limit %s: %v", tc.limit, err)
+		}
+
+		if resp.Header.Get("Deprecation") != "true" {
+			t.Errorf("limit %s: got no Deprecation header", tc.limit)
+		}
+		if len(metrics) != len(tc.cpu) {
+			t.Fatalf("limit %s: got %d metrics, want %d", tc.limit, len(metrics), len(tc.cpu))
+		}
+		for i, m := range metrics {
+			if m.CPU != tc.cpu[i] || m.Bandwidth != 100 {
+				t.Errorf("limit %s: got metric %d with cpu %q and bandwidth %d, want %q and 100", tc.limit, i, m.CPU, m.Bandwidth, tc.cpu[i])
+			}
+		}
+	}
+}
+
+func TestAddServices(t *testing.T) {
+	services := addServices(nil, strings.Split(" api,worker,,api ", ",")...)
+	services = addServices(services, "worker", "web")
+	if want := []string{"api", "worker", "web"}; !reflect.DeepEqual(services, want) {
+		t.Errorf("got services %q, want %q", services, want)
+	}
+}
@@ -0,0 +1,276 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// The OpenAPI document is generated from the route table and the Go types
+// the handlers respond with, so it cannot drift from the code.
+
+type object = map[string]interface{}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemas builds the component schemas of the Go types used by the routes.
+type schemas struct {
+	components object
+	names      map[reflect.Type]string
+	taken      map[string]reflect.Type
+}
+
+func newSchemas() *schemas {
+	return &schemas{
+		components: object{},
+		names:      make(map[reflect.Type]string),
+		taken:      make(map[string]reflect.Type),
+	}
+}
+
+// of returns the schema of t, a reference for named structs.
+func (s *schemas) of(t reflect.Type) object {
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := s.of(t.Elem())
+		// OpenAPI 3.0 ignores the siblings of a reference.
+		if _, ok := schema["$ref"]; ok {
+			return object{"allOf": []object{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return object{"type": "integer", "format": "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return object{"type": "integer", "format": "int32"}
+	case reflect.Float32:
+		return object{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return object{"type": "number", "format": "double"}
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return object{"type": "string", "format": "byte"}
+		}
+		return object{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return object{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return object{"type": "string", "format": "date-time"}
+		}
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return object{"$ref": "#/components/schemas/" + s.name(t)}
+	default:
+		// Interfaces accept any value.
+		return object{}
+	}
+}
+
+// name registers the schema of a named struct. Types of different packages
+// sharing a name are told apart by prefixing the package.
+func (s *schemas) name(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if other, ok := s.taken[name]; ok && other != t {
+		pkg := t.PkgPath()
+		pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+		name = string(unicode.ToUpper(rune(pkg[0]))) + pkg[1:] + name
+	}
+	// Recorded before building the schema so recursive types terminate.
+	s.names[t] = name
+	s.taken[name] = t
+	s.components[name] = s.object(t)
+	return name
+}
+
+func (s *schemas) object(t reflect.Type) object {
+	properties := object{}
+	var required []string
+	s.fields(t, properties, &required)
+
+	schema := object{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// fields adds the JSON fields of t, following the rules of encoding/json:
+// embedded structs without a name are inlined and omitempty fields may be
+// absent.
+func (s *schemas) fields(t reflect.Type, properties object, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s.fields(ft, properties, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		properties[name] = s.of(f.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// document returns the OpenAPI document of the versioned routes.
+func document(routes []route) object {
+	s := newSchemas()
+	errorSchema := s.of(reflect.TypeOf(ErrorResponse{}))
+
+	paths := object{}
+	for _, r := range routes {
+		op := object{
+			"summary":     r.summary,
+			"operationId": operationID(r.method, r.path),
+			"tags":        []string{r.tag},
+		}
+		if r.description != "" {
+			op["description"] = r.description
+		}
+		if r.public {
+			op["security"] = []object{}
+		}
+
+		routeParams := append([]param{}, r.params...)
+		if r.paged {
+			routeParams = append(routeParams, afterParam, pageSizeParam)
+		}
+		if len(routeParams) > 0 {
+			params := make([]object, 0, len(routeParams))
+			for _, p := range routeParams {
+				params = append(params, object{
+					"name":        p.name,
+					"in":          "query",
+					"description": p.description,
+					"required":    p.required,
+					"schema":      object{"type": p.typ},
+				})
+			}
+			op["parameters"] = params
+		}
+
+		if r.body != nil {
+			content := object{
+				"application/json": object{"schema": s.of(reflect.TypeOf(r.body))},
+			}
+			if r.text {
+				content["text/plain"] = object{"schema": object{"type": "string"}}
+			}
+			op["requestBody"] = object{"required": true, "content": content}
+		}
+
+		responses := object{
+			"default": object{
+				"description": "Error",
+				"content":     object{"application/json": object{"schema": errorSchema}},
+			},
+		}
+		if r.response == nil {
+			responses["204"] = object{"description": http.StatusText(http.StatusNoContent)}
+		} else {
+			schema := s.of(reflect.TypeOf(r.response))
+			if r.paged {
+				schema = object{"oneOf": []object{schema, pageSchema(schema)}}
+			}
+			content := object{
+				"application/json": object{"schema": schema},
+			}
+			if r.image {
+				binary := object{"schema": object{"type": "string", "format": "binary"}}
+				content = object{"image/png": binary, "image/svg+xml": binary}
+			}
+			if r.text && r.body == nil {
+				content["text/plain"] = object{"schema": object{"type": "string"}}
+			}
+			responses["200"] = object{"description": http.StatusText(http.StatusOK), "content": content}
+		}
+		op["responses"] = responses
+
+		item, ok := paths[Prefix+r.path].(object)
+		if !ok {
+			item = object{}
+			paths[Prefix+r.path] = item
+		}
+		item[strings.ToLower(r.method)] = op
+	}
+
+	return object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":       "Monitoring agent API",
+			"version":     "1",
+			"description": "Metrics, alerts and checks collected by the monitoring agent. The unversioned routes of earlier releases are still served but deprecated.",
+		},
+		"servers":  []object{{"url": "/"}},
+		"security": []object{{"bearerAuth": []string{}}},
+		"paths":    paths,
+		"components": object{
+			"schemas": s.components,
+			"securitySchemes": object{
+				"bearerAuth": object{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// pageSchema is the schema of a PageResponse of the items of list.
+func pageSchema(list object) object {
+	return object{
+		"type": "object",
+		"properties": object{
+			"items":       list,
+			"next_cursor": object{"type": "string"},
+		},
+		"required": []string{"items"},
+	}
+}
+
+// operationID derives an identifier such as getMetricsContainersBatch from
+// the method and path of a route.
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	upper := true
+	for _, r := range path {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+// PageResponse is the response of the list endpoints when after or
+// page_size is given. NextCursor is passed as after to get the next page and
+// is absent on the last page.
+type PageResponse struct {
+	Items      interface{} `json:"items"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// pageParams returns the page requested with after and page_size, and
+// whether one was requested at all.
+func pageParams(c *fiber.Ctx) (database.Page, bool, error) {
+	after, size := c.Query("after"), c.Query("page_size")
+	if after == "" && size == "" {
+		return database.Page{}, false, nil
+	}
+
+	p := database.Page{Size: database.DefaultPageSize}
+	if size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n <= 0 {
+			return p, true, fmt.Errorf("invalid page_size %q", size)
+		}
+		p.Size = n
+		if p.Size > database.MaxPageSize {
+			p.Size = database.MaxPageSize
+		}
+	}
+
+	var err error
+	p.After, err = database.ParsePageCursor(after, time.Now())
+	return p, true, err
+}
+
+// list answers a list endpoint in one of three ways: a page with after or
+// page_size, every row with limit=all, or the latest rows (50 by default).
+// convert maps the stored rows to the response type; nil keeps them as is.
+func list[T any](c *fiber.Ctx, what string, page func(database.Page) ([]T, string, error), latest func(limit int) ([]T, error), convert func(T) interface{}) error {
+	if convert == nil {
+		convert = func(item T) interface{} { return item }
+	}
+
+	p, paged, err := pageParams(c)
+	if err != nil {
+		return fail(c, 400, err.Error())
+	}
+	if paged {
+		items, next, err := page(p)
+		if err != nil {
+			return fail(c, 500, "Error getting "+what+": "+err.Error())
+		}
+		return c.JSON(PageResponse{Items: convertAll(items, convert), NextCursor: next})
+	}
+
+	limit, all := queryLimit(c)
+	if all {
+		return streamPages(c, what, page, convert)
+	}
+
+	items, err := latest(limit)
+	if err != nil {
+		return fail(c, 500, "Error getting "+what+": "+err.Error())
+	}
+	return c.JSON(convertAll(items, convert))
+}
+
+func convertAll[T any](items []T, convert func(T) interface{}) []interface{} {
+	result := make([]interface{}, len(items))
+	for i, item := range items {
+		result[i] = convert(item)
+	}
+	return result
+}
+
+// streamPages writes every row as a JSON array while reading them one page
+// at a time, so neither the rows nor the response are held in memory and the
+// database is not locked for the whole transfer. Once the status is sent an
+// error can only cut the response short, which leaves the JSON invalid.
+func streamPages[T any](c *fiber.Ctx, what string, page func(database.Page) ([]T, string, error), convert func(T) interface{}) error {
+	p := database.Page{Size: database.MaxPageSize}
+	items, next, err := page(p)
+	if err != nil {
+		return fail(c, 500, "Error getting "+what+": "+err.Error())
+	}
+
+	path := c.Path()
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		w.WriteString("[")
+		first := true
+		for {
+			for _, item := range items {
+				if !first {
+					w.WriteString(",")
+				}
+				first = false
+				b, err := json.Marshal(convert(item))
+				if err != nil {
+					logger.Error("Error streaming response", "path", path, "error", err)
+					return
+				}
+				w.Write(b)
+			}
+			if next == "" {
+				break
+			}
+			// Fails when the client went away.
+			if err := w.Flush(); err != nil {
+				return
+			}
+
+			p.After = next
+			if items, next, err = page(p); err != nil {
+				logger.Error("Error streaming response", "path", path, "error", err)
+				return
+			}
+		}
+		w.WriteString("]")
+	})
+	return nil
+}
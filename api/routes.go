@@ -0,0 +1,217 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/mauriciogm/dokploy/apps/monitoring/aggregator"
+	"github.com/mauriciogm/dokploy/apps/monitoring/containers"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/health"
+	"github.com/mauriciogm/dokploy/apps/monitoring/ingest"
+	"github.com/mauriciogm/dokploy/apps/monitoring/logging"
+	"github.com/mauriciogm/dokploy/apps/monitoring/probes"
+	"github.com/mauriciogm/dokploy/apps/monitoring/push"
+	"github.com/mauriciogm/dokploy/apps/monitoring/reports"
+	"github.com/mauriciogm/dokploy/apps/monitoring/scrape"
+	"github.com/mauriciogm/dokploy/apps/monitoring/telemetry"
+)
+
+// route describes an endpoint both for the router and for the OpenAPI
+// document.
+type route struct {
+	method      string
+	path        string
+	tag         string
+	summary     string
+	description string
+	params      []param
+	// body and response are values of the request and response types; a nil
+	// response means 204 No Content.
+	body     interface{}
+	response interface{}
+	// text is set when the body or the response may also be plain text.
+	text bool
+	// image responses are a PNG or SVG image instead of JSON.
+	image bool
+	// paged lists accept after and page_size, answering a PageResponse of
+	// the response items.
+	paged bool
+
+	public bool
+	// signed routes also accept links signed by /render/link instead of a
+	// token.
+	signed     bool
+	aggregator bool
+	handler    fiber.Handler
+
+	// legacy answers the unversioned path when its response differs from
+	// the versioned one. versionOnly routes have no unversioned path.
+	legacy      fiber.Handler
+	versionOnly bool
+}
+
+type param struct {
+	name        string
+	typ         string
+	description string
+	required    bool
+}
+
+var (
+	fromParam = param{name: "from", typ: "string", description: "Start of the range: an RFC3339 timestamp, unix seconds, now or a duration before now such as -6h or 7d (default: one hour ago)"}
+	toParam   = param{name: "to", typ: "string", description: "End of the range, in the same formats as from (default: now)"}
+	stepParam = param{name: "step", typ: "string", description: "Bucket width such as 30s or 5m (default: the range split into 120 buckets)"}
+)
+
+var (
+	afterParam    = param{name: "after", typ: "string", description: "Cursor of the page: the next_cursor of the previous page, or a time in the formats of from to start after it"}
+	pageSizeParam = param{name: "page_size", typ: "integer", description: "Rows per page, at most 1000 (default: 100)"}
+)
+
+func limitParam(def string) param {
+	return param{name: "limit", typ: "string", description: "Number of latest rows, or all to stream every row (default: " + def + "); ignored when a page is requested"}
+}
+
+func (s *Server) routes() []route {
+	return []route{
+		{method: fiber.MethodGet, path: "/health", tag: "health", summary: "Check that the agent is up",
+			response: StatusResponse{}, public: true, handler: s.health},
+		{method: fiber.MethodGet, path: "/health/live", tag: "health", summary: "Liveness check",
+			description: "Only tells that the process serves requests; orchestrators should not restart the agent because a dependency is down.",
+			response:    StatusResponse{}, public: true, handler: s.health},
+		{method: fiber.MethodGet, path: "/health/ready", tag: "health", summary: "Readiness check of the database, disk, Docker and collectors",
+			description: "Responds 503 with the same report when a check fails.",
+			response:    health.Report{}, public: true, handler: s.ready},
+		{method: fiber.MethodGet, path: "/openapi.json", tag: "meta", summary: "This document",
+			response: object{}, public: true, versionOnly: true, handler: s.openAPI},
+
+		{method: fiber.MethodGet, path: "/metrics", tag: "server", summary: "Latest host metrics, oldest first",
+			params:   []param{limitParam("50")},
+			response: []ServerMetricResponse{}, paged: true, handler: s.serverMetrics, legacy: s.legacyServerMetrics},
+		{method: fiber.MethodGet, path: "/metrics/containers", tag: "containers", summary: "Latest metrics of the containers of a service",
+			description: "Values are in base units. The last -suffix of appName is dropped to get the service, so myapp-xyz123 reads the containers of myapp. " +
+				"A container belongs to a service when its name is the service name, alone or followed by -, _ or . (app matches app-1 and app.1.xyz, not app2-1).",
+			params:   []param{{name: "appName", typ: "string", description: "Dokploy app name; the response is empty without it"}, limitParam("50")},
+			response: []containers.Sample{}, paged: true, handler: s.containerMetrics, legacy: s.legacyContainerMetrics},
+		{method: fiber.MethodGet, path: "/metrics/containers/batch", tag: "containers", summary: "Metrics of several services averaged into aligned buckets",
+			description: "A container belongs to a service when its name is the service name, alone or followed by -, _ or . (app matches app-1 and app.1.xyz, not app2-1). " +
+				"A service listed twice or also part of the project is returned once; buckets without samples are null.",
+			params: []param{
+				{name: "services", typ: "string", description: "Comma-separated services"},
+				{name: "project", typ: "string", description: "Compose project or swarm stack whose services are added"},
+				fromParam, toParam, stepParam,
+			},
+			response: containers.SeriesResponse{}, handler: s.containerSeries},
+		{method: fiber.MethodGet, path: "/metrics/containers/logs", tag: "containers", summary: "Log pattern match counts of a service per interval",
+			params:   []param{{name: "appName", typ: "string", description: "Dokploy app name, matched as in /metrics/containers; the response is empty without it"}, fromParam, toParam},
+			response: []database.ContainerLogMetric{}, paged: true, handler: s.containerLogMetrics},
+		{method: fiber.MethodGet, path: "/containers/logs/matches", tag: "containers", summary: "Most recent matching log lines kept in memory",
+			params:   []param{{name: "appName", typ: "string", description: "Dokploy app name, matched as in /metrics/containers (default: every service)"}},
+			response: []containers.LogMatch{}, handler: s.logMatches},
+		{method: fiber.MethodGet, path: "/containers", tag: "containers", summary: "Running containers with their service, labels and last sample",
+			response: []containers.ContainerInfo{}, handler: s.containers},
+		{method: fiber.MethodGet, path: "/services", tag: "containers", summary: "Running containers summarized per service",
+			response: []containers.ServiceSummary{}, handler: s.services},
+
+		{method: fiber.MethodGet, path: "/probes", tag: "probes", summary: "Status, last result and uptime of every probe",
+			response: []probes.ProbeStatus{}, handler: s.probes},
+		{method: fiber.MethodGet, path: "/probes/results", tag: "probes", summary: "Latest results of a probe",
+			params:   []param{{name: "name", typ: "string", description: "Probe; the response is empty without it"}, limitParam("50")},
+			response: []database.ProbeResult{}, paged: true, handler: s.probeResults},
+		{method: fiber.MethodGet, path: "/certificates", tag: "probes", summary: "Latest check of every monitored certificate",
+			response: []database.CertificateCheck{}, handler: s.certificates},
+		{method: fiber.MethodGet, path: "/checks", tag: "checks", summary: "Latest result of every custom check",
+			response: map[string]*database.CheckResult{}, handler: s.checks},
+		{method: fiber.MethodGet, path: "/checks/results", tag: "checks", summary: "Latest results of a check",
+			params:   []param{{name: "name", typ: "string", description: "Check; the response is empty without it"}, limitParam("50")},
+			response: []database.CheckResult{}, paged: true, handler: s.checkResults},
+		{method: fiber.MethodGet, path: "/alerts/active", tag: "alerts", summary: "Alerts whose condition still holds",
+			response: []database.ActiveAlert{}, handler: s.activeAlerts},
+		{method: fiber.MethodGet, path: "/reports/summary", tag: "reports", summary: "Statistics of the host, alerts, probes and containers over a range",
+			description: "Computed from the stored history, so the range is bounded by the retention period.",
+			params: []param{
+				{name: "range", typ: "string", description: "Duration before now such as 24h or 7d (default: 30d)"},
+				{name: "top", typ: "integer", description: "Number of containers ranked by CPU and by memory (default: 5)"},
+			},
+			response: reports.Summary{}, versionOnly: true, handler: s.reportSummary},
+		{method: fiber.MethodGet, path: "/reports/digest", tag: "reports", summary: "Preview a digest report over a range",
+			description: "With format=html the HTML version is returned as text/html.",
+			params: []param{
+				{name: "range", typ: "string", description: "Duration before now such as 24h or 7d (default: 24h)"},
+				{name: "top", typ: "integer", description: "Number of containers ranked by CPU and by memory (default: 5)"},
+				{name: "format", typ: "string", description: "json, text or html (default: json, the body posted to digest webhooks)"},
+			},
+			response: reports.WebhookPayload{}, text: true, versionOnly: true, handler: s.reportDigest},
+		{method: fiber.MethodPost, path: "/reports/send", tag: "reports", summary: "Send a configured digest now",
+			params:      []param{{name: "name", typ: "string", description: "Digest", required: true}},
+			versionOnly: true, handler: s.sendDigest},
+
+		{method: fiber.MethodGet, path: "/render", tag: "charts", summary: "Chart of a host or container metric over a range",
+			description: "Without services the metric is a host metric: cpu, memory, disk, upload or download. With services it is a container metric, one line per service: cpu, memory, memoryUsed, networkIn, networkOut, blockRead or blockWrite. " +
+				"Links returned by /render/link are accepted without a token until they expire.",
+			params: []param{
+				{name: "metric", typ: "string", description: "Metric to draw", required: true},
+				{name: "services", typ: "string", description: "Comma-separated services, matched as in /metrics/containers/batch"},
+				fromParam, toParam, stepParam,
+				{name: "format", typ: "string", description: "png or svg (default: png)"},
+				{name: "width", typ: "integer", description: "Width in pixels, from 200 to 4000 (default: 800)"},
+				{name: "height", typ: "integer", description: "Height in pixels, from 100 to 2000 (default: 300)"},
+				{name: "expires", typ: "integer", description: "Expiry of a signed link, in unix seconds"},
+				{name: "signature", typ: "string", description: "Signature of a link returned by /render/link"},
+			},
+			response: "", image: true, signed: true, versionOnly: true, handler: s.render},
+		{method: fiber.MethodGet, path: "/render/link", tag: "charts", summary: "Signed link to a chart that can be opened without a token",
+			description: "Takes the parameters of /render and returns its path with them and a signature, e.g. to embed the chart in an alert or a chat message. Relative times are evaluated when the link is opened.",
+			params: []param{
+				{name: "ttl", typ: "string", description: "Validity of the link such as 24h or 30d (default: 7d)"},
+			},
+			response: RenderLinkResponse{}, versionOnly: true, handler: s.renderLink},
+
+		{method: fiber.MethodPost, path: "/ingest", tag: "custom", summary: "Record custom metrics",
+			description: "Accepts a sample or an array of samples as JSON, or InfluxDB line protocol with any other content type.",
+			params:      []param{{name: "precision", typ: "string", description: "Timestamp precision of line protocol: ns, us, ms or s (default: ns)"}},
+			body:        []IngestSample{}, text: true,
+			response: IngestResponse{}, handler: s.ingest},
+		{method: fiber.MethodGet, path: "/metrics/custom", tag: "custom", summary: "Names of the stored custom metrics",
+			response: []string{}, handler: s.customMetricNames, legacy: s.legacyCustomMetrics},
+		{method: fiber.MethodGet, path: "/metrics/custom/series", tag: "custom", summary: "Custom metric averaged into aligned buckets, one series per label set",
+			description: "Parameters named label.<key> only keep the samples whose label has the given value.",
+			params:      []param{{name: "name", typ: "string", description: "Metric", required: true}, fromParam, toParam, stepParam},
+			response:    ingest.SeriesResponse{}, versionOnly: true, handler: s.customMetricSeries},
+		{method: fiber.MethodGet, path: "/scrape/targets", tag: "custom", summary: "Prometheus scrape targets with the result of their last scrape",
+			response: []scrape.TargetStatus{}, handler: s.scrapeTargets},
+
+		{method: fiber.MethodGet, path: "/push/status", tag: "push", summary: "Push cursor, buffered batches and last push result",
+			response: push.Status{}, handler: s.pushStatus},
+		{method: fiber.MethodGet, path: "/push/export", tag: "push", summary: "Metrics stored after the given rows, in the push batch format",
+			params: []param{
+				{name: "server", typ: "integer", description: "Last server metrics row already read"},
+				{name: "containers", typ: "integer", description: "Last container metrics row already read"},
+				{name: "limit", typ: "integer", description: "Rows per table"},
+			},
+			response: push.Batch{}, handler: s.pushExport},
+		{method: fiber.MethodPost, path: "/aggregator/push", tag: "fleet", summary: "Receive a batch from an agent in push mode",
+			body: push.Batch{}, aggregator: true, handler: s.aggregatorPush},
+		{method: fiber.MethodGet, path: "/aggregator/agents", tag: "fleet", summary: "Pull cursor and last result of every configured agent",
+			response: []aggregator.AgentStatus{}, aggregator: true, handler: s.aggregatorAgents},
+		{method: fiber.MethodGet, path: "/fleet/hosts", tag: "fleet", summary: "Hosts known to the aggregator with their last sample",
+			response: []database.FleetHost{}, aggregator: true, handler: s.fleetHosts},
+		{method: fiber.MethodGet, path: "/fleet/top", tag: "fleet", summary: "Hosts ranked by the average of a server metric",
+			params: []param{
+				{name: "metric", typ: "string", description: "cpu, memory, disk, upload or download (default: cpu)"},
+				{name: "limit", typ: "integer", description: "Number of hosts (default: 5)"},
+				fromParam, toParam,
+			},
+			response: []database.FleetHostValue{}, aggregator: true, handler: s.fleetTop},
+		{method: fiber.MethodGet, path: "/fleet/services", tag: "fleet", summary: "Hosts that reported containers of a service",
+			params:   []param{{name: "name", typ: "string", description: "Service", required: true}, fromParam},
+			response: []database.FleetServiceHost{}, aggregator: true, handler: s.fleetServices},
+
+		{method: fiber.MethodGet, path: "/metrics/agent", tag: "agent", summary: "Internal metrics of the agent",
+			params:   []param{{name: "format", typ: "string", description: "json or prometheus (default: json)"}},
+			response: telemetry.Snapshot{}, text: true, handler: s.agentMetrics},
+		{method: fiber.MethodGet, path: "/logging", tag: "agent", summary: "Global and per-component log levels",
+			response: logging.Levels{}, handler: s.getLogging},
+		{method: fiber.MethodPut, path: "/logging", tag: "agent", summary: "Change the log levels at runtime",
+			body: logging.Levels{}, response: logging.Levels{}, handler: s.putLogging},
+	}
+}
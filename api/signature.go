@@ -0,0 +1,56 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// sign returns the signature of a request to path with the query, which
+// must not hold a signature itself. The key is server.token, so changing it
+// revokes every link.
+func (s *Server) sign(path string, query url.Values) string {
+	mac := hmac.New(sha256.New, []byte(s.Config.Server.Token))
+	// Encode sorts the parameters, so their order in the link is irrelevant.
+	mac.Write([]byte(path + "?" + query.Encode()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signedLink returns path with query, an expiry and their signature.
+func (s *Server) signedLink(path string, query url.Values, expires time.Time) string {
+	query.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	query.Set("signature", s.sign(path, query))
+	return path + "?" + query.Encode()
+}
+
+// signedOrAuth accepts the requests whose query carries a valid signature
+// that has not expired, for links embedded where no Authorization header can
+// be sent, and authenticates the others with auth.
+func (s *Server) signedOrAuth(auth fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		signature := c.Query("signature")
+		if signature == "" {
+			return auth(c)
+		}
+
+		query := url.Values{}
+		c.Context().QueryArgs().VisitAll(func(k, v []byte) {
+			query.Add(string(k), string(v))
+		})
+		query.Del("signature")
+
+		expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+		if err != nil || time.Now().Unix() > expires {
+			return fail(c, 401, "link expired")
+		}
+		if !hmac.Equal([]byte(signature), []byte(s.sign(c.Path(), query))) {
+			return fail(c, 401, "invalid signature")
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,190 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+const dialTimeout = 10 * time.Second
+
+// CheckHost connects to a TLS endpoint and inspects the leaf certificate it
+// presents. Certificates that fail verification are still reported.
+func CheckHost(ctx context.Context, host config.CertificateHost) database.CertificateCheck {
+	port := host.Port
+	if port == 0 {
+		port = 443
+	}
+	serverName := host.ServerName
+	if serverName == "" {
+		serverName = host.Host
+	}
+	address := net.JoinHostPort(host.Host, strconv.Itoa(port))
+
+	check := database.CertificateCheck{
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Source:    "host",
+		Target:    address,
+	}
+
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: dialTimeout},
+		Config: &tls.Config{
+			ServerName:         serverName,
+			InsecureSkipVerify: true,
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	defer conn.Close()
+
+	chain := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(chain) == 0 {
+		check.Error = "no certificate presented"
+		return check
+	}
+
+	describe(&check, chain[0])
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err = chain[0].Verify(x509.VerifyOptions{
+		DNSName:       serverName,
+		Intermediates: intermediates,
+	})
+	verified := err == nil
+	check.Verified = &verified
+	if err != nil {
+		check.Error = err.Error()
+	}
+
+	return check
+}
+
+// CheckFile inspects the first certificate of a PEM file.
+func CheckFile(path string) database.CertificateCheck {
+	check := database.CertificateCheck{
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Source:    "file",
+		Target:    path,
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+
+	cert, err := parseLeaf(data)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	describe(&check, cert)
+	return check
+}
+
+// acmeStore is the layout of Traefik's acme.json: one entry per certificate
+// resolver.
+type acmeStore map[string]struct {
+	Certificates []struct {
+		Domain struct {
+			Main string   `json:"main"`
+			SANs []string `json:"sans"`
+		} `json:"domain"`
+		Certificate string `json:"certificate"`
+	} `json:"Certificates"`
+}
+
+// CheckAcmeFile inspects every certificate stored in a Traefik acme.json file.
+func CheckAcmeFile(path string) ([]database.CertificateCheck, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var store acmeStore
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+
+	resolvers := make([]string, 0, len(store))
+	for resolver := range store {
+		resolvers = append(resolvers, resolver)
+	}
+	sort.Strings(resolvers)
+
+	timestamp := time.Now().UTC().Format(time.RFC3339Nano)
+	var checks []database.CertificateCheck
+	for _, resolver := range resolvers {
+		for _, entry := range store[resolver].Certificates {
+			check := database.CertificateCheck{
+				Timestamp: timestamp,
+				Source:    "acme",
+				Target:    resolver + "/" + entry.Domain.Main,
+			}
+
+			pemData, err := base64.StdEncoding.DecodeString(entry.Certificate)
+			if err != nil {
+				check.Error = fmt.Sprintf("error decoding certificate: %v", err)
+				checks = append(checks, check)
+				continue
+			}
+
+			cert, err := parseLeaf(pemData)
+			if err != nil {
+				check.Error = err.Error()
+			} else {
+				describe(&check, cert)
+			}
+			checks = append(checks, check)
+		}
+	}
+
+	return checks, nil
+}
+
+func parseLeaf(data []byte) (*x509.Certificate, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("no certificate found")
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+func describe(check *database.CertificateCheck, cert *x509.Certificate) {
+	check.Subject = cert.Subject.CommonName
+	check.Issuer = cert.Issuer.CommonName
+	if check.Issuer == "" {
+		check.Issuer = cert.Issuer.String()
+	}
+	check.SANs = append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		check.SANs = append(check.SANs, ip.String())
+	}
+	check.NotBefore = cert.NotBefore.UTC().Format(time.RFC3339)
+	check.NotAfter = cert.NotAfter.UTC().Format(time.RFC3339)
+	check.DaysUntilExpiry = time.Until(cert.NotAfter).Hours() / 24
+}
@@ -0,0 +1,178 @@
+package certs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/logging"
+	"github.com/mauriciogm/dokploy/apps/monitoring/monitoring"
+	"github.com/mauriciogm/dokploy/apps/monitoring/telemetry"
+)
+
+var logger = logging.New("certificates")
+
+const defaultRefreshRate = 3600
+
+var defaultThresholds = []int{30, 14, 7}
+
+// CertificateMonitor periodically checks the configured certificates and
+// alerts when one gets closer to its expiry than a configured threshold.
+type CertificateMonitor struct {
+	db         *database.DB
+	thresholds []int
+	mu         sync.Mutex
+	// alerted holds the lowest threshold already alerted for a target and
+	// certificate expiry date, so renewals reset the alerts.
+	alerted  map[string]int
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	// ctx is cancelled on Stop to abort the connections in progress.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewCertificateMonitor(db *database.DB) (*CertificateMonitor, error) {
+	if err := db.InitCertificateChecksTable(); err != nil {
+		return nil, fmt.Errorf("failed to initialize certificate checks table: %v", err)
+	}
+
+	thresholds := append([]int{}, config.GetMetricsConfig().Certificates.Thresholds...)
+	if len(thresholds) == 0 {
+		thresholds = defaultThresholds
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(thresholds)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &CertificateMonitor{
+		db:         db,
+		thresholds: thresholds,
+		alerted:    make(map[string]int),
+		stopChan:   make(chan struct{}),
+		ctx:        ctx,
+		cancel:     cancel,
+	}, nil
+}
+
+func (cm *CertificateMonitor) Start() error {
+	cfg := config.GetMetricsConfig().Certificates
+	if len(cfg.Hosts) == 0 && len(cfg.Files) == 0 && cfg.AcmeFile == "" {
+		return nil
+	}
+
+	refreshRate := cfg.RefreshRate
+	if refreshRate <= 0 {
+		refreshRate = defaultRefreshRate
+	}
+
+	telemetry.Expect("certificates", time.Duration(refreshRate)*time.Second)
+	ticker := time.NewTicker(time.Duration(refreshRate) * time.Second)
+	cm.wg.Add(1)
+	go func() {
+		defer cm.wg.Done()
+		cm.checkAll()
+		for {
+			select {
+			case <-ticker.C:
+				cm.checkAll()
+			case <-cm.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (cm *CertificateMonitor) Stop() {
+	close(cm.stopChan)
+	cm.cancel()
+	cm.wg.Wait()
+}
+
+func (cm *CertificateMonitor) checkAll() {
+	cfg := config.GetMetricsConfig().Certificates
+	cycle := telemetry.StartCycle("certificates")
+	defer cycle.Done()
+
+	var checks []database.CertificateCheck
+	for _, host := range cfg.Hosts {
+		checks = append(checks, CheckHost(cm.ctx, host))
+	}
+	if cm.ctx.Err() != nil {
+		// Interrupted by Stop, the failed connections say nothing about the
+		// certificates.
+		return
+	}
+	for _, file := range cfg.Files {
+		checks = append(checks, CheckFile(file))
+	}
+	if cfg.AcmeFile != "" {
+		acmeChecks, err := CheckAcmeFile(cfg.AcmeFile)
+		if err != nil {
+			logger.Error("Error reading acme file", "file", cfg.AcmeFile, "error", err)
+			cycle.Error()
+		}
+		checks = append(checks, acmeChecks...)
+	}
+
+	for _, check := range checks {
+		if err := cm.db.SaveCertificateCheck(check); err != nil {
+			logger.Error("Error saving certificate check", "target", check.Target, "error", err)
+			cycle.Error()
+		}
+		if check.NotAfter == "" {
+			logger.Warn("Error checking certificate", "target", check.Target, "error", check.Error)
+			continue
+		}
+		cm.checkThresholds(check)
+	}
+}
+
+func (cm *CertificateMonitor) checkThresholds(check database.CertificateCheck) {
+	// Thresholds are sorted from the largest to the smallest, so the last
+	// one crossed is the most urgent.
+	crossed := -1
+	for _, threshold := range cm.thresholds {
+		if check.DaysUntilExpiry <= float64(threshold) {
+			crossed = threshold
+		}
+	}
+	if crossed < 0 {
+		monitoring.ResolveAlert("certificate:" + check.Target)
+		return
+	}
+
+	message := fmt.Sprintf("Certificate %s (%s) expires in %.1f days on %s", check.Target, check.Subject, check.DaysUntilExpiry, check.NotAfter)
+	if check.DaysUntilExpiry < 0 {
+		message = fmt.Sprintf("Certificate %s (%s) expired on %s", check.Target, check.Subject, check.NotAfter)
+	}
+
+	alert := monitoring.AlertPayload{
+		Type:      "Certificate",
+		Value:     check.DaysUntilExpiry,
+		Threshold: float64(crossed),
+		Message:   message,
+		Timestamp: check.Timestamp,
+	}
+	monitoring.RaiseAlert("certificate:"+check.Target, alert)
+
+	key := check.Target + "|" + check.NotAfter
+	cm.mu.Lock()
+	previous, ok := cm.alerted[key]
+	if ok && previous <= crossed {
+		cm.mu.Unlock()
+		return
+	}
+	cm.alerted[key] = crossed
+	cm.mu.Unlock()
+
+	if err := monitoring.SendAlert(alert); err != nil {
+		logger.Error("Error sending certificate alert", "target", check.Target, "error", err)
+	}
+}
@@ -0,0 +1,214 @@
+package charts
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultWidth  = 800
+	DefaultHeight = 300
+	MinWidth      = 200
+	MinHeight     = 100
+	MaxWidth      = 4000
+	MaxHeight     = 2000
+
+	marginLeft   = 56
+	marginRight  = 24
+	marginTop    = 32
+	marginBottom = 24
+	yTicks       = 4
+	maxXTicks    = 6
+)
+
+// palette holds the colors of the series, reused in order.
+var palette = []string{"#2563eb", "#dc2626", "#16a34a", "#d97706", "#7c3aed", "#0891b2", "#db2777", "#4b5563"}
+
+// Series is a line of a chart. Values are aligned with Chart.Timestamps;
+// nil values are buckets without samples.
+type Series struct {
+	Name   string
+	Values []*float64
+}
+
+// Chart is a line chart of one or more series over a time range.
+type Chart struct {
+	Title      string
+	Unit       string
+	Timestamps []time.Time
+	Series     []Series
+	// Max fixes the top of the value axis, e.g. 100 for percentages; when 0
+	// the axis fits the largest value.
+	Max float64
+	// MaxGap is the longest run of empty buckets the line is drawn across; a
+	// longer one, e.g. while the agent was down, leaves a gap.
+	MaxGap int
+	Width  int
+	Height int
+}
+
+type point struct{ x, y float64 }
+
+type tick struct {
+	pos   float64
+	label string
+}
+
+// layout is the geometry shared by the SVG and PNG renderers.
+type layout struct {
+	width, height            int
+	left, right, top, bottom float64
+	yTicks, xTicks           []tick
+	// lines holds the segments of every series, split at gaps.
+	lines [][][]point
+	// dots are the samples with no neighbour to draw a line to.
+	dots [][]point
+}
+
+func (c *Chart) layout() layout {
+	l := layout{width: c.Width, height: c.Height}
+	if l.width <= 0 {
+		l.width = DefaultWidth
+	}
+	if l.height <= 0 {
+		l.height = DefaultHeight
+	}
+	l.left, l.right = marginLeft, float64(l.width-marginRight)
+	l.top, l.bottom = marginTop, float64(l.height-marginBottom)
+
+	max := c.Max
+	if max <= 0 {
+		for _, s := range c.Series {
+			for _, v := range s.Values {
+				if v != nil && *v > max {
+					max = *v
+				}
+			}
+		}
+	}
+	step := niceStep(max / yTicks)
+	top := c.Max
+	if top <= 0 {
+		top = math.Max(math.Ceil(max/step), 1) * step
+	}
+	for i := 0; float64(i)*step <= top+step/2; i++ {
+		v := float64(i) * step
+		l.yTicks = append(l.yTicks, tick{pos: l.y(v, top), label: formatValue(v, step)})
+	}
+
+	n := len(c.Timestamps)
+	if n > 1 {
+		first, last := c.Timestamps[0], c.Timestamps[n-1]
+		format := "15:04"
+		switch span := last.Sub(first); {
+		case span > 10*24*time.Hour:
+			format = "Jan 2"
+		case span > 24*time.Hour:
+			format = "Jan 2 15:04"
+		case span < 10*time.Minute:
+			format = "15:04:05"
+		}
+		// As many labels as fit, leaving room between them.
+		ticks := int((l.right - l.left) / float64(len(format)*7+24))
+		if ticks > maxXTicks {
+			ticks = maxXTicks
+		}
+		if ticks < 2 {
+			ticks = 2
+		}
+		for i := 0; i < ticks; i++ {
+			idx := i * (n - 1) / (ticks - 1)
+			l.xTicks = append(l.xTicks, tick{pos: l.x(idx, n), label: c.Timestamps[idx].UTC().Format(format)})
+		}
+	}
+
+	for _, s := range c.Series {
+		var segments [][]point
+		var dots []point
+		var current []point
+		flush := func() {
+			switch len(current) {
+			case 0:
+			case 1:
+				dots = append(dots, current[0])
+			default:
+				segments = append(segments, current)
+			}
+			current = nil
+		}
+
+		gap := 0
+		for i, v := range s.Values {
+			if v == nil {
+				gap++
+				continue
+			}
+			if gap > c.MaxGap {
+				flush()
+			}
+			gap = 0
+			value := math.Min(math.Max(*v, 0), top)
+			current = append(current, point{l.x(i, n), l.y(value, top)})
+		}
+		flush()
+		l.lines = append(l.lines, segments)
+		l.dots = append(l.dots, dots)
+	}
+	return l
+}
+
+func (l layout) x(i, n int) float64 {
+	if n < 2 {
+		return l.left
+	}
+	return l.left + float64(i)*(l.right-l.left)/float64(n-1)
+}
+
+func (l layout) y(v, top float64) float64 {
+	return l.bottom - v/top*(l.bottom-l.top)
+}
+
+// title returns the title with the unit.
+func (c *Chart) title() string {
+	if c.Unit == "" {
+		return c.Title
+	}
+	return fmt.Sprintf("%s (%s)", c.Title, c.Unit)
+}
+
+// niceStep rounds a raw tick interval up to 1, 2 or 5 times a power of ten.
+func niceStep(raw float64) float64 {
+	if raw <= 0 {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if raw <= m*magnitude {
+			return m * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+// formatValue formats an axis value with as many decimals as the step needs,
+// abbreviating large values.
+func formatValue(v, step float64) string {
+	for _, unit := range []struct {
+		factor float64
+		suffix string
+	}{{1e12, "T"}, {1e9, "G"}, {1e6, "M"}, {1e3, "k"}} {
+		if step >= unit.factor {
+			return strconv.FormatFloat(v/unit.factor, 'f', decimals(step/unit.factor), 64) + unit.suffix
+		}
+	}
+	return strconv.FormatFloat(v, 'f', decimals(step), 64)
+}
+
+func decimals(step float64) int {
+	if step >= 1 {
+		return 0
+	}
+	return int(math.Ceil(-math.Log10(step)))
+}
@@ -0,0 +1,105 @@
+package charts
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"unicode"
+)
+
+const (
+	glyphWidth  = 5
+	glyphHeight = 7
+	// glyphAdvance leaves a column between characters.
+	glyphAdvance = glyphWidth + 1
+)
+
+// font is a 5x7 bitmap font for the PNG labels, as the standard library has
+// no text rendering. Lowercase letters are drawn as uppercase and other
+// characters as a box.
+var font = parseFont(map[rune]string{
+	'0': ".###. #...# #..## #.#.# ##..# #...# .###.",
+	'1': "..#.. .##.. ..#.. ..#.. ..#.. ..#.. .###.",
+	'2': ".###. #...# ....# ...#. ..#.. .#... #####",
+	'3': "####. ....# ....# .###. ....# ....# ####.",
+	'4': "...#. ..##. .#.#. #..#. ##### ...#. ...#.",
+	'5': "##### #.... ####. ....# ....# #...# .###.",
+	'6': ".###. #.... #.... ####. #...# #...# .###.",
+	'7': "##### ....# ...#. ..#.. .#... .#... .#...",
+	'8': ".###. #...# #...# .###. #...# #...# .###.",
+	'9': ".###. #...# #...# .#### ....# ....# .###.",
+	'A': ".###. #...# #...# ##### #...# #...# #...#",
+	'B': "####. #...# #...# ####. #...# #...# ####.",
+	'C': ".###. #...# #.... #.... #.... #...# .###.",
+	'D': "####. #...# #...# #...# #...# #...# ####.",
+	'E': "##### #.... #.... ####. #.... #.... #####",
+	'F': "##### #.... #.... ####. #.... #.... #....",
+	'G': ".###. #...# #.... #.### #...# #...# .####",
+	'H': "#...# #...# #...# ##### #...# #...# #...#",
+	'I': ".###. ..#.. ..#.. ..#.. ..#.. ..#.. .###.",
+	'J': "..### ...#. ...#. ...#. ...#. #..#. .##..",
+	'K': "#...# #..#. #.#.. ##... #.#.. #..#. #...#",
+	'L': "#.... #.... #.... #.... #.... #.... #####",
+	'M': "#...# ##.## #.#.# #.#.# #...# #...# #...#",
+	'N': "#...# #...# ##..# #.#.# #..## #...# #...#",
+	'O': ".###. #...# #...# #...# #...# #...# .###.",
+	'P': "####. #...# #...# ####. #.... #.... #....",
+	'Q': ".###. #...# #...# #...# #.#.# #..#. .##.#",
+	'R': "####. #...# #...# ####. #.#.. #..#. #...#",
+	'S': ".#### #.... #.... .###. ....# ....# ####.",
+	'T': "##### ..#.. ..#.. ..#.. ..#.. ..#.. ..#..",
+	'U': "#...# #...# #...# #...# #...# #...# .###.",
+	'V': "#...# #...# #...# #...# #...# .#.#. ..#..",
+	'W': "#...# #...# #...# #.#.# #.#.# #.#.# .#.#.",
+	'X': "#...# #...# .#.#. ..#.. .#.#. #...# #...#",
+	'Y': "#...# #...# .#.#. ..#.. ..#.. ..#.. ..#..",
+	'Z': "##### ....# ...#. ..#.. .#... #.... #####",
+	' ': "..... ..... ..... ..... ..... ..... .....",
+	'.': "..... ..... ..... ..... ..... .##.. .##..",
+	',': "..... ..... ..... ..... .##.. ..#.. .#...",
+	':': "..... .##.. .##.. ..... .##.. .##.. .....",
+	'-': "..... ..... ..... .###. ..... ..... .....",
+	'_': "..... ..... ..... ..... ..... ..... #####",
+	'+': "..... ..#.. ..#.. ##### ..#.. ..#.. .....",
+	'/': "....# ....# ...#. ..#.. .#... #.... #....",
+	'%': "##..# ##..# ...#. ..#.. .#... #..## #..##",
+	'(': "...#. ..#.. .#... .#... .#... ..#.. ...#.",
+	')': ".#... ..#.. ...#. ...#. ...#. ..#.. .#...",
+	'?': "##### #...# #...# #...# #...# #...# #####",
+})
+
+func parseFont(glyphs map[rune]string) map[rune][glyphHeight]string {
+	result := make(map[rune][glyphHeight]string, len(glyphs))
+	for r, art := range glyphs {
+		var rows [glyphHeight]string
+		copy(rows[:], strings.Fields(art))
+		result[r] = rows
+	}
+	return result
+}
+
+func textWidth(s string) int {
+	n := len([]rune(s))
+	if n == 0 {
+		return 0
+	}
+	return n*glyphAdvance - 1
+}
+
+// drawText draws s with its top left corner at x, y.
+func drawText(img *image.RGBA, x, y int, s string, c color.Color) {
+	for _, r := range s {
+		glyph, ok := font[unicode.ToUpper(r)]
+		if !ok {
+			glyph = font['?']
+		}
+		for row, line := range glyph {
+			for col, pixel := range line {
+				if pixel == '#' {
+					img.Set(x+col, y+row, c)
+				}
+			}
+		}
+		x += glyphAdvance
+	}
+}
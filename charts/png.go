@@ -0,0 +1,89 @@
+package charts
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+)
+
+// PNG renders the chart as a PNG image. Lines are not antialiased and the
+// labels use a small bitmap font, so the SVG version is sharper where it is
+// supported.
+func (c *Chart) PNG() ([]byte, error) {
+	l := c.layout()
+	img := image.NewRGBA(image.Rect(0, 0, l.width, l.height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	text, grid := parseColor(textColor), parseColor(gridColor)
+
+	drawText(img, int(l.left), 12, c.title(), text)
+
+	for _, t := range l.yTicks {
+		y := int(math.Round(t.pos))
+		for x := int(l.left); x <= int(l.right); x++ {
+			img.Set(x, y, grid)
+		}
+		drawText(img, int(l.left)-6-textWidth(t.label), y-glyphHeight/2, t.label, text)
+	}
+	for _, t := range l.xTicks {
+		x := int(math.Round(t.pos)) - textWidth(t.label)/2
+		if x+textWidth(t.label) > l.width-2 {
+			x = l.width - 2 - textWidth(t.label)
+		}
+		drawText(img, x, int(l.bottom)+8, t.label, text)
+	}
+
+	for i, segments := range l.lines {
+		col := parseColor(palette[i%len(palette)])
+		for _, segment := range segments {
+			for j := 1; j < len(segment); j++ {
+				drawLine(img, segment[j-1], segment[j], col)
+			}
+		}
+		for _, p := range l.dots[i] {
+			drawLine(img, p, p, col)
+		}
+	}
+
+	if len(c.Series) > 1 {
+		x := int(l.right)
+		for i := len(c.Series) - 1; i >= 0; i-- {
+			name := c.Series[i].Name
+			x -= textWidth(name) + 14
+			draw.Draw(img, image.Rect(x, 12, x+7, 19), image.NewUniform(parseColor(palette[i%len(palette)])), image.Point{}, draw.Src)
+			drawText(img, x+10, 12, name, text)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// drawLine draws a two pixel wide line from a to b.
+func drawLine(img *image.RGBA, a, b point, c color.Color) {
+	steps := int(math.Max(math.Abs(b.x-a.x), math.Abs(b.y-a.y)))
+	for i := 0; i <= steps; i++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(i) / float64(steps)
+		}
+		x := int(math.Round(a.x + (b.x-a.x)*t))
+		y := int(math.Round(a.y + (b.y-a.y)*t))
+		img.Set(x, y, c)
+		img.Set(x+1, y, c)
+		img.Set(x, y+1, c)
+		img.Set(x+1, y+1, c)
+	}
+}
+
+// parseColor parses a #rrggbb color.
+func parseColor(hex string) color.Color {
+	v, _ := strconv.ParseUint(hex[1:], 16, 32)
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}
+}
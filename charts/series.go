@@ -0,0 +1,147 @@
+package charts
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/containers"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+const (
+	defaultRefreshRate = 60
+	// A gap of more than maxGapIntervals refresh intervals without samples
+	// means the collector was not running, and is left empty on the chart.
+	maxGapIntervals = 3
+)
+
+type serverMetric struct {
+	title string
+	unit  string
+	max   float64
+	value func(database.HostSample) float64
+}
+
+var serverMetrics = map[string]serverMetric{
+	"cpu":      {"CPU", "%", 100, func(s database.HostSample) float64 { return s.CPU }},
+	"memory":   {"Memory", "%", 100, func(s database.HostSample) float64 { return s.Memory }},
+	"disk":     {"Disk", "%", 100, func(s database.HostSample) float64 { return s.Disk }},
+	"upload":   {"Upload", "MB/s", 0, func(s database.HostSample) float64 { return s.Upload }},
+	"download": {"Download", "MB/s", 0, func(s database.HostSample) float64 { return s.Download }},
+}
+
+type containerMetric struct {
+	title  string
+	unit   string
+	max    float64
+	series func(*containers.ServiceSeries) []*float64
+}
+
+var containerMetrics = map[string]containerMetric{
+	"cpu":        {"CPU", "%", 0, func(s *containers.ServiceSeries) []*float64 { return s.CPU }},
+	"memory":     {"Memory", "%", 100, func(s *containers.ServiceSeries) []*float64 { return s.MemoryPercent }},
+	"memoryUsed": {"Memory used", "MB", 0, func(s *containers.ServiceSeries) []*float64 { return s.MemoryUsedMB }},
+	"networkIn":  {"Network in", "bytes", 0, func(s *containers.ServiceSeries) []*float64 { return s.NetworkInBytes }},
+	"networkOut": {"Network out", "bytes", 0, func(s *containers.ServiceSeries) []*float64 { return s.NetworkOutBytes }},
+	"blockRead":  {"Block read", "bytes", 0, func(s *containers.ServiceSeries) []*float64 { return s.BlockReadBytes }},
+	"blockWrite": {"Block write", "bytes", 0, func(s *containers.ServiceSeries) []*float64 { return s.BlockWriteBytes }},
+}
+
+// ServerChart returns the chart of a host metric: cpu, memory, disk, upload
+// or download.
+func ServerChart(db *database.DB, metric string, tr database.TimeRange) (*Chart, error) {
+	m, ok := serverMetrics[metric]
+	if !ok {
+		return nil, fmt.Errorf("unknown server metric %q, expected one of %s", metric, names(serverMetrics))
+	}
+
+	samples, err := db.GetHostSamples(tr.Start, tr.End)
+	if err != nil {
+		return nil, err
+	}
+
+	n := tr.Buckets()
+	sums := make([]float64, n)
+	counts := make([]int, n)
+	for _, s := range samples {
+		if i := tr.BucketIndex(s.Timestamp); i >= 0 {
+			sums[i] += m.value(s)
+			counts[i]++
+		}
+	}
+	values := make([]*float64, n)
+	for i := range values {
+		if counts[i] > 0 {
+			avg := sums[i] / float64(counts[i])
+			values[i] = &avg
+		}
+	}
+
+	return &Chart{
+		Title:      m.title,
+		Unit:       m.unit,
+		Timestamps: timestamps(tr),
+		Series:     []Series{{Name: metric, Values: values}},
+		Max:        m.max,
+		MaxGap:     maxGap(config.GetMetricsConfig().Server.RefreshRate, tr),
+	}, nil
+}
+
+// ContainerChart returns the chart of a container metric with a line per
+// service: cpu, memory, memoryUsed, networkIn, networkOut, blockRead or
+// blockWrite.
+func ContainerChart(db *database.DB, metric string, services []string, tr database.TimeRange) (*Chart, error) {
+	m, ok := containerMetrics[metric]
+	if !ok {
+		return nil, fmt.Errorf("unknown container metric %q, expected one of %s", metric, names(containerMetrics))
+	}
+
+	metrics, err := db.GetContainerMetricsInRange(services, tr.Start, tr.End)
+	if err != nil {
+		return nil, err
+	}
+	response := containers.BuildServiceSeries(metrics, services, tr)
+
+	chart := &Chart{
+		Title:      m.title,
+		Unit:       m.unit,
+		Timestamps: timestamps(tr),
+		Max:        m.max,
+		MaxGap:     maxGap(config.GetMetricsConfig().Containers.RefreshRate, tr),
+	}
+	if len(services) == 1 {
+		chart.Title = services[0] + " " + m.title
+	}
+	for _, service := range services {
+		chart.Series = append(chart.Series, Series{Name: service, Values: m.series(response.Services[service])})
+	}
+	return chart, nil
+}
+
+func timestamps(tr database.TimeRange) []time.Time {
+	result := make([]time.Time, tr.Buckets())
+	for i := range result {
+		result[i] = tr.Start.Add(time.Duration(i) * tr.Step)
+	}
+	return result
+}
+
+// maxGap returns the number of empty buckets the lines are drawn across.
+func maxGap(refreshRate int, tr database.TimeRange) int {
+	if refreshRate <= 0 {
+		refreshRate = defaultRefreshRate
+	}
+	return int(maxGapIntervals * time.Duration(refreshRate) * time.Second / tr.Step)
+}
+
+func names[V any](m map[string]V) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
@@ -0,0 +1,66 @@
+package charts
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+)
+
+const (
+	textColor = "#374151"
+	gridColor = "#e5e7eb"
+	fontSize  = 11
+)
+
+// SVG renders the chart as a standalone SVG document.
+func (c *Chart) SVG() []byte {
+	l := c.layout()
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="%d">`,
+		l.width, l.height, l.width, l.height, fontSize)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#ffffff"/>`, l.width, l.height)
+	fmt.Fprintf(&b, `<text x="%.1f" y="20" fill="%s" font-size="13" font-weight="bold">%s</text>`,
+		l.left, textColor, html.EscapeString(c.title()))
+
+	for _, t := range l.yTicks {
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s"/>`, l.left, t.pos, l.right, t.pos, gridColor)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" fill="%s" text-anchor="end" dominant-baseline="middle">%s</text>`,
+			l.left-6, t.pos, textColor, html.EscapeString(t.label))
+	}
+	for _, t := range l.xTicks {
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" fill="%s" text-anchor="middle">%s</text>`,
+			t.pos, l.bottom+16, textColor, html.EscapeString(t.label))
+	}
+
+	for i, segments := range l.lines {
+		color := palette[i%len(palette)]
+		for _, segment := range segments {
+			b.WriteString(`<polyline fill="none" stroke-width="1.5" stroke-linejoin="round" stroke="` + color + `" points="`)
+			for j, p := range segment {
+				if j > 0 {
+					b.WriteByte(' ')
+				}
+				fmt.Fprintf(&b, "%.1f,%.1f", p.x, p.y)
+			}
+			b.WriteString(`"/>`)
+		}
+		for _, p := range l.dots[i] {
+			fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="1.5" fill="%s"/>`, p.x, p.y, color)
+		}
+	}
+
+	// The legend is only needed to tell several series apart.
+	if len(c.Series) > 1 {
+		x := l.right
+		for i := len(c.Series) - 1; i >= 0; i-- {
+			name := c.Series[i].Name
+			x -= float64(len([]rune(name)))*fontSize*0.6 + 20
+			fmt.Fprintf(&b, `<rect x="%.1f" y="11" width="10" height="10" fill="%s"/>`, x, palette[i%len(palette)])
+			fmt.Fprintf(&b, `<text x="%.1f" y="20" fill="%s">%s</text>`, x+14, textColor, html.EscapeString(name))
+		}
+	}
+
+	b.WriteString(`</svg>`)
+	return b.Bytes()
+}
@@ -0,0 +1,214 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/logging"
+	"github.com/mauriciogm/dokploy/apps/monitoring/monitoring"
+	"github.com/mauriciogm/dokploy/apps/monitoring/telemetry"
+)
+
+var logger = logging.New("checks")
+
+const (
+	defaultInterval    = 60
+	defaultConcurrency = 4
+)
+
+type checkState struct {
+	running bool
+	last    *database.CheckResult
+}
+
+// CheckRunner runs the configured commands on their schedule, with at most
+// Concurrency of them at the same time.
+type CheckRunner struct {
+	db       *database.DB
+	checks   []config.CheckConfig
+	sem      chan struct{}
+	mu       sync.Mutex
+	state    map[string]*checkState
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	// ctx is cancelled on Stop to kill the running commands.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewCheckRunner(db *database.DB) (*CheckRunner, error) {
+	if err := db.InitCheckResultsTable(); err != nil {
+		return nil, fmt.Errorf("failed to initialize check results table: %v", err)
+	}
+	if err := db.InitCustomMetricsTable(); err != nil {
+		return nil, fmt.Errorf("failed to initialize custom metrics table: %v", err)
+	}
+
+	cfg := config.GetMetricsConfig().Checks
+	state := make(map[string]*checkState, len(cfg.Commands))
+	for _, check := range cfg.Commands {
+		if check.Name == "" || check.Command == "" {
+			return nil, fmt.Errorf("checks require a name and a command")
+		}
+		if _, ok := state[check.Name]; ok {
+			return nil, fmt.Errorf("duplicate check name %q", check.Name)
+		}
+		if check.Mode != "" && check.Mode != "nagios" && check.Mode != "metrics" {
+			return nil, fmt.Errorf("check %q has unknown mode %q", check.Name, check.Mode)
+		}
+		state[check.Name] = &checkState{}
+	}
+
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &CheckRunner{
+		db:       db,
+		checks:   cfg.Commands,
+		sem:      make(chan struct{}, concurrency),
+		state:    state,
+		stopChan: make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+func (cr *CheckRunner) Start() error {
+	for _, check := range cr.checks {
+		interval := check.Interval
+		if interval <= 0 {
+			interval = defaultInterval
+		}
+		telemetry.Expect("checks", time.Duration(interval)*time.Second)
+		cr.wg.Add(1)
+		go cr.schedule(check, time.Duration(interval)*time.Second)
+	}
+
+	if len(cr.checks) > 0 {
+		logger.Info("Started custom checks", "count", len(cr.checks))
+	}
+	return nil
+}
+
+func (cr *CheckRunner) Stop() {
+	close(cr.stopChan)
+	cr.cancel()
+	cr.wg.Wait()
+}
+
+// Results returns the latest result of every check, nil for checks that have
+// not run yet.
+func (cr *CheckRunner) Results() map[string]*database.CheckResult {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	result := make(map[string]*database.CheckResult, len(cr.state))
+	for name, state := range cr.state {
+		result[name] = state.last
+	}
+	return result
+}
+
+func (cr *CheckRunner) schedule(check config.CheckConfig, interval time.Duration) {
+	defer cr.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	cr.wg.Add(1)
+	go cr.runOnce(check)
+	for {
+		select {
+		case <-ticker.C:
+			cr.wg.Add(1)
+			go cr.runOnce(check)
+		case <-cr.stopChan:
+			return
+		}
+	}
+}
+
+func (cr *CheckRunner) runOnce(check config.CheckConfig) {
+	defer cr.wg.Done()
+
+	cr.mu.Lock()
+	state := cr.state[check.Name]
+	if state.running {
+		cr.mu.Unlock()
+		logger.Warn("Check still running, skipping", "check", check.Name)
+		telemetry.SkipCycle("checks")
+		return
+	}
+	state.running = true
+	cr.mu.Unlock()
+
+	defer func() {
+		cr.mu.Lock()
+		state.running = false
+		cr.mu.Unlock()
+	}()
+
+	select {
+	case cr.sem <- struct{}{}:
+	case <-cr.stopChan:
+		return
+	}
+	cycle := telemetry.StartCycle("checks")
+	result, metrics := Run(cr.ctx, check)
+	<-cr.sem
+	if cr.ctx.Err() != nil {
+		// Interrupted by Stop, the result says nothing about the check.
+		return
+	}
+
+	if err := cr.db.SaveCheckResult(result); err != nil {
+		logger.Error("Error saving check result", "check", check.Name, "error", err)
+		cycle.Error()
+	}
+	if len(metrics) > 0 {
+		if err := cr.db.SaveCustomMetrics(metrics); err != nil {
+			logger.Error("Error saving check metrics", "check", check.Name, "error", err)
+			cycle.Error()
+		}
+	}
+	cycle.Done()
+
+	cr.mu.Lock()
+	previous := StatusOK
+	if state.last != nil {
+		previous = state.last.Status
+	}
+	state.last = &result
+	cr.mu.Unlock()
+
+	// Alert when a check turns WARNING or CRITICAL, or goes from WARNING to
+	// CRITICAL. UNKNOWN results are recorded without alerting.
+	key := "check:" + check.Name
+	if result.Status != StatusWarning && result.Status != StatusCritical {
+		monitoring.ResolveAlert(key)
+		return
+	}
+
+	alert := monitoring.AlertPayload{
+		Type:      "Check",
+		Value:     float64(result.Status),
+		Threshold: float64(StatusWarning),
+		Message:   fmt.Sprintf("Check %s is %s: %s", check.Name, result.StatusText, result.Output),
+		Timestamp: result.Timestamp,
+	}
+	monitoring.RaiseAlert(key, alert)
+	if previous == result.Status || (previous == StatusCritical && result.Status == StatusWarning) {
+		return
+	}
+
+	if err := monitoring.SendAlert(alert); err != nil {
+		logger.Error("Error sending check alert", "check", check.Name, "error", err)
+	}
+}
@@ -0,0 +1,206 @@
+package checks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/ingest"
+)
+
+// Nagios plugin exit codes.
+const (
+	StatusOK       = 0
+	StatusWarning  = 1
+	StatusCritical = 2
+	StatusUnknown  = 3
+)
+
+const (
+	defaultTimeout = 30
+	maxOutputSize  = 64 * 1024
+)
+
+var statusText = map[int]string{
+	StatusOK:       "OK",
+	StatusWarning:  "WARNING",
+	StatusCritical: "CRITICAL",
+	StatusUnknown:  "UNKNOWN",
+}
+
+// Run executes a check once and returns its result and the metrics found in
+// its output. In "nagios" mode (the default) the exit code is the status and
+// performance data after "|" becomes metrics; in "metrics" mode every
+// "name value" line is a metric and any non-zero exit code is CRITICAL.
+func Run(ctx context.Context, check config.CheckConfig) (database.CheckResult, []database.CustomMetric) {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if len(check.Args) > 0 {
+		cmd = exec.CommandContext(ctx, check.Command, check.Args...)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", check.Command)
+	}
+	// Children that inherited stdout could keep Wait blocked after the
+	// process is killed on timeout.
+	cmd.WaitDelay = time.Second
+
+	output := &limitedBuffer{limit: maxOutputSize}
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	start := time.Now()
+	err := cmd.Run()
+	timestamp := time.Now().UTC().Format(time.RFC3339Nano)
+
+	result := database.CheckResult{
+		Timestamp:  timestamp,
+		Name:       check.Name,
+		Output:     strings.TrimSpace(output.String()),
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+
+	status := StatusOK
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case ctx.Err() == context.DeadlineExceeded:
+		status = StatusUnknown
+		result.Output = strings.TrimSpace(result.Output + "\ncheck timed out after " + strconv.Itoa(timeout) + "s")
+	case errors.As(err, &exitErr):
+		status = exitErr.ExitCode()
+	default:
+		status = StatusUnknown
+		result.Output = strings.TrimSpace(result.Output + "\n" + err.Error())
+	}
+
+	var metrics []database.CustomMetric
+	if check.Mode == "metrics" {
+		if status != StatusOK && status != StatusUnknown {
+			status = StatusCritical
+		}
+		metrics = parseMetricLines(result.Output)
+	} else {
+		if status < StatusOK || status > StatusUnknown {
+			status = StatusUnknown
+		}
+		metrics = parsePerfData(result.Output)
+	}
+
+	valid := metrics[:0]
+	for _, m := range metrics {
+		m.Timestamp = timestamp
+		m.Labels = map[string]string{"check": check.Name}
+		if err := ingest.Validate(m); err != nil {
+			logger.Warn("Ignoring check metric", "check", check.Name, "metric", m.Name, "error", err)
+			continue
+		}
+		valid = append(valid, m)
+	}
+	metrics = valid
+
+	result.Status = status
+	result.StatusText = statusText[status]
+	return result, metrics
+}
+
+// parseMetricLines reads "name value" lines, ignoring anything else.
+func parseMetricLines(output string) []database.CustomMetric {
+	var metrics []database.CustomMetric
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		metrics = append(metrics, database.CustomMetric{Name: fields[0], Value: value})
+	}
+	return metrics
+}
+
+// parsePerfData reads Nagios performance data ('label'=value[UOM];warn;crit;min;max)
+// from the text after "|" on every output line.
+func parsePerfData(output string) []database.CustomMetric {
+	var metrics []database.CustomMetric
+	for _, line := range strings.Split(output, "\n") {
+		idx := strings.Index(line, "|")
+		if idx < 0 {
+			continue
+		}
+		for _, item := range splitPerfData(line[idx+1:]) {
+			eq := strings.LastIndex(item, "=")
+			if eq <= 0 {
+				continue
+			}
+			label := strings.Trim(item[:eq], "'")
+			raw := strings.SplitN(item[eq+1:], ";", 2)[0]
+			raw = strings.TrimRight(raw, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ%")
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				continue
+			}
+			metrics = append(metrics, database.CustomMetric{Name: label, Value: value})
+		}
+	}
+	return metrics
+}
+
+// splitPerfData splits on spaces outside single-quoted labels.
+func splitPerfData(s string) []string {
+	var items []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range s {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+			current.WriteRune(r)
+		case r == ' ' && !quoted:
+			if current.Len() > 0 {
+				items = append(items, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		items = append(items, current.String())
+	}
+	return items
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the rest.
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.buf.Len(); remaining > 0 {
+		if len(p) > remaining {
+			b.buf.Write(p[:remaining])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
@@ -0,0 +1,101 @@
+package checks
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+func TestRunStatus(t *testing.T) {
+	for _, tc := range []struct {
+		mode   string
+		exit   string
+		status int
+		text   string
+	}{
+		{"", "0", StatusOK, "OK"},
+		{"", "1", StatusWarning, "WARNING"},
+		{"nagios", "2", StatusCritical, "CRITICAL"},
+		{"", "3", StatusUnknown, "UNKNOWN"},
+		{"", "7", StatusUnknown, "UNKNOWN"},
+		{"metrics", "0", StatusOK, "OK"},
+		{"metrics", "1", StatusCritical, "CRITICAL"},
+		{"metrics", "3", StatusUnknown, "UNKNOWN"},
+	} {
+		check := config.CheckConfig{Name: "status", Mode: tc.mode, Command: "echo checked; exit " + tc.exit}
+		result, _ := Run(context.Background(), check)
+		if result.Status != tc.status || result.StatusText != tc.text {
+			t.Errorf("%q mode exiting with %s: got %d %s, want %d %s", tc.mode, tc.exit, result.Status, result.StatusText, tc.status, tc.text)
+		}
+		if result.Output != "checked" || result.Name != "status" {
+			t.Errorf("got output %q for check %q", result.Output, result.Name)
+		}
+	}
+}
+
+func TestRunArgs(t *testing.T) {
+	result, _ := Run(context.Background(), config.CheckConfig{Name: "args", Command: "echo", Args: []string{"a;", "exit 2"}})
+	if result.Status != StatusOK || result.Output != "a; exit 2" {
+		t.Errorf("got status %d with output %q, want the arguments passed without a shell", result.Status, result.Output)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	start := time.Now()
+	result, metrics := Run(context.Background(), config.CheckConfig{Name: "slow", Command: "echo 'started | up=1'; sleep 10", Timeout: 1})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("the check took %s with a timeout of 1s", elapsed)
+	}
+	if result.Status != StatusUnknown || !strings.HasSuffix(result.Output, "check timed out after 1s") {
+		t.Errorf("got status %d with output %q, want UNKNOWN and the timeout", result.Status, result.Output)
+	}
+	if !strings.HasPrefix(result.Output, "started") || len(metrics) != 1 {
+		t.Errorf("got output %q and %d metrics, want what was written before the timeout", result.Output, len(metrics))
+	}
+}
+
+func TestParsePerfData(t *testing.T) {
+	output := "DISK OK - free space: / 3326 MB (56%); | /=2643MB;5948;5958;0;5968 'used space'=44.5%;80;90 " +
+		"time=0.5s invalid=;1 nothing\n" +
+		"second line | load1=0.12;1.5;2 count=12c\n" +
+		"no perfdata here"
+
+	want := []database.CustomMetric{
+		{Name: "/", Value: 2643},
+		{Name: "used space", Value: 44.5},
+		{Name: "time", Value: 0.5},
+		{Name: "load1", Value: 0.12},
+		{Name: "count", Value: 12},
+	}
+	if got := parsePerfData(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseMetricLines(t *testing.T) {
+	output := "queue_depth 42\nratio 0.25\nnot a metric\nempty\ncount many"
+	want := []database.CustomMetric{
+		{Name: "queue_depth", Value: 42},
+		{Name: "ratio", Value: 0.25},
+	}
+	if got := parseMetricLines(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestRunDropsInvalidMetrics(t *testing.T) {
+	check := config.CheckConfig{
+		Name:    "invalid",
+		Mode:    "metrics",
+		Command: "echo 'nan NaN'; echo 'inf +Inf'; echo '" + strings.Repeat("x", 201) + " 1'; echo 'valid 1'",
+	}
+	_, metrics := Run(context.Background(), check)
+	if len(metrics) != 1 || metrics[0].Name != "valid" || metrics[0].Labels["check"] != "invalid" {
+		t.Errorf("got metrics %+v, want only the valid one labeled with the check", metrics)
+	}
+}
@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+func runAlertsList(args []string) error {
+	fs := newFlagSet("alerts list", "alerts list [flags]",
+		"Lists the alerts whose condition still holds, as last stored by the agent.")
+	dbPath := dbFlag(fs)
+	format := outputFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(fs, *format); err != nil {
+		return err
+	}
+
+	db, err := database.OpenReadOnly(*dbPath)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %v", *dbPath, err)
+	}
+	defer db.Close()
+
+	alerts, err := db.GetActiveAlerts()
+	if err != nil {
+		return fmt.Errorf("error getting alerts: %v", err)
+	}
+
+	if *format == formatJSON {
+		return writeJSON(alerts)
+	}
+	t := newTable("SINCE", "LAST SEEN", "TYPE", "VALUE", "THRESHOLD", "MESSAGE")
+	for _, a := range alerts {
+		t.row(a.Since, a.LastSeen, a.Type, a.Value, a.Threshold, a.Message)
+	}
+	return t.flush()
+}
+
+type dbStats struct {
+	SizeBytes int64            `json:"sizeBytes"`
+	Tables    map[string]int64 `json:"tables"`
+}
+
+func runDBStats(args []string) error {
+	fs := newFlagSet("db stats", "db stats [flags]",
+		"Prints the size of the database and the number of rows of every table.")
+	dbPath := dbFlag(fs)
+	format := outputFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(fs, *format); err != nil {
+		return err
+	}
+
+	db, err := database.OpenReadOnly(*dbPath)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %v", *dbPath, err)
+	}
+	defer db.Close()
+
+	var stats dbStats
+	if stats.SizeBytes, err = db.FileSize(); err != nil {
+		return fmt.Errorf("error getting database size: %v", err)
+	}
+	if stats.Tables, err = db.RowCounts(); err != nil {
+		return fmt.Errorf("error counting rows: %v", err)
+	}
+
+	if *format == formatJSON {
+		return writeJSON(stats)
+	}
+	fmt.Printf("Size: %s\n\n", formatBytes(stats.SizeBytes))
+	t := newTable("TABLE", "ROWS")
+	for _, name := range sortedKeys(stats.Tables) {
+		t.row(name, stats.Tables[name])
+	}
+	return t.flush()
+}
+
+type vacuumResult struct {
+	SizeBeforeBytes int64 `json:"sizeBeforeBytes"`
+	SizeAfterBytes  int64 `json:"sizeAfterBytes"`
+}
+
+func runDBVacuum(args []string) error {
+	fs := newFlagSet("db vacuum", "db vacuum [flags]",
+		"Rebuilds the database so the space of deleted rows is returned to the disk.\n"+
+			"The agent's writes wait while it runs, which may take a while on large databases.")
+	dbPath := dbFlag(fs)
+	format := outputFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(fs, *format); err != nil {
+		return err
+	}
+
+	db, err := database.Open(*dbPath)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %v", *dbPath, err)
+	}
+	defer db.Close()
+
+	var result vacuumResult
+	if result.SizeBeforeBytes, err = db.FileSize(); err != nil {
+		return fmt.Errorf("error getting database size: %v", err)
+	}
+	// The rows the push cursors point at are compared using the positions
+	// read before, as a running pusher may move the cursors meanwhile.
+	cursors, err := db.GetPushCursors()
+	if err != nil {
+		return fmt.Errorf("error reading cursors: %v", err)
+	}
+	before, err := db.PushCursorRows(cursors)
+	if err != nil {
+		return fmt.Errorf("error reading cursors: %v", err)
+	}
+	if _, err := db.Exec(`VACUUM`); err != nil {
+		return fmt.Errorf("error vacuuming database: %v", err)
+	}
+	after, err := db.PushCursorRows(cursors)
+	if err != nil {
+		return fmt.Errorf("error reading cursors: %v", err)
+	}
+	for _, name := range sortedKeys(cursors) {
+		if before[name] != after[name] {
+			return fmt.Errorf("cursor %s moved from the row of %q to %q while vacuuming", name, before[name], after[name])
+		}
+	}
+	if result.SizeAfterBytes, err = db.FileSize(); err != nil {
+		return fmt.Errorf("error getting database size: %v", err)
+	}
+
+	if *format == formatJSON {
+		return writeJSON(result)
+	}
+	fmt.Printf("Vacuumed %s: %s -> %s\n", *dbPath, formatBytes(result.SizeBeforeBytes), formatBytes(result.SizeAfterBytes))
+	return nil
+}
+
+type cleanupResult struct {
+	DryRun        bool             `json:"dryRun"`
+	RetentionDays int              `json:"retentionDays"`
+	Tables        map[string]int64 `json:"tables"`
+}
+
+func runCleanup(args []string) error {
+	cfg := agentConfig()
+	retention := 0
+	if cfg != nil {
+		retention = cfg.Server.RetentionDays
+	}
+
+	fs := newFlagSet("cleanup", "cleanup [flags]",
+		"Deletes the metrics older than the retention period, as the agent's cleanup job does.")
+	dbPath := dbFlag(fs)
+	retentionDays := fs.Int("retention-days", retention, "days of metrics to keep (default: server.retentionDays from METRICS_CONFIG)")
+	dryRun := fs.Bool("dry-run", false, "only count the rows that would be deleted")
+	format := outputFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(fs, *format); err != nil {
+		return err
+	}
+	if *retentionDays <= 0 {
+		return usageError(fs, "-retention-days must be positive")
+	}
+
+	open := database.Open
+	if *dryRun {
+		open = database.OpenReadOnly
+	}
+	db, err := open(*dbPath)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %v", *dbPath, err)
+	}
+	defer db.Close()
+
+	result := cleanupResult{DryRun: *dryRun, RetentionDays: *retentionDays}
+	if *dryRun {
+		result.Tables, err = database.CountExpiredMetrics(db.DB, *retentionDays)
+	} else {
+		result.Tables, err = database.DeleteExpiredMetrics(db.DB, *retentionDays)
+	}
+	if err != nil {
+		return fmt.Errorf("error cleaning up metrics: %v", err)
+	}
+
+	if *format == formatJSON {
+		return writeJSON(result)
+	}
+	column := "DELETED"
+	if *dryRun {
+		column = "TO DELETE"
+	}
+	t := newTable("TABLE", column)
+	for _, name := range sortedKeys(result.Tables) {
+		t.row(name, result.Tables[name])
+	}
+	return t.flush()
+}
+
+func sortedKeys(m map[string]int64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
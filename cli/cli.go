@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+)
+
+// command is a subcommand of the agent binary, or a group of subcommands.
+// Without a subcommand the binary runs the agent.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+	sub     []command
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "top", summary: "Show a live view of the host, services and firing alerts", run: runTop},
+		{name: "query", summary: "Query the stored metrics", sub: []command{
+			{name: "server", summary: "Show the host metrics of a time range", run: runQueryServer},
+			{name: "containers", summary: "Show the container metrics of a service in a time range", run: runQueryContainers},
+		}},
+		{name: "alerts", summary: "Inspect alerts", sub: []command{
+			{name: "list", summary: "List the firing alerts", run: runAlertsList},
+		}},
+		{name: "db", summary: "Inspect and maintain the database", sub: []command{
+			{name: "stats", summary: "Show the size of the database and the rows per table", run: runDBStats},
+			{name: "vacuum", summary: "Rebuild the database to reclaim the space of deleted rows", run: runDBVacuum},
+		}},
+		{name: "cleanup", summary: "Delete the metrics older than the retention period", run: runCleanup},
+		{name: "token", summary: "Manage additional API tokens", sub: []command{
+			{name: "create", summary: "Create a token", run: runTokenCreate},
+			{name: "list", summary: "List the tokens", run: runTokenList},
+			{name: "revoke", summary: "Revoke a token", run: runTokenRevoke},
+		}},
+	}
+}
+
+// errUsage is returned by the commands whose arguments are invalid, after the
+// problem and the usage were printed.
+var errUsage = errors.New("invalid usage")
+
+// Run runs the subcommand named by args and returns the exit status.
+func Run(args []string) int {
+	return dispatch("monitoring", commands, args)
+}
+
+func dispatch(path string, cmds []command, args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(os.Stdout, path, cmds)
+		return 0
+	}
+
+	name := args[0]
+	for _, c := range cmds {
+		if c.name != name {
+			continue
+		}
+		if c.sub != nil {
+			return dispatch(path+" "+name, c.sub, args[1:])
+		}
+
+		err := c.run(args[1:])
+		switch {
+		case err == nil, errors.Is(err, flag.ErrHelp):
+			return 0
+		case errors.Is(err, errUsage):
+			return 2
+		default:
+			fmt.Fprintf(os.Stderr, "%s %s: %v\n", path, name, err)
+			return 1
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", path+" "+name)
+	usage(os.Stderr, path, cmds)
+	return 2
+}
+
+func usage(w io.Writer, path string, cmds []command) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n", path)
+	fmt.Fprintln(w)
+	if path == "monitoring" {
+		fmt.Fprintln(w, "Without a command the agent is started. Commands:")
+	} else {
+		fmt.Fprintln(w, "Commands:")
+	}
+	for _, c := range cmds {
+		fmt.Fprintf(w, "  %-12s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Run '%s <command> -h' for the flags of a command.\n", path)
+}
+
+// newFlagSet returns the flag set of a command, with a usage message made of
+// its synopsis, description and flags.
+func newFlagSet(name, synopsis, description string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: monitoring %s\n\n%s\n\n", synopsis, description)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	if fs.NArg() > 0 {
+		return usageError(fs, "unexpected arguments: %v", fs.Args())
+	}
+	return nil
+}
+
+// usageError prints a problem with the arguments and the usage.
+func usageError(fs *flag.FlagSet, format string, args ...interface{}) error {
+	fmt.Fprintf(fs.Output(), format+"\n", args...)
+	fs.Usage()
+	return errUsage
+}
+
+// agentConfig returns the configuration of the agent when METRICS_CONFIG is
+// set, e.g. when the commands run inside the agent's container, so they
+// default to its port, token and retention.
+func agentConfig() *config.Config {
+	if os.Getenv("METRICS_CONFIG") == "" {
+		return nil
+	}
+	return config.GetMetricsConfig()
+}
+
+func defaultURL(cfg *config.Config) string {
+	port := 3001
+	if cfg != nil && cfg.Server.Port != 0 {
+		port = cfg.Server.Port
+	}
+	return "http://localhost:" + strconv.Itoa(port)
+}
+
+func defaultToken(cfg *config.Config) string {
+	if cfg == nil {
+		return ""
+	}
+	return cfg.Server.Token
+}
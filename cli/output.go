@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// outputFlag adds the -o flag selecting between a table for people and
+// JSON for scripts.
+func outputFlag(fs *flag.FlagSet) *string {
+	return fs.String("o", formatTable, "output format: table or json")
+}
+
+func checkFormat(fs *flag.FlagSet, format string) error {
+	if format != formatTable && format != formatJSON {
+		return usageError(fs, "invalid -o %q, expected table or json", format)
+	}
+	return nil
+}
+
+func dbFlag(fs *flag.FlagSet) *string {
+	return fs.String("db", database.File, "path of the database")
+}
+
+func writeJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// table writes aligned columns to stdout.
+type table struct {
+	w *tabwriter.Writer
+}
+
+func newTable(columns ...string) *table {
+	t := &table{w: tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)}
+	fmt.Fprintln(t.w, strings.Join(columns, "\t"))
+	return t
+}
+
+func (t *table) row(values ...interface{}) {
+	cells := make([]string, len(values))
+	for i, v := range values {
+		if f, ok := v.(float64); ok {
+			cells[i] = fmt.Sprintf("%.2f", f)
+		} else {
+			cells[i] = fmt.Sprint(v)
+		}
+	}
+	fmt.Fprintln(t.w, strings.Join(cells, "\t"))
+}
+
+func (t *table) flush() error {
+	return t.w.Flush()
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
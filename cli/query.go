@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+// timeRangeFlags adds -since and -until, which accept the same values as the
+// from and to parameters of the API.
+func timeRangeFlags(fs *flag.FlagSet) (*string, *string) {
+	since := fs.String("since", "1h", "start of the range: a duration before now such as 1h or 7d, an RFC3339 timestamp or unix seconds")
+	until := fs.String("until", "now", "end of the range, in the same formats as -since")
+	return since, until
+}
+
+func parseRange(fs *flag.FlagSet, since, until string) (database.TimeRange, error) {
+	r, err := database.ParseTimeRange(since, until, "", time.Now())
+	if err != nil {
+		return r, usageError(fs, "%v", err)
+	}
+	return r, nil
+}
+
+func runQueryServer(args []string) error {
+	fs := newFlagSet("query server", "query server [flags]",
+		"Prints the host metrics stored in a time range, oldest first.")
+	dbPath := dbFlag(fs)
+	since, until := timeRangeFlags(fs)
+	format := outputFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(fs, *format); err != nil {
+		return err
+	}
+	r, err := parseRange(fs, *since, *until)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.OpenReadOnly(*dbPath)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %v", *dbPath, err)
+	}
+	defer db.Close()
+
+	metrics, err := db.GetMetricsInRange(r.Start, r.End)
+	if err != nil {
+		return fmt.Errorf("error getting metrics: %v", err)
+	}
+	if metrics == nil {
+		metrics = []database.ServerMetric{}
+	}
+
+	if *format == formatJSON {
+		return writeJSON(metrics)
+	}
+	t := newTable("TIMESTAMP", "CPU %", "MEM %", "MEM GB", "DISK %", "UP MB/s", "DOWN MB/s")
+	for _, m := range metrics {
+		t.row(m.Timestamp, m.CPU, m.MemUsed, m.MemUsedGB, m.DiskUsed, m.UploadRate, m.DownloadRate)
+	}
+	return t.flush()
+}
+
+func runQueryContainers(args []string) error {
+	fs := newFlagSet("query containers", "query containers -service <name> [flags]",
+		"Prints the container metrics of a service stored in a time range, oldest first.\n"+
+			"Containers are matched by name prefix, as in /metrics/containers/batch.")
+	dbPath := dbFlag(fs)
+	service := fs.String("service", "", "service (container name prefix) to query")
+	since, until := timeRangeFlags(fs)
+	format := outputFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(fs, *format); err != nil {
+		return err
+	}
+	if *service == "" {
+		return usageError(fs, "-service is required")
+	}
+	r, err := parseRange(fs, *since, *until)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.OpenReadOnly(*dbPath)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %v", *dbPath, err)
+	}
+	defer db.Close()
+
+	metrics, err := db.GetContainerMetricsInRange([]string{*service}, r.Start, r.End)
+	if err != nil {
+		return fmt.Errorf("error getting container metrics: %v", err)
+	}
+	if metrics == nil {
+		metrics = []database.ContainerMetric{}
+	}
+
+	if *format == formatJSON {
+		return writeJSON(metrics)
+	}
+	t := newTable("TIMESTAMP", "NAME", "CPU %", "MEM %", "MEMORY", "NET I/O", "BLOCK I/O")
+	for _, m := range metrics {
+		t.row(m.Timestamp, m.Name, m.CPU, m.Memory.Percentage,
+			fmt.Sprintf("%.1f %s / %.1f %s", m.Memory.Used, m.Memory.UsedUnit, m.Memory.Total, m.Memory.TotalUnit),
+			fmt.Sprintf("%.1f %s / %.1f %s", m.Network.Input, m.Network.InputUnit, m.Network.Output, m.Network.OutputUnit),
+			fmt.Sprintf("%.1f %s / %.1f %s", m.BlockIO.Read, m.BlockIO.ReadUnit, m.BlockIO.Write, m.BlockIO.WriteUnit))
+	}
+	return t.flush()
+}
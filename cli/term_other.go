@@ -0,0 +1,19 @@
+//go:build !linux
+
+package cli
+
+import "errors"
+
+// The agent only runs on Linux; elsewhere top prints a single snapshot.
+
+func isTerminal(fd int) bool {
+	return false
+}
+
+func terminalSize(fd int) (int, int, bool) {
+	return 0, 0, false
+}
+
+func readKeys(fd int) (func(), error) {
+	return nil, errors.New("key input is only supported on Linux")
+}
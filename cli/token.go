@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+type createdToken struct {
+	database.APIToken
+	Token string `json:"token"`
+}
+
+func runTokenCreate(args []string) error {
+	fs := newFlagSet("token create", "token create -name <name> [flags]",
+		"Creates a token accepted by the API besides server.token, e.g. one per user or\n"+
+			"integration so it can be revoked on its own. The token is printed only once.")
+	dbPath := dbFlag(fs)
+	name := fs.String("name", "", "unique name of the token")
+	expires := fs.String("expires", "", "lifetime of the token, such as 12h or 90d (default: never expires)")
+	format := outputFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(fs, *format); err != nil {
+		return err
+	}
+	if *name == "" {
+		return usageError(fs, "-name is required")
+	}
+
+	var expiresAt time.Time
+	if *expires != "" {
+		d, err := database.ParseDuration(*expires)
+		if err != nil || d <= 0 {
+			return usageError(fs, "invalid -expires %q", *expires)
+		}
+		expiresAt = time.Now().Add(d)
+	}
+
+	db, err := database.Open(*dbPath)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %v", *dbPath, err)
+	}
+	defer db.Close()
+
+	// The agent creates the table on start, but the token may be created
+	// before it ran with this version.
+	if err := db.InitAPITokensTable(); err != nil {
+		return err
+	}
+	token, t, err := db.CreateAPIToken(*name, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	if *format == formatJSON {
+		return writeJSON(createdToken{APIToken: t, Token: token})
+	}
+	fmt.Println(token)
+	fmt.Fprintf(os.Stderr, "Created token %q. Store it now, it cannot be shown again.\n", t.Name)
+	return nil
+}
+
+func runTokenList(args []string) error {
+	fs := newFlagSet("token list", "token list [flags]",
+		"Lists the tokens created with 'token create'. The tokens themselves are not stored.")
+	dbPath := dbFlag(fs)
+	format := outputFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(fs, *format); err != nil {
+		return err
+	}
+
+	db, err := database.OpenReadOnly(*dbPath)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %v", *dbPath, err)
+	}
+	defer db.Close()
+
+	tokens, err := db.GetAPITokens()
+	if err != nil {
+		return fmt.Errorf("error getting tokens: %v", err)
+	}
+
+	if *format == formatJSON {
+		return writeJSON(tokens)
+	}
+	now := time.Now()
+	t := newTable("NAME", "CREATED", "EXPIRES", "STATUS")
+	for _, token := range tokens {
+		expires, status := "never", "active"
+		if token.ExpiresAt != "" {
+			expires = token.ExpiresAt
+			if at, err := time.Parse(time.RFC3339Nano, token.ExpiresAt); err == nil && !now.Before(at) {
+				status = "expired"
+			}
+		}
+		t.row(token.Name, token.CreatedAt, expires, status)
+	}
+	return t.flush()
+}
+
+func runTokenRevoke(args []string) error {
+	fs := newFlagSet("token revoke", "token revoke -name <name> [flags]",
+		"Deletes a token; requests using it are rejected from then on.")
+	dbPath := dbFlag(fs)
+	name := fs.String("name", "", "name of the token")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return usageError(fs, "-name is required")
+	}
+
+	db, err := database.Open(*dbPath)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %v", *dbPath, err)
+	}
+	defer db.Close()
+
+	found, err := db.DeleteAPIToken(*name)
+	if err != nil {
+		return fmt.Errorf("error revoking token: %v", err)
+	}
+	if !found {
+		return fmt.Errorf("no token named %q", *name)
+	}
+	fmt.Printf("Revoked token %q\n", *name)
+	return nil
+}
@@ -0,0 +1,378 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/containers"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+const (
+	topSamples = 120
+	barWidth   = 30
+
+	defaultWidth  = 100
+	defaultHeight = 40
+)
+
+// ANSI escape sequences used by the live view.
+const (
+	altScreen   = "\x1b[?1049h\x1b[?25l"
+	mainScreen  = "\x1b[?25h\x1b[?1049l"
+	cursorHome  = "\x1b[H"
+	clearLine   = "\x1b[K"
+	clearScreen = "\x1b[J"
+
+	styleBold    = "\x1b[1m"
+	styleInverse = "\x1b[7m"
+	styleRed     = "\x1b[31m"
+	styleYellow  = "\x1b[33m"
+	styleReset   = "\x1b[0m"
+)
+
+var sortKeys = map[byte]string{'c': "cpu", 'm': "memory", 'n': "name"}
+
+type topOptions struct {
+	interval time.Duration
+	sort     string
+	color    bool
+}
+
+func runTop(args []string) error {
+	cfg := agentConfig()
+
+	fs := newFlagSet("top", "top [flags]",
+		"Shows the host metrics, the container usage per service and the firing alerts,\n"+
+			"refreshed every interval. Keys: c, m, n sort by CPU, memory or name; r refreshes; q quits.")
+	apiURL := fs.String("url", defaultURL(cfg), "URL of the agent API")
+	token := fs.String("token", defaultToken(cfg), "API token (default: server.token from METRICS_CONFIG)")
+	dbPath := fs.String("db", "", "read this database file instead of the API")
+	interval := fs.Duration("interval", 2*time.Second, "refresh interval")
+	sortBy := fs.String("sort", "cpu", "sort services by cpu, memory or name")
+	once := fs.Bool("once", false, "print a single snapshot and exit (default when the output is not a terminal)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *sortBy != "cpu" && *sortBy != "memory" && *sortBy != "name" {
+		return usageError(fs, "invalid -sort %q", *sortBy)
+	}
+	if *interval < time.Second {
+		*interval = time.Second
+	}
+
+	var source topSource
+	if *dbPath != "" {
+		db, err := database.OpenReadOnly(*dbPath)
+		if err != nil {
+			return fmt.Errorf("error opening %s: %v", *dbPath, err)
+		}
+		source = &dbSource{db: db, path: *dbPath}
+	} else {
+		source = &apiSource{client: newClient(*apiURL, *token)}
+	}
+	defer source.Close()
+
+	stdout := int(os.Stdout.Fd())
+	opts := topOptions{interval: *interval, sort: *sortBy, color: isTerminal(stdout)}
+	if *once || !isTerminal(stdout) {
+		width, height, ok := terminalSize(stdout)
+		if !ok {
+			width, height = defaultWidth, 0
+		}
+		return writeFrame(os.Stdout, renderTop(source.load(topSamples), source.String(), opts, width, height, time.Now()), false)
+	}
+
+	return liveTop(source, opts)
+}
+
+// liveTop redraws the view on the alternate screen until q or Ctrl+C.
+func liveTop(source topSource, opts topOptions) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	keys := make(chan byte)
+	stdin := int(os.Stdin.Fd())
+	if isTerminal(stdin) {
+		if restore, err := readKeys(stdin); err == nil {
+			defer restore()
+			go func() {
+				r := bufio.NewReader(os.Stdin)
+				for {
+					b, err := r.ReadByte()
+					if err != nil {
+						return
+					}
+					keys <- b
+				}
+			}()
+		}
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	fmt.Fprint(out, altScreen)
+	defer func() {
+		fmt.Fprint(out, mainScreen)
+		out.Flush()
+	}()
+
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+
+	snap := source.load(topSamples)
+	for {
+		width, height, ok := terminalSize(int(os.Stdout.Fd()))
+		if !ok {
+			width, height = defaultWidth, defaultHeight
+		}
+		frame := renderTop(snap, source.String(), opts, width, height, time.Now())
+		if len(frame) > height {
+			frame = frame[:height]
+		}
+		fmt.Fprint(out, cursorHome)
+		if err := writeFrame(out, frame, true); err != nil {
+			return err
+		}
+		out.Flush()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			snap = source.load(topSamples)
+		case key := <-keys:
+			switch key {
+			case 'q', 'Q':
+				return nil
+			case 'r', 'R':
+				snap = source.load(topSamples)
+			default:
+				if sortBy, ok := sortKeys[key]; ok {
+					opts.sort = sortBy
+				}
+			}
+		}
+	}
+}
+
+// line is a line of the view with an optional style, applied after it is
+// cut to the terminal width.
+type line struct {
+	text  string
+	style string
+}
+
+func writeFrame(w io.Writer, lines []line, live bool) error {
+	var b strings.Builder
+	for i, l := range lines {
+		if l.style != "" {
+			b.WriteString(l.style + l.text + styleReset)
+		} else {
+			b.WriteString(l.text)
+		}
+		if live {
+			b.WriteString(clearLine)
+		}
+		// A newline after the last row of the screen would scroll it.
+		if !live || i < len(lines)-1 {
+			b.WriteString("\n")
+		}
+	}
+	if live {
+		b.WriteString(clearScreen)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// renderTop lays out a snapshot in width columns. With a height, the service
+// table is cut to fit; without one every service is listed.
+func renderTop(snap snapshot, source string, opts topOptions, width, height int, now time.Time) []line {
+	var lines []line
+	add := func(style, format string, args ...interface{}) {
+		text := truncate(fmt.Sprintf(format, args...), width)
+		if !opts.color {
+			style = ""
+		}
+		lines = append(lines, line{text: text, style: style})
+	}
+
+	title := fmt.Sprintf("monitoring top · %s · %s · every %s", source, now.Format("15:04:05"), opts.interval)
+	keys := "[c]pu [m]emory [n]ame [r]efresh [q]uit"
+	if pad := width - utf8.RuneCountInString(title) - utf8.RuneCountInString(keys); pad > 0 {
+		title += strings.Repeat(" ", pad) + keys
+	}
+	add(styleInverse, "%s", title)
+	for _, err := range snap.errors {
+		add(styleRed, "error: %s", err)
+	}
+	add("", "")
+
+	if len(snap.host) == 0 {
+		add("", "No host metrics yet")
+	} else {
+		last := snap.host[len(snap.host)-1]
+		system := last.Distro
+		if system == "" {
+			system = last.OS
+		}
+		add(styleBold, "Host     %s · %s · %d cores · up %s · sampled %s ago",
+			system, last.Arch, last.CPUCores, formatUptime(last.Uptime), formatAge(last.Timestamp, now))
+
+		sparkWidth := width - 9 - (barWidth + 2) - 24
+		cpu := make([]float64, len(snap.host))
+		mem := make([]float64, len(snap.host))
+		for i, m := range snap.host {
+			cpu[i] = m.CPU
+			mem[i] = m.MemUsed
+		}
+		add(levelStyle(last.CPU), "CPU      %s %6.1f%%  %-14s %s",
+			bar(last.CPU), last.CPU, "", sparkline(cpu, 100, sparkWidth))
+		add(levelStyle(last.MemUsed), "Memory   %s %6.1f%%  %-14s %s",
+			bar(last.MemUsed), last.MemUsed, fmt.Sprintf("%.1f/%.1f GB", last.MemUsedGB, last.MemTotal), sparkline(mem, 100, sparkWidth))
+		add(levelStyle(last.DiskUsed), "Disk     %s %6.1f%%  %-14s",
+			bar(last.DiskUsed), last.DiskUsed, fmt.Sprintf("of %.1f GB", last.TotalDisk))
+		add("", "Network  ↑ %.2f MB/s  ↓ %.2f MB/s", last.UploadRate, last.DownloadRate)
+	}
+	add("", "")
+
+	add(styleBold, "Alerts (%d)", len(snap.alerts))
+	if len(snap.alerts) == 0 {
+		add("", "  No firing alerts")
+	}
+	for _, a := range snap.alerts {
+		add(styleRed, "  %-12s %-6s %s", a.Type, formatAge(a.Since, now), a.Message)
+	}
+	add("", "")
+
+	services := append([]containers.Sample(nil), snap.services...)
+	sortServices(services, opts.sort)
+	add(styleBold, "Services (%d, by %s)", len(services), opts.sort)
+	if len(services) == 0 {
+		add("", "  No recent container samples")
+		return lines
+	}
+	add(styleBold, "  %-24s %7s %7s  %-22s %-22s %6s", "SERVICE", "CPU %", "MEM %", "MEMORY", "NET I/O", "AGE")
+
+	rows := len(services)
+	if height > 0 && len(lines)+rows > height {
+		rows = height - len(lines) - 1
+		if rows < 0 {
+			rows = 0
+		}
+	}
+	for _, m := range services[:rows] {
+		add(levelStyle(math.Max(m.CPU, m.MemoryPercent)), "  %-24s %7.2f %7.2f  %-22s %-22s %6s",
+			truncate(m.Name, 24), m.CPU, m.MemoryPercent,
+			formatBytes(int64(m.MemoryUsedBytes))+" / "+formatBytes(int64(m.MemoryLimitBytes)),
+			formatBytes(int64(m.NetworkInBytes))+" / "+formatBytes(int64(m.NetworkOutBytes)),
+			formatAge(m.Timestamp, now))
+	}
+	if rows < len(services) {
+		add("", "  … %d more", len(services)-rows)
+	}
+	return lines
+}
+
+func sortServices(services []containers.Sample, by string) {
+	sort.SliceStable(services, func(i, j int) bool {
+		a, b := services[i], services[j]
+		switch {
+		case by == "cpu" && a.CPU != b.CPU:
+			return a.CPU > b.CPU
+		case by == "memory" && a.MemoryPercent != b.MemoryPercent:
+			return a.MemoryPercent > b.MemoryPercent
+		}
+		return a.Name < b.Name
+	})
+}
+
+func levelStyle(percent float64) string {
+	switch {
+	case percent >= 90:
+		return styleRed
+	case percent >= 70:
+		return styleYellow
+	}
+	return ""
+}
+
+func bar(percent float64) string {
+	filled := int(percent/100*barWidth + 0.5)
+	if filled < 0 {
+		filled = 0
+	}
+	if filled > barWidth {
+		filled = barWidth
+	}
+	return "[" + strings.Repeat("|", filled) + strings.Repeat(" ", barWidth-filled) + "]"
+}
+
+var sparkChars = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws the last width values scaled to max.
+func sparkline(values []float64, max float64, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+	runes := make([]rune, len(values))
+	for i, v := range values {
+		idx := int(v / max * float64(len(sparkChars)-1))
+		if idx < 0 {
+			idx = 0
+		}
+		if idx >= len(sparkChars) {
+			idx = len(sparkChars) - 1
+		}
+		runes[i] = sparkChars[idx]
+	}
+	return string(runes)
+}
+
+func truncate(s string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
+
+func formatAge(timestamp string, now time.Time) string {
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return "-"
+	}
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
+func formatUptime(seconds uint64) string {
+	d := time.Duration(seconds) * time.Second
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh", days, hours)
+	}
+	return fmt.Sprintf("%dh %dm", hours, int(d.Minutes())%60)
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/containers"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+// serviceWindow is how old the last sample of a service may be for it to be
+// shown; older samples belong to services that stopped.
+const serviceWindow = 5 * time.Minute
+
+// snapshot is what top shows in one refresh. Each part is loaded on its own,
+// so errors only leave their part empty.
+type snapshot struct {
+	host     []database.ServerMetric
+	services []containers.Sample
+	alerts   []database.ActiveAlert
+	errors   []string
+}
+
+type topSource interface {
+	// load returns up to samples of the latest host metrics, the latest
+	// usage of every service and the firing alerts.
+	load(samples int) snapshot
+	String() string
+	Close() error
+}
+
+// apiSource reads a running agent through its API.
+type apiSource struct {
+	client *client
+}
+
+func (s *apiSource) load(samples int) snapshot {
+	var snap snapshot
+
+	if err := s.client.get("/api/v1/metrics?limit="+strconv.Itoa(samples), &snap.host); err != nil {
+		snap.errors = append(snap.errors, "host metrics: "+err.Error())
+	}
+
+	if err := s.client.get("/api/v1/alerts/active", &snap.alerts); err != nil {
+		snap.errors = append(snap.errors, "alerts: "+err.Error())
+	}
+
+	var services []containers.ServiceSummary
+	if err := s.client.get("/api/v1/services", &services); err != nil {
+		snap.errors = append(snap.errors, "services: "+err.Error())
+	}
+	since := time.Now().Add(-serviceWindow)
+	for _, svc := range services {
+		if !svc.Monitored {
+			continue
+		}
+		var metrics []containers.Sample
+		if err := s.client.get("/api/v1/metrics/containers?limit=1&appName="+url.QueryEscape(svc.Service), &metrics); err != nil {
+			snap.errors = append(snap.errors, svc.Service+": "+err.Error())
+			continue
+		}
+		if len(metrics) == 0 || !sampledSince(metrics[len(metrics)-1].Timestamp, since) {
+			continue
+		}
+		snap.services = append(snap.services, metrics[len(metrics)-1])
+	}
+
+	return snap
+}
+
+func (s *apiSource) String() string {
+	return s.client.url
+}
+
+func (s *apiSource) Close() error {
+	return nil
+}
+
+// dbSource reads the database of an agent directly, e.g. when its API is
+// not reachable. It works without the agent running, showing the state it
+// left.
+type dbSource struct {
+	db   *database.DB
+	path string
+}
+
+func (s *dbSource) load(samples int) snapshot {
+	var snap snapshot
+	var err error
+
+	if snap.host, err = s.db.GetLastNMetrics(samples); err != nil {
+		snap.errors = append(snap.errors, "host metrics: "+err.Error())
+	}
+	services, err := s.db.GetLatestContainerMetrics(time.Now().Add(-serviceWindow))
+	if err != nil {
+		snap.errors = append(snap.errors, "services: "+err.Error())
+	}
+	for _, m := range services {
+		snap.services = append(snap.services, containers.NewSample(m))
+	}
+	// Agents older than the active alerts table don't have it.
+	if snap.alerts, err = s.db.GetActiveAlerts(); err != nil {
+		snap.errors = append(snap.errors, "alerts: "+err.Error())
+	}
+	return snap
+}
+
+func (s *dbSource) String() string {
+	return s.path
+}
+
+func (s *dbSource) Close() error {
+	return s.db.Close()
+}
+
+func sampledSince(timestamp string, since time.Time) bool {
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+	return err == nil && !t.Before(since)
+}
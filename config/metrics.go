@@ -9,15 +9,16 @@ import (
 
 type Config struct {
 	Server struct {
-		Bandwidth     int    `json:"bandwidth"`
-		ServerType    string `json:"type"`
-		RefreshRate   int    `json:"refreshRate"`
-		Port          int    `json:"port"`
-		Token         string `json:"token"`
-		UrlCallback   string `json:"urlCallback"`
-		CronJob       string `json:"cronJob"`
-		RetentionDays int    `json:"retentionDays"`
-		Thresholds    struct {
+		Bandwidth       int    `json:"bandwidth"`
+		ServerType      string `json:"type"`
+		RefreshRate     int    `json:"refreshRate"`
+		Port            int    `json:"port"`
+		Token           string `json:"token"`
+		UrlCallback     string `json:"urlCallback"`
+		CronJob         string `json:"cronJob"`
+		RetentionDays   int    `json:"retentionDays"`
+		ShutdownTimeout int    `json:"shutdownTimeout"`
+		Thresholds      struct {
 			CPU    int `json:"cpu"`
 			Memory int `json:"memory"`
 		} `json:"thresholds"`
@@ -28,7 +29,193 @@ type Config struct {
 			Include []string `json:"include"`
 			Exclude []string `json:"exclude"`
 		} `json:"services"`
+		Logs struct {
+			Enabled     bool         `json:"enabled"`
+			RefreshRate int          `json:"refreshRate"`
+			Threshold   float64      `json:"threshold"`
+			BufferSize  int          `json:"bufferSize"`
+			Patterns    []LogPattern `json:"patterns"`
+		} `json:"logs"`
 	} `json:"containers"`
+	Probes       []ProbeConfig `json:"probes"`
+	Certificates struct {
+		RefreshRate int               `json:"refreshRate"`
+		Thresholds  []int             `json:"thresholds"`
+		Hosts       []CertificateHost `json:"hosts"`
+		Files       []string          `json:"files"`
+		AcmeFile    string            `json:"acmeFile"`
+	} `json:"certificates"`
+	Ingest struct {
+		StatsdAddress       string `json:"statsdAddress"`
+		StatsdFlushInterval int    `json:"statsdFlushInterval"`
+	} `json:"ingest"`
+	Checks struct {
+		Concurrency int           `json:"concurrency"`
+		Commands    []CheckConfig `json:"commands"`
+	} `json:"checks"`
+	Scrape struct {
+		Discovery bool           `json:"discovery"`
+		Interval  int            `json:"interval"`
+		Timeout   int            `json:"timeout"`
+		Metrics   []string       `json:"metrics"`
+		Targets   []ScrapeTarget `json:"targets"`
+	} `json:"scrape"`
+	Push struct {
+		Enabled        bool              `json:"enabled"`
+		URL            string            `json:"url"`
+		Token          string            `json:"token"`
+		Host           string            `json:"host"`
+		Interval       int               `json:"interval"`
+		BatchSize      int               `json:"batchSize"`
+		BufferDir      string            `json:"bufferDir"`
+		MaxBufferFiles int               `json:"maxBufferFiles"`
+		Headers        map[string]string `json:"headers"`
+	} `json:"push"`
+	Aggregator struct {
+		Enabled      bool          `json:"enabled"`
+		PullInterval int           `json:"pullInterval"`
+		Agents       []AgentConfig `json:"agents"`
+	} `json:"aggregator"`
+	Exporters struct {
+		Labels      map[string]string `json:"labels"`
+		RemoteWrite RemoteWriteConfig `json:"remoteWrite"`
+		OTLP        OTLPConfig        `json:"otlp"`
+		Influx      InfluxConfig      `json:"influx"`
+		Graphite    GraphiteConfig    `json:"graphite"`
+	} `json:"exporters"`
+	Logging struct {
+		Level      string            `json:"level"`
+		Format     string            `json:"format"`
+		Components map[string]string `json:"components"`
+	} `json:"logging"`
+	Health struct {
+		MinFreeDiskMB  int `json:"minFreeDiskMB"`
+		StaleIntervals int `json:"staleIntervals"`
+		Timeout        int `json:"timeout"`
+	} `json:"health"`
+	Reports struct {
+		SMTP    SMTPConfig     `json:"smtp"`
+		Digests []DigestConfig `json:"digests"`
+	} `json:"reports"`
+}
+
+type LogPattern struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+type ProbeConfig struct {
+	Name               string `json:"name"`
+	Type               string `json:"type"`
+	URL                string `json:"url"`
+	Address            string `json:"address"`
+	Interval           int    `json:"interval"`
+	Timeout            int    `json:"timeout"`
+	ExpectedStatus     int    `json:"expectedStatus"`
+	BodyContains       string `json:"bodyContains"`
+	FailureThreshold   int    `json:"failureThreshold"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"`
+}
+
+type CertificateHost struct {
+	Host       string `json:"host"`
+	Port       int    `json:"port"`
+	ServerName string `json:"serverName"`
+}
+
+type CheckConfig struct {
+	Name     string   `json:"name"`
+	Command  string   `json:"command"`
+	Args     []string `json:"args"`
+	Mode     string   `json:"mode"`
+	Interval int      `json:"interval"`
+	Timeout  int      `json:"timeout"`
+}
+
+type ScrapeTarget struct {
+	Job    string            `json:"job"`
+	URL    string            `json:"url"`
+	Labels map[string]string `json:"labels"`
+}
+
+type AgentConfig struct {
+	Host  string `json:"host"`
+	URL   string `json:"url"`
+	Token string `json:"token"`
+}
+
+type SMTPConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+}
+
+// DigestConfig schedules a report sent by email to To, posted to Webhook,
+// or both.
+type DigestConfig struct {
+	Name     string            `json:"name"`
+	Schedule string            `json:"schedule"`
+	Range    string            `json:"range"`
+	Top      int               `json:"top"`
+	To       []string          `json:"to"`
+	Webhook  string            `json:"webhook"`
+	Headers  map[string]string `json:"headers"`
+}
+
+// QueueConfig tunes the write-ahead queue of an exporter.
+type RemoteWriteConfig struct {
+	Enabled     bool              `json:"enabled"`
+	URL         string            `json:"url"`
+	BearerToken string            `json:"bearerToken"`
+	Username    string            `json:"username"`
+	Password    string            `json:"password"`
+	Headers     map[string]string `json:"headers"`
+	Timeout     int               `json:"timeout"`
+	Queue       QueueConfig       `json:"queue"`
+}
+
+type OTLPConfig struct {
+	Enabled  bool              `json:"enabled"`
+	Endpoint string            `json:"endpoint"`
+	Headers  map[string]string `json:"headers"`
+	Timeout  int               `json:"timeout"`
+	Queue    QueueConfig       `json:"queue"`
+}
+
+type InfluxConfig struct {
+	Enabled  bool              `json:"enabled"`
+	URL      string            `json:"url"`
+	Org      string            `json:"org"`
+	Bucket   string            `json:"bucket"`
+	Token    string            `json:"token"`
+	Database string            `json:"database"`
+	Username string            `json:"username"`
+	Password string            `json:"password"`
+	Headers  map[string]string `json:"headers"`
+	Timeout  int               `json:"timeout"`
+	Queue    QueueConfig       `json:"queue"`
+}
+
+type GraphiteConfig struct {
+	Enabled bool        `json:"enabled"`
+	Address string      `json:"address"`
+	Prefix  string      `json:"prefix"`
+	Tagged  bool        `json:"tagged"`
+	Timeout int         `json:"timeout"`
+	Queue   QueueConfig `json:"queue"`
+}
+
+type QueueConfig struct {
+	Shards        int    `json:"shards"`
+	BatchSize     int    `json:"batchSize"`
+	FlushInterval int    `json:"flushInterval"`
+	MaxRetries    int    `json:"maxRetries"`
+	WALDir        string `json:"walDir"`
+	MaxSegments   int    `json:"maxSegments"`
+	Capacity      int    `json:"capacity"`
+	BlockTimeout  int    `json:"blockTimeout"`
 }
 
 var (
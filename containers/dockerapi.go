@@ -0,0 +1,214 @@
+package containers
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultDockerHost = "unix:///var/run/docker.sock"
+
+// DockerClient is a minimal client for the Docker Engine API. It honours
+// DOCKER_HOST for unix sockets and tcp endpoints.
+type DockerClient struct {
+	client  *http.Client
+	baseURL string
+}
+
+type APIContainer struct {
+	ID     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	Image  string            `json:"Image"`
+	State  string            `json:"State"`
+	Labels map[string]string `json:"Labels"`
+	Ports  []struct {
+		PrivatePort int    `json:"PrivatePort"`
+		Type        string `json:"Type"`
+	} `json:"Ports"`
+	NetworkSettings struct {
+		Networks map[string]struct {
+			IPAddress string `json:"IPAddress"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// Name returns the container name without the leading slash.
+func (c APIContainer) Name() string {
+	if len(c.Names) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
+
+// IPAddress returns the address of the container on its first network.
+func (c APIContainer) IPAddress() string {
+	networks := make([]string, 0, len(c.NetworkSettings.Networks))
+	for name := range c.NetworkSettings.Networks {
+		networks = append(networks, name)
+	}
+	sort.Strings(networks)
+	for _, name := range networks {
+		if ip := c.NetworkSettings.Networks[name].IPAddress; ip != "" {
+			return ip
+		}
+	}
+	return ""
+}
+
+func NewDockerClient() *DockerClient {
+	host := os.Getenv("DOCKER_HOST")
+	if host == "" {
+		host = defaultDockerHost
+	}
+
+	if strings.HasPrefix(host, "unix://") {
+		socket := strings.TrimPrefix(host, "unix://")
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+		return &DockerClient{
+			client:  &http.Client{Transport: transport},
+			baseURL: "http://docker",
+		}
+	}
+
+	return &DockerClient{
+		client:  &http.Client{},
+		baseURL: "http://" + strings.TrimPrefix(host, "tcp://"),
+	}
+}
+
+// Ping checks that the Docker Engine API answers.
+func (d *DockerClient) Ping(ctx context.Context) error {
+	resp, err := d.get(ctx, "/_ping", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// ListContainers returns the running containers.
+func (d *DockerClient) ListContainers(ctx context.Context) ([]APIContainer, error) {
+	resp, err := d.get(ctx, "/containers/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result []APIContainer
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error decoding container list: %v", err)
+	}
+	return result, nil
+}
+
+// IsTTY reports whether the container was started with a terminal, in which
+// case its log stream is not multiplexed.
+func (d *DockerClient) IsTTY(ctx context.Context, id string) (bool, error) {
+	resp, err := d.get(ctx, "/containers/"+id+"/json", nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	var inspect struct {
+		Config struct {
+			Tty bool `json:"Tty"`
+		} `json:"Config"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&inspect); err != nil {
+		return false, fmt.Errorf("error decoding container %s: %v", id, err)
+	}
+	return inspect.Config.Tty, nil
+}
+
+// FollowLogs streams stdout and stderr of a container starting at since,
+// inclusive. The returned reader yields plain log output for both TTY and
+// non-TTY containers, each line prefixed with its RFC3339Nano timestamp and a
+// space.
+func (d *DockerClient) FollowLogs(ctx context.Context, id string, since time.Time) (io.ReadCloser, error) {
+	tty, err := d.IsTTY(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("follow", "1")
+	query.Set("stdout", "1")
+	query.Set("stderr", "1")
+	query.Set("timestamps", "1")
+	query.Set("since", fmt.Sprintf("%d.%09d", since.Unix(), since.Nanosecond()))
+
+	resp, err := d.get(ctx, "/containers/"+id+"/logs", query)
+	if err != nil {
+		return nil, err
+	}
+	if tty {
+		return resp.Body, nil
+	}
+	return &demuxReader{body: resp.Body}, nil
+}
+
+func (d *DockerClient) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	u := d.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("docker API request failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("docker API returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+// demuxReader strips the 8 byte frame headers Docker adds to the log stream
+// of containers without a TTY.
+type demuxReader struct {
+	body      io.ReadCloser
+	remaining uint32
+}
+
+func (r *demuxReader) Read(p []byte) (int, error) {
+	for r.remaining == 0 {
+		var header [8]byte
+		if _, err := io.ReadFull(r.body, header[:]); err != nil {
+			return 0, err
+		}
+		r.remaining = binary.BigEndian.Uint32(header[4:])
+	}
+
+	if uint32(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.body.Read(p)
+	r.remaining -= uint32(n)
+	return n, err
+}
+
+func (r *demuxReader) Close() error {
+	return r.body.Close()
+}
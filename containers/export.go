@@ -0,0 +1,44 @@
+package containers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/exporters"
+)
+
+// ContainerSamples converts a container metric to exporter samples in base
+// units, labeled with the container and its service.
+func ContainerSamples(metric *database.ContainerMetric) []exporters.Sample {
+	ts, err := time.Parse(time.RFC3339Nano, metric.Timestamp)
+	if err != nil {
+		ts = time.Now()
+	}
+
+	name := strings.TrimPrefix(metric.Name, "/")
+	labels := map[string]string{
+		"container":    name,
+		"container_id": metric.ID,
+		"service":      GetServiceName(name),
+	}
+
+	values := []struct {
+		name  string
+		value float64
+	}{
+		{"container_cpu_usage_percent", metric.CPU},
+		{"container_memory_usage_percent", metric.Memory.Percentage},
+		{"container_memory_used_bytes", memoryToMB(metric.Memory.Used, metric.Memory.UsedUnit) * 1024 * 1024},
+		{"container_network_receive_bytes_total", ioToBytes(metric.Network.Input, metric.Network.InputUnit)},
+		{"container_network_transmit_bytes_total", ioToBytes(metric.Network.Output, metric.Network.OutputUnit)},
+		{"container_block_read_bytes_total", ioToBytes(metric.BlockIO.Read, metric.BlockIO.ReadUnit)},
+		{"container_block_write_bytes_total", ioToBytes(metric.BlockIO.Write, metric.BlockIO.WriteUnit)},
+	}
+
+	samples := make([]exporters.Sample, len(values))
+	for i, v := range values {
+		samples[i] = exporters.Sample{Name: v.name, Labels: labels, Value: v.value, Timestamp: ts}
+	}
+	return samples
+}
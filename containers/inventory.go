@@ -0,0 +1,201 @@
+package containers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+// Labels set by Docker Compose and Swarm that identify the service a
+// container belongs to.
+const (
+	composeProjectLabel = "com.docker.compose.project"
+	swarmServiceLabel   = "com.docker.swarm.service.name"
+	stackNamespaceLabel = "com.docker.stack.namespace"
+)
+
+type ContainerInfo struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Service    string            `json:"service"`
+	Project    string            `json:"project,omitempty"`
+	Image      string            `json:"image"`
+	State      string            `json:"state"`
+	StartedAt  string            `json:"startedAt"`
+	Labels     map[string]string `json:"labels"`
+	Monitored  bool              `json:"monitored"`
+	LastSample string            `json:"lastSample,omitempty"`
+}
+
+type ServiceSummary struct {
+	Service    string   `json:"service"`
+	Project    string   `json:"project,omitempty"`
+	Replicas   int      `json:"replicas"`
+	Images     []string `json:"images"`
+	Containers []string `json:"containers"`
+	Monitored  bool     `json:"monitored"`
+	LastSample string   `json:"lastSample,omitempty"`
+}
+
+// dockerInspect is the subset of `docker inspect` output used by the inventory.
+type dockerInspect struct {
+	ID    string `json:"Id"`
+	Name  string `json:"Name"`
+	State struct {
+		Status    string `json:"Status"`
+		StartedAt string `json:"StartedAt"`
+	} `json:"State"`
+	Config struct {
+		Image  string            `json:"Image"`
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+}
+
+// ListContainers returns the running containers together with their service
+// identity and whether the container monitor collects metrics for them.
+func ListContainers(db *database.DB) ([]ContainerInfo, error) {
+	output, err := exec.Command("docker", "ps", "-q").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error listing containers: %v", err)
+	}
+
+	ids := strings.Fields(string(output))
+	if len(ids) == 0 {
+		return []ContainerInfo{}, nil
+	}
+
+	args := append([]string{"inspect"}, ids...)
+	output, err = exec.Command("docker", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("error inspecting containers: %v", err)
+	}
+
+	var inspected []dockerInspect
+	if err := json.Unmarshal(output, &inspected); err != nil {
+		return nil, fmt.Errorf("error parsing docker inspect output: %v", err)
+	}
+
+	lastSamples, err := db.GetLastContainerSampleTimes()
+	if err != nil {
+		return nil, err
+	}
+
+	// Samples are stored per service, keyed by the name of the container that
+	// was sampled, so index them the same way the collector groups containers.
+	lastByService := make(map[string]string)
+	for name, ts := range lastSamples {
+		service := GetServiceName(name)
+		if ts > lastByService[service] {
+			lastByService[service] = ts
+		}
+	}
+
+	result := make([]ContainerInfo, 0, len(inspected))
+	for _, c := range inspected {
+		name := strings.TrimPrefix(c.Name, "/")
+		id := c.ID
+		if len(id) > 12 {
+			id = id[:12]
+		}
+		labels := c.Config.Labels
+		if labels == nil {
+			labels = map[string]string{}
+		}
+
+		result = append(result, ContainerInfo{
+			ID:         id,
+			Name:       name,
+			Service:    ServiceIdentity(name, labels),
+			Project:    projectIdentity(labels),
+			Image:      c.Config.Image,
+			State:      c.State.Status,
+			StartedAt:  c.State.StartedAt,
+			Labels:     labels,
+			Monitored:  isMonitored(name),
+			LastSample: lastByService[GetServiceName(name)],
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+// SummarizeServices groups containers by service and counts their replicas.
+func SummarizeServices(containers []ContainerInfo) []ServiceSummary {
+	byService := make(map[string]*ServiceSummary)
+	var order []string
+
+	for _, c := range containers {
+		summary, ok := byService[c.Service]
+		if !ok {
+			summary = &ServiceSummary{
+				Service:    c.Service,
+				Project:    c.Project,
+				Images:     []string{},
+				Containers: []string{},
+			}
+			byService[c.Service] = summary
+			order = append(order, c.Service)
+		}
+
+		summary.Replicas++
+		summary.Containers = append(summary.Containers, c.Name)
+		if !containsString(summary.Images, c.Image) {
+			summary.Images = append(summary.Images, c.Image)
+		}
+		if c.Monitored {
+			summary.Monitored = true
+		}
+		if c.LastSample > summary.LastSample {
+			summary.LastSample = c.LastSample
+		}
+	}
+
+	sort.Strings(order)
+	result := make([]ServiceSummary, 0, len(order))
+	for _, service := range order {
+		result = append(result, *byService[service])
+	}
+	return result
+}
+
+// ServiceIdentity returns the swarm service of a container, falling back to
+// the service name derived from the container name.
+func ServiceIdentity(containerName string, labels map[string]string) string {
+	if svc := labels[swarmServiceLabel]; svc != "" {
+		return svc
+	}
+	return GetServiceName(containerName)
+}
+
+func projectIdentity(labels map[string]string) string {
+	if project := labels[composeProjectLabel]; project != "" {
+		return project
+	}
+	return labels[stackNamespaceLabel]
+}
+
+// isMonitored mirrors the checks done by the collector: nothing is collected
+// unless at least one service is included.
+func isMonitored(containerName string) bool {
+	if monitorConfig == nil || len(monitorConfig.IncludeServices) == 0 {
+		return false
+	}
+	return ShouldMonitorContainer(containerName)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
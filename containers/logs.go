@@ -0,0 +1,442 @@
+package containers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/monitoring"
+	"github.com/mauriciogm/dokploy/apps/monitoring/telemetry"
+)
+
+const (
+	defaultLogBufferSize = 100
+	maxLogLineLength     = 1024
+	logRetryDelay        = 5 * time.Second
+
+	// Only the first maxLogLineRead bytes of a line are read and matched.
+	maxLogLineRead = 1024 * 1024
+)
+
+// defaultLogPatterns is used when no patterns are configured.
+var defaultLogPatterns = []config.LogPattern{
+	{Name: "error", Pattern: `ERROR`},
+	{Name: "panic", Pattern: `panic:`},
+	{Name: "http_5xx", Pattern: `"\s5\d\d\s|\sHTTP/\d(\.\d)?"?\s5\d\d\s`},
+}
+
+type LogMatch struct {
+	Timestamp string `json:"timestamp"`
+	Service   string `json:"service"`
+	Container string `json:"container"`
+	Pattern   string `json:"pattern"`
+	Line      string `json:"line"`
+}
+
+type logPattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// LogMonitor tails the logs of monitored containers and counts the lines
+// matching the configured patterns per interval.
+type LogMonitor struct {
+	db       *database.DB
+	docker   *DockerClient
+	patterns []logPattern
+
+	mu       sync.Mutex
+	counts   map[string]map[string]int // service -> pattern -> matches
+	matched  map[string]int            // service -> lines matching any pattern
+	lines    map[string]int            // service -> lines read
+	tails    map[string]context.CancelFunc
+	alerting map[string]bool
+
+	recent     []LogMatch
+	recentNext int
+
+	bufferSize int
+	stopChan   chan struct{}
+	wg         sync.WaitGroup
+}
+
+func NewLogMonitor(db *database.DB) (*LogMonitor, error) {
+	if err := db.InitContainerLogMetricsTable(); err != nil {
+		return nil, fmt.Errorf("failed to initialize container log metrics table: %v", err)
+	}
+
+	cfg := config.GetMetricsConfig().Containers.Logs
+	configured := cfg.Patterns
+	if len(configured) == 0 {
+		configured = defaultLogPatterns
+	}
+
+	patterns := make([]logPattern, 0, len(configured))
+	for _, p := range configured {
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log pattern %q: %v", p.Name, err)
+		}
+		name := p.Name
+		if name == "" {
+			name = p.Pattern
+		}
+		patterns = append(patterns, logPattern{name: name, re: re})
+	}
+
+	bufferSize := cfg.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultLogBufferSize
+	}
+
+	return &LogMonitor{
+		db:         db,
+		docker:     NewDockerClient(),
+		patterns:   patterns,
+		counts:     make(map[string]map[string]int),
+		matched:    make(map[string]int),
+		lines:      make(map[string]int),
+		tails:      make(map[string]context.CancelFunc),
+		alerting:   make(map[string]bool),
+		bufferSize: bufferSize,
+		stopChan:   make(chan struct{}),
+	}, nil
+}
+
+func (lm *LogMonitor) Start() error {
+	cfg := config.GetMetricsConfig().Containers.Logs
+	if !cfg.Enabled {
+		return nil
+	}
+
+	if err := LoadConfig(); err != nil {
+		return fmt.Errorf("error loading config: %v", err)
+	}
+
+	if len(monitorConfig.IncludeServices) == 0 {
+		logger.Info("No services to monitor. Skipping container log monitoring")
+		return nil
+	}
+
+	refreshRate := cfg.RefreshRate
+	if refreshRate == 0 {
+		refreshRate = 60
+	}
+	interval := time.Duration(refreshRate) * time.Second
+
+	lm.syncTails()
+
+	telemetry.Expect("container_logs", interval)
+	ticker := time.NewTicker(interval)
+	lm.wg.Add(1)
+	go func() {
+		defer lm.wg.Done()
+		for {
+			select {
+			case <-ticker.C:
+				lm.flush(interval)
+				lm.syncTails()
+			case <-lm.stopChan:
+				ticker.Stop()
+				lm.mu.Lock()
+				for id, cancel := range lm.tails {
+					cancel()
+					delete(lm.tails, id)
+				}
+				lm.mu.Unlock()
+				// Store the counts of the unfinished interval.
+				lm.flush(interval)
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (lm *LogMonitor) Stop() {
+	close(lm.stopChan)
+	lm.wg.Wait()
+}
+
+// RecentMatches returns the buffered matching lines of the service of an app
+// name, as matched by database.AppService and database.IsServiceContainer,
+// oldest first. Without app name every buffered line is returned.
+func (lm *LogMonitor) RecentMatches(appName string) []LogMatch {
+	service := database.AppService(appName)
+
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	result := []LogMatch{}
+	n := len(lm.recent)
+	for i := 0; i < n; i++ {
+		// Once the buffer is full recentNext points at the oldest entry.
+		m := lm.recent[(lm.recentNext+i)%n]
+		if service == "" || database.IsServiceContainer(m.Service, service) {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// syncTails starts following the logs of new monitored containers and stops
+// following containers that are gone.
+func (lm *LogMonitor) syncTails() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	list, err := lm.docker.ListContainers(ctx)
+	if err != nil {
+		logger.Error("Error listing containers for log monitoring", "error", err)
+		return
+	}
+
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	running := make(map[string]bool)
+	for _, c := range list {
+		name := c.Name()
+		if !ShouldMonitorContainer(name) {
+			continue
+		}
+		running[c.ID] = true
+		if _, ok := lm.tails[c.ID]; ok {
+			continue
+		}
+
+		tailCtx, tailCancel := context.WithCancel(context.Background())
+		lm.tails[c.ID] = tailCancel
+		lm.wg.Add(1)
+		go lm.tail(tailCtx, c.ID, name)
+	}
+
+	for id, cancel := range lm.tails {
+		if !running[id] {
+			cancel()
+			delete(lm.tails, id)
+		}
+	}
+}
+
+// tail follows the logs of one container until ctx is cancelled, reconnecting
+// when the stream ends. A new stream resumes after the last line read, so
+// lines written while reconnecting are neither lost nor counted twice.
+func (lm *LogMonitor) tail(ctx context.Context, id, name string) {
+	defer lm.wg.Done()
+	service := GetServiceName(name)
+	since := time.Now()
+
+	for {
+		stream, err := lm.docker.FollowLogs(ctx, id, since)
+		if err == nil {
+			err = readLines(stream, maxLogLineRead, func(text string) {
+				at, line := splitLogTimestamp(text)
+				if !at.IsZero() {
+					// since is inclusive.
+					since = at.Add(time.Nanosecond)
+				} else {
+					at = time.Now()
+				}
+				lm.process(service, name, at, line)
+			})
+			stream.Close()
+			if err != nil && ctx.Err() == nil {
+				logger.Warn("Error reading container logs", "container", name, "error", err)
+			}
+		} else if ctx.Err() == nil {
+			logger.Error("Error following container logs", "container", name, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(logRetryDelay):
+		}
+	}
+}
+
+// readLines calls fn with every line of r without its line ending. Lines
+// longer than max bytes are cut to their first max bytes and the rest is
+// skipped, so a huge line can't stop the reading like with bufio.Scanner.
+func readLines(r io.Reader, max int, fn func(string)) error {
+	reader := bufio.NewReader(r)
+	var line []byte
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if room := max - len(line); room > 0 {
+			if len(chunk) > room {
+				chunk = chunk[:room]
+			}
+			line = append(line, chunk...)
+		}
+
+		switch err {
+		case nil:
+			line = bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+			fn(string(line))
+			line = line[:0]
+		case bufio.ErrBufferFull:
+			// The rest of the line follows in the next chunks.
+		default:
+			if len(line) > 0 {
+				fn(string(line))
+			}
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// splitLogTimestamp splits the timestamp Docker adds to a log line from the
+// line. The time is zero when the line has no valid timestamp.
+func splitLogTimestamp(text string) (time.Time, string) {
+	prefix, line, ok := strings.Cut(text, " ")
+	if !ok {
+		prefix, line = text, ""
+	}
+	at, err := time.Parse(time.RFC3339Nano, prefix)
+	if err != nil {
+		return time.Time{}, text
+	}
+	return at, line
+}
+
+// process counts a line once per pattern it matches, and once toward the
+// error rate of the service whatever the number of patterns.
+func (lm *LogMonitor) process(service, container string, at time.Time, line string) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	lm.lines[service]++
+	matched := false
+	for _, p := range lm.patterns {
+		if !p.re.MatchString(line) {
+			continue
+		}
+
+		if lm.counts[service] == nil {
+			lm.counts[service] = make(map[string]int)
+		}
+		lm.counts[service][p.name]++
+		matched = true
+
+		stored := line
+		if len(stored) > maxLogLineLength {
+			stored = stored[:maxLogLineLength]
+		}
+		lm.record(LogMatch{
+			Timestamp: at.UTC().Format(time.RFC3339Nano),
+			Service:   service,
+			Container: container,
+			Pattern:   p.name,
+			Line:      stored,
+		})
+	}
+	if matched {
+		lm.matched[service]++
+	}
+}
+
+// record appends a match to the ring buffer. Callers must hold lm.mu.
+func (lm *LogMonitor) record(m LogMatch) {
+	if len(lm.recent) < lm.bufferSize {
+		lm.recent = append(lm.recent, m)
+		return
+	}
+	lm.recent[lm.recentNext] = m
+	lm.recentNext = (lm.recentNext + 1) % lm.bufferSize
+}
+
+// flush stores the counts of the finished interval and checks the error rate
+// of every service against the configured threshold.
+func (lm *LogMonitor) flush(interval time.Duration) {
+	cycle := telemetry.StartCycle("container_logs")
+	defer cycle.Done()
+
+	lm.mu.Lock()
+	counts, matched, lines := lm.counts, lm.matched, lm.lines
+	lm.counts = make(map[string]map[string]int)
+	lm.matched = make(map[string]int)
+	lm.lines = make(map[string]int)
+	lm.mu.Unlock()
+
+	// Services still marked as alerting are included so a quiet interval
+	// clears the alert state.
+	services := make(map[string]bool)
+	for service := range lines {
+		services[service] = true
+	}
+	for service, alerting := range lm.alerting {
+		if alerting {
+			services[service] = true
+		}
+	}
+
+	timestamp := time.Now().UTC().Format(time.RFC3339Nano)
+	threshold := config.GetMetricsConfig().Containers.Logs.Threshold
+
+	names := make([]string, 0, len(services))
+	for service := range services {
+		names = append(names, service)
+	}
+	sort.Strings(names)
+
+	for _, service := range names {
+		for _, p := range lm.patterns {
+			count := counts[service][p.name]
+			err := lm.db.SaveContainerLogMetric(database.ContainerLogMetric{
+				Timestamp: timestamp,
+				Service:   service,
+				Pattern:   p.name,
+				Count:     count,
+				Lines:     lines[service],
+			})
+			if err != nil {
+				logger.Error("Error saving log metrics", "service", service, "error", err)
+				cycle.Error()
+			}
+		}
+
+		if threshold <= 0 {
+			continue
+		}
+
+		key := "logs:" + service
+		rate := float64(matched[service]) / interval.Minutes()
+		if rate <= threshold {
+			lm.alerting[service] = false
+			monitoring.ResolveAlert(key)
+			continue
+		}
+
+		alert := monitoring.AlertPayload{
+			Type:      "Logs",
+			Value:     rate,
+			Threshold: threshold,
+			Message:   fmt.Sprintf("Log error rate of %s (%.2f matches/min) exceeded threshold (%.2f matches/min)", service, rate, threshold),
+			Timestamp: timestamp,
+		}
+		monitoring.RaiseAlert(key, alert)
+		if lm.alerting[service] {
+			continue
+		}
+		lm.alerting[service] = true
+
+		if err := monitoring.SendAlert(alert); err != nil {
+			logger.Error("Error sending log alert", "service", service, "error", err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package containers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSplitLogTimestamp(t *testing.T) {
+	at, line := splitLogTimestamp("2026-10-15T10:00:01.123456789Z ERROR something failed")
+	if want := time.Date(2026, 10, 15, 10, 0, 1, 123456789, time.UTC); !at.Equal(want) || line != "ERROR something failed" {
+		t.Errorf("got %v %q, want %v and the line without timestamp", at, line, want)
+	}
+
+	at, line = splitLogTimestamp("ERROR no timestamp")
+	if !at.IsZero() || line != "ERROR no timestamp" {
+		t.Errorf("got %v %q for a line without timestamp, want it unchanged", at, line)
+	}
+}
+
+func TestProcessCountsLinesOnce(t *testing.T) {
+	lm := &LogMonitor{
+		patterns: []logPattern{
+			{name: "error", re: regexp.MustCompile(`ERROR`)},
+			{name: "panic", re: regexp.MustCompile(`panic:`)},
+		},
+		counts:     make(map[string]map[string]int),
+		matched:    make(map[string]int),
+		lines:      make(map[string]int),
+		bufferSize: 10,
+	}
+
+	now := time.Now()
+	lm.process("app", "app-1", now, "ERROR panic: nil map")
+	lm.process("app", "app-1", now, "ERROR timeout")
+	lm.process("app", "app-1", now, "all good")
+
+	if lm.lines["app"] != 3 || lm.matched["app"] != 2 {
+		t.Errorf("got %d lines and %d matching, want 3 and 2", lm.lines["app"], lm.matched["app"])
+	}
+	if lm.counts["app"]["error"] != 2 || lm.counts["app"]["panic"] != 1 {
+		t.Errorf("got pattern counts %v, want error 2 and panic 1", lm.counts["app"])
+	}
+	if len(lm.recent) != 3 {
+		t.Errorf("got %d recent matches, want one per pattern matched", len(lm.recent))
+	}
+}
+
+func TestRecentMatchesMatchesServices(t *testing.T) {
+	lm := &LogMonitor{bufferSize: 10}
+	for _, service := range []string{"myapp", "myapp2", "myapplication", "my_pp", "other"} {
+		lm.record(LogMatch{Service: service, Line: "ERROR in " + service})
+	}
+
+	for _, tc := range []struct {
+		appName string
+		want    []string
+	}{
+		{"myapp-xyz123", []string{"myapp"}},
+		{"myapp", []string{"myapp"}},
+		{"my_pp-abc", []string{"my_pp"}},
+		{"my%p-abc", nil},
+		{"", []string{"myapp", "myapp2", "myapplication", "my_pp", "other"}},
+	} {
+		var got []string
+		for _, m := range lm.RecentMatches(tc.appName) {
+			got = append(got, m.Service)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got services %v, want %v", tc.appName, got, tc.want)
+		}
+	}
+}
+
+func TestReadLinesTruncatesLongLines(t *testing.T) {
+	long := strings.Repeat("x", 100)
+	input := "first\r\n" + long + "\nlast"
+
+	var got []string
+	if err := readLines(strings.NewReader(input), 40, func(line string) { got = append(got, line) }); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"first", long[:40], "last"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got lines %q, want %q", got, want)
+	}
+}
+
+func TestTailSkipsOverLongLines(t *testing.T) {
+	at := time.Date(2026, 10, 15, 10, 0, 0, 0, time.UTC)
+	stamp := func(d time.Duration) string { return at.Add(d).Format(time.RFC3339Nano) }
+	logs := stamp(0) + " ERROR before\n" +
+		stamp(time.Second) + " ERROR " + strings.Repeat("x", 3*maxLogLineRead) + "\n" +
+		stamp(2*time.Second) + " ERROR after\n"
+
+	var mu sync.Mutex
+	var since []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/containers/app-1/json":
+			fmt.Fprint(w, `{"Config":{"Tty":true}}`)
+		case "/containers/app-1/logs":
+			mu.Lock()
+			since = append(since, r.URL.Query().Get("since"))
+			first := len(since) == 1
+			mu.Unlock()
+			if first {
+				fmt.Fprint(w, logs)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	lm := &LogMonitor{
+		docker:     &DockerClient{client: server.Client(), baseURL: server.URL},
+		patterns:   []logPattern{{name: "error", re: regexp.MustCompile(`ERROR`)}},
+		counts:     make(map[string]map[string]int),
+		matched:    make(map[string]int),
+		lines:      make(map[string]int),
+		bufferSize: 10,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	lm.wg.Add(1)
+	go lm.tail(ctx, "app-1", "app-1")
+	defer lm.wg.Wait()
+	defer cancel()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && len(lm.RecentMatches("")) < 3 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	matches := lm.RecentMatches("")
+	if len(matches) != 3 {
+		t.Fatalf("got %d matching lines, want the lines before and after the long one too", len(matches))
+	}
+	if matches[2].Line != "ERROR after" || matches[2].Timestamp != stamp(2*time.Second) {
+		t.Errorf("got last match %q at %s, want the line after the long one", matches[2].Line, matches[2].Timestamp)
+	}
+	if len(matches[1].Line) != maxLogLineLength {
+		t.Errorf("got a stored long line of %d bytes, want %d", len(matches[1].Line), maxLogLineLength)
+	}
+
+	// The tail reconnects after the stream ends, from the last line read.
+	requests := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), since...)
+	}
+	deadline = time.Now().Add(logRetryDelay + 5*time.Second)
+	for time.Now().Before(deadline) && len(requests()) < 2 {
+		time.Sleep(50 * time.Millisecond)
+	}
+	resumed := at.Add(2*time.Second + time.Nanosecond)
+	if got := requests(); len(got) < 2 || got[1] != fmt.Sprintf("%d.%09d", resumed.Unix(), resumed.Nanosecond()) {
+		t.Errorf("got since %q, want the reconnection to resume after the last line", got)
+	}
+}
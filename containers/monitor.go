@@ -1,9 +1,9 @@
 package containers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"os/exec"
 	"strconv"
 	"strings"
@@ -12,13 +12,19 @@ import (
 
 	"github.com/mauriciogm/dokploy/apps/monitoring/config"
 	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/exporters"
+	"github.com/mauriciogm/dokploy/apps/monitoring/logging"
+	"github.com/mauriciogm/dokploy/apps/monitoring/telemetry"
 )
 
+var logger = logging.New("containers")
+
 type ContainerMonitor struct {
 	db        *database.DB
 	isRunning bool
 	mu        sync.Mutex
 	stopChan  chan struct{}
+	wg        sync.WaitGroup
 }
 
 func NewContainerMonitor(db *database.DB) (*ContainerMonitor, error) {
@@ -39,7 +45,7 @@ func (cm *ContainerMonitor) Start() error {
 
 	// Check if there are services to monitor
 	if len(monitorConfig.IncludeServices) == 0 {
-		log.Printf("No services to monitor. Skipping container metrics collection")
+		logger.Info("No services to monitor. Skipping container metrics collection")
 		return nil
 	}
 
@@ -50,16 +56,19 @@ func (cm *ContainerMonitor) Start() error {
 	}
 	duration := time.Duration(refreshRate) * time.Second
 
-	// log.Printf("Container metrics collection will run every %d seconds for services: %v", refreshRate, monitorConfig.IncludeServices)
+	logger.Info("Collecting container metrics", "interval", duration, "services", monitorConfig.IncludeServices)
 
+	telemetry.Expect("containers", duration)
 	ticker := time.NewTicker(duration)
+	cm.wg.Add(1)
 	go func() {
+		defer cm.wg.Done()
 		for {
 			select {
 			case <-ticker.C:
 				// Check again in case the configuration has changed
 				if len(monitorConfig.IncludeServices) == 0 {
-					log.Printf("No services to monitor. Stopping metrics collection")
+					logger.Info("No services to monitor. Stopping metrics collection")
 					ticker.Stop()
 					return
 				}
@@ -76,13 +85,15 @@ func (cm *ContainerMonitor) Start() error {
 
 func (cm *ContainerMonitor) Stop() {
 	close(cm.stopChan)
+	cm.wg.Wait()
 }
 
 func (cm *ContainerMonitor) collectMetrics() {
 	cm.mu.Lock()
 	if cm.isRunning {
 		cm.mu.Unlock()
-		log.Println("Previous collection still running, skipping...")
+		logger.Warn("Previous collection still running, skipping")
+		telemetry.SkipCycle("containers")
 		return
 	}
 	cm.isRunning = true
@@ -94,14 +105,16 @@ func (cm *ContainerMonitor) collectMetrics() {
 		cm.mu.Unlock()
 	}()
 
+	cycle := telemetry.StartCycle("containers")
+	defer cycle.Done()
+
 	cmd := exec.Command("docker", "stats", "--no-stream", "--format",
 		`{"BlockIO":"{{.BlockIO}}","CPUPerc":"{{.CPUPerc}}","ID":"{{.ID}}","MemPerc":"{{.MemPerc}}","MemUsage":"{{.MemUsage}}","Name":"{{.Name}}","NetIO":"{{.NetIO}}"}`)
 
 	output, err := cmd.CombinedOutput()
-
-	// log.Printf("Output: %s", string(output))
 	if err != nil {
-		log.Printf("Error getting docker stats: %v", err)
+		logger.Error("Error getting docker stats", "error", err, "output", strings.TrimSpace(string(output)))
+		cycle.Error()
 		return
 	}
 
@@ -111,6 +124,7 @@ func (cm *ContainerMonitor) collectMetrics() {
 	}
 
 	seenServices := make(map[string]bool)
+	var samples []exporters.Sample
 	for _, line := range strings.Split(lines, "\n") {
 		if line == "" {
 			continue
@@ -118,7 +132,8 @@ func (cm *ContainerMonitor) collectMetrics() {
 
 		var container Container
 		if err := json.Unmarshal([]byte(line), &container); err != nil {
-			log.Printf("Error parsing container data: %v", err)
+			logger.Error("Error parsing container data", "error", err)
+			cycle.Error()
 			continue
 		}
 
@@ -134,17 +149,33 @@ func (cm *ContainerMonitor) collectMetrics() {
 
 		seenServices[serviceName] = true
 
-		// log.Printf("Container: %+v", container)
-
 		// Process metrics
 		metric := processContainerMetrics(container)
 
-		// log.Printf("Saving metrics for %s: %+v", serviceName, metric)
-
+		logger.Debug("Saving container metrics", "service", serviceName, "container", container.Name)
 		if err := cm.db.SaveContainerMetric(metric); err != nil {
-			log.Printf("Error saving metrics for %s: %v", serviceName, err)
+			logger.Error("Error saving container metrics", "service", serviceName, "error", err)
+			cycle.Error()
 		}
+		samples = append(samples, ContainerSamples(metric)...)
+	}
+
+	// Published once, so a full export queue delays the cycle by its block
+	// timeout once rather than once per container.
+	exporters.Publish(samples)
+}
+
+// CheckDockerCLI checks that the docker CLI used by the container monitor is
+// installed and can reach the daemon.
+func CheckDockerCLI(ctx context.Context) error {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return fmt.Errorf("docker CLI not found: %v", err)
 	}
+	output, err := exec.CommandContext(ctx, "docker", "version", "--format", "{{.Server.Version}}").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error running docker version: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
 }
 
 func processContainerMetrics(container Container) *database.ContainerMetric {
@@ -0,0 +1,213 @@
+package containers
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+// ServiceSeries holds the bucketed samples of one service. Every slice is
+// aligned with SeriesResponse.Timestamps; buckets without samples are nil.
+type ServiceSeries struct {
+	CPU             []*float64 `json:"cpu"`
+	MemoryPercent   []*float64 `json:"memoryPercent"`
+	MemoryUsedMB    []*float64 `json:"memoryUsedMB"`
+	NetworkInBytes  []*float64 `json:"networkInBytes"`
+	NetworkOutBytes []*float64 `json:"networkOutBytes"`
+	BlockReadBytes  []*float64 `json:"blockReadBytes"`
+	BlockWriteBytes []*float64 `json:"blockWriteBytes"`
+}
+
+type SeriesResponse struct {
+	Start      string                    `json:"start"`
+	End        string                    `json:"end"`
+	Step       int64                     `json:"step"`
+	Timestamps []string                  `json:"timestamps"`
+	Services   map[string]*ServiceSeries `json:"services"`
+}
+
+// ResolveProjectServices returns the services of a compose project or swarm
+// stack, based on the container names that have stored samples.
+func ResolveProjectServices(db *database.DB, project string) ([]string, error) {
+	names, err := db.GetLastContainerSampleTimes()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var services []string
+	for name := range names {
+		name = strings.TrimPrefix(name, "/")
+		if !strings.HasPrefix(name, project+"-") && !strings.HasPrefix(name, project+"_") {
+			continue
+		}
+		service := GetServiceName(name)
+		if !seen[service] {
+			seen[service] = true
+			services = append(services, service)
+		}
+	}
+	sort.Strings(services)
+	return services, nil
+}
+
+// BuildServiceSeries averages the samples of each service into the buckets of
+// the given range. Samples are matched to the longest service their container
+// belongs to, as reported by database.IsServiceContainer.
+func BuildServiceSeries(metrics []database.ContainerMetric, services []string, tr database.TimeRange) SeriesResponse {
+	n := tr.Buckets()
+	response := SeriesResponse{
+		Start:      tr.Start.Format(time.RFC3339Nano),
+		End:        tr.End.Format(time.RFC3339Nano),
+		Step:       int64(tr.Step / time.Second),
+		Timestamps: tr.Timestamps(),
+		Services:   make(map[string]*ServiceSeries, len(services)),
+	}
+
+	type accumulator struct {
+		count                                                       []int
+		cpu, memPerc, memUsed, netIn, netOut, blockRead, blockWrite []float64
+	}
+	acc := make(map[string]*accumulator, len(services))
+	for _, service := range services {
+		acc[service] = &accumulator{
+			count:      make([]int, n),
+			cpu:        make([]float64, n),
+			memPerc:    make([]float64, n),
+			memUsed:    make([]float64, n),
+			netIn:      make([]float64, n),
+			netOut:     make([]float64, n),
+			blockRead:  make([]float64, n),
+			blockWrite: make([]float64, n),
+		}
+	}
+
+	// Longest first so "app-api" wins over "app" for "app-api-1".
+	ordered := append([]string(nil), services...)
+	sort.Slice(ordered, func(i, j int) bool { return len(ordered[i]) > len(ordered[j]) })
+
+	for _, m := range metrics {
+		ts, err := time.Parse(time.RFC3339Nano, m.Timestamp)
+		if err != nil {
+			continue
+		}
+		idx := tr.BucketIndex(ts)
+		if idx < 0 {
+			continue
+		}
+
+		name := strings.TrimPrefix(m.Name, "/")
+		for _, service := range ordered {
+			if !database.IsServiceContainer(name, service) {
+				continue
+			}
+			a := acc[service]
+			a.count[idx]++
+			a.cpu[idx] += m.CPU
+			a.memPerc[idx] += m.Memory.Percentage
+			a.memUsed[idx] += memoryToMB(m.Memory.Used, m.Memory.UsedUnit)
+			a.netIn[idx] += ioToBytes(m.Network.Input, m.Network.InputUnit)
+			a.netOut[idx] += ioToBytes(m.Network.Output, m.Network.OutputUnit)
+			a.blockRead[idx] += ioToBytes(m.BlockIO.Read, m.BlockIO.ReadUnit)
+			a.blockWrite[idx] += ioToBytes(m.BlockIO.Write, m.BlockIO.WriteUnit)
+			break
+		}
+	}
+
+	for _, service := range services {
+		a := acc[service]
+		response.Services[service] = &ServiceSeries{
+			CPU:             averages(a.cpu, a.count),
+			MemoryPercent:   averages(a.memPerc, a.count),
+			MemoryUsedMB:    averages(a.memUsed, a.count),
+			NetworkInBytes:  averages(a.netIn, a.count),
+			NetworkOutBytes: averages(a.netOut, a.count),
+			BlockReadBytes:  averages(a.blockRead, a.count),
+			BlockWriteBytes: averages(a.blockWrite, a.count),
+		}
+	}
+
+	return response
+}
+
+func averages(sums []float64, counts []int) []*float64 {
+	result := make([]*float64, len(sums))
+	for i, sum := range sums {
+		if counts[i] == 0 {
+			continue
+		}
+		avg := sum / float64(counts[i])
+		result[i] = &avg
+	}
+	return result
+}
+
+// Sample is a container metric with every value in base units, as served
+// by the versioned API. The stored metrics keep the value and unit pairs
+// reported by docker stats.
+type Sample struct {
+	Timestamp        string  `json:"timestamp"`
+	ID               string  `json:"id"`
+	Name             string  `json:"name"`
+	CPU              float64 `json:"cpu"`
+	MemoryPercent    float64 `json:"memoryPercent"`
+	MemoryUsedBytes  float64 `json:"memoryUsedBytes"`
+	MemoryLimitBytes float64 `json:"memoryLimitBytes"`
+	NetworkInBytes   float64 `json:"networkInBytes"`
+	NetworkOutBytes  float64 `json:"networkOutBytes"`
+	BlockReadBytes   float64 `json:"blockReadBytes"`
+	BlockWriteBytes  float64 `json:"blockWriteBytes"`
+}
+
+func NewSample(m database.ContainerMetric) Sample {
+	const mib = 1024 * 1024
+	return Sample{
+		Timestamp:        m.Timestamp,
+		ID:               m.ID,
+		Name:             m.Name,
+		CPU:              m.CPU,
+		MemoryPercent:    m.Memory.Percentage,
+		MemoryUsedBytes:  memoryToMB(m.Memory.Used, m.Memory.UsedUnit) * mib,
+		MemoryLimitBytes: memoryToMB(m.Memory.Total, m.Memory.TotalUnit) * mib,
+		NetworkInBytes:   ioToBytes(m.Network.Input, m.Network.InputUnit),
+		NetworkOutBytes:  ioToBytes(m.Network.Output, m.Network.OutputUnit),
+		BlockReadBytes:   ioToBytes(m.BlockIO.Read, m.BlockIO.ReadUnit),
+		BlockWriteBytes:  ioToBytes(m.BlockIO.Write, m.BlockIO.WriteUnit),
+	}
+}
+
+// memoryToMB converts the memory usage reported by docker stats to MiB.
+// processContainerMetrics rewrites MiB/GiB as MB/GB, so those are binary.
+func memoryToMB(value float64, unit string) float64 {
+	switch unit {
+	case "B":
+		return value / (1024 * 1024)
+	case "KiB", "kB":
+		return value / 1024
+	case "GB", "GiB":
+		return value * 1024
+	case "TB", "TiB":
+		return value * 1024 * 1024
+	default:
+		return value
+	}
+}
+
+// ioToBytes converts network and block I/O values reported by docker stats,
+// which use decimal units, to bytes.
+func ioToBytes(value float64, unit string) float64 {
+	switch unit {
+	case "kB":
+		return value * 1e3
+	case "MB":
+		return value * 1e6
+	case "GB":
+		return value * 1e9
+	case "TB":
+		return value * 1e12
+	default:
+		return value
+	}
+}
@@ -0,0 +1,40 @@
+package dashboard
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
+)
+
+// Prefix is the path the dashboard is served under.
+const Prefix = "/dashboard"
+
+//go:embed static
+var static embed.FS
+
+// Handler serves the dashboard, a single page that reads the agent API with
+// the token the user enters. The assets hold no data, so they are served
+// without authentication and every asset is embedded, so the page works
+// without internet access.
+func Handler() fiber.Handler {
+	root, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+
+	files := filesystem.New(filesystem.Config{
+		Root:  http.FS(root),
+		Index: "index.html",
+	})
+	return func(c *fiber.Ctx) error {
+		// Without the trailing slash the relative asset URLs would resolve
+		// outside the dashboard.
+		if c.Path() == Prefix {
+			return c.Redirect(Prefix+"/", fiber.StatusMovedPermanently)
+		}
+		return files(c)
+	}
+}
@@ -0,0 +1,563 @@
+(function () {
+  "use strict";
+
+  var API = "/api/v1";
+  var TOKEN_KEY = "monitoring.token";
+  var SVG_NS = "http://www.w3.org/2000/svg";
+
+  var state = {
+    token: localStorage.getItem(TOKEN_KEY) || "",
+    timer: null,
+    sort: { key: "cpu", desc: true },
+    services: []
+  };
+
+  function $(id) {
+    return document.getElementById(id);
+  }
+
+  // el creates an element with the given attributes and children. Strings
+  // become text nodes, so API values are never parsed as HTML.
+  function el(tag, attrs, children) {
+    var node = document.createElement(tag);
+    Object.keys(attrs || {}).forEach(function (key) {
+      node.setAttribute(key, attrs[key]);
+    });
+    (children || []).forEach(function (child) {
+      if (child === null || child === undefined) {
+        return;
+      }
+      node.appendChild(typeof child === "object" ? child : document.createTextNode(String(child)));
+    });
+    return node;
+  }
+
+  function svg(tag, attrs) {
+    var node = document.createElementNS(SVG_NS, tag);
+    Object.keys(attrs || {}).forEach(function (key) {
+      node.setAttribute(key, attrs[key]);
+    });
+    return node;
+  }
+
+  function replaceRows(tbody, rows, columns, emptyText) {
+    tbody.textContent = "";
+    if (rows.length === 0) {
+      tbody.appendChild(el("tr", {}, [el("td", { colspan: columns, "class": "empty" }, [emptyText])]));
+      return;
+    }
+    rows.forEach(function (row) {
+      tbody.appendChild(row);
+    });
+  }
+
+  function fixed(value, digits) {
+    var n = Number(value);
+    return isFinite(n) ? n.toFixed(digits === undefined ? 1 : digits) : "-";
+  }
+
+  function formatBytes(value) {
+    var n = Number(value);
+    if (!isFinite(n)) {
+      return "-";
+    }
+    var units = ["B", "KiB", "MiB", "GiB", "TiB"];
+    var i = 0;
+    while (n >= 1024 && i < units.length - 1) {
+      n /= 1024;
+      i++;
+    }
+    return n.toFixed(i === 0 ? 0 : 1) + " " + units[i];
+  }
+
+  function formatTime(timestamp) {
+    if (!timestamp) {
+      return "-";
+    }
+    var date = new Date(timestamp);
+    return isNaN(date) ? timestamp : date.toLocaleString();
+  }
+
+  function formatAge(timestamp) {
+    var date = new Date(timestamp);
+    if (!timestamp || isNaN(date)) {
+      return "-";
+    }
+    var seconds = Math.max(0, Math.round((Date.now() - date) / 1000));
+    if (seconds < 60) {
+      return seconds + "s ago";
+    }
+    if (seconds < 3600) {
+      return Math.round(seconds / 60) + "m ago";
+    }
+    if (seconds < 86400) {
+      return Math.round(seconds / 3600) + "h ago";
+    }
+    return Math.round(seconds / 86400) + "d ago";
+  }
+
+  function formatUptime(seconds) {
+    var days = Math.floor(seconds / 86400);
+    var hours = Math.floor((seconds % 86400) / 3600);
+    var minutes = Math.floor((seconds % 3600) / 60);
+    return days > 0 ? days + "d " + hours + "h" : hours + "h " + minutes + "m";
+  }
+
+  // Authentication
+
+  function api(path) {
+    return fetch(API + path, {
+      headers: { Authorization: "Bearer " + state.token },
+      cache: "no-store"
+    }).then(function (resp) {
+      if (resp.status === 401) {
+        var err = new Error("Unauthorized");
+        err.unauthorized = true;
+        throw err;
+      }
+      if (!resp.ok) {
+        return resp.json().catch(function () {
+          return {};
+        }).then(function (body) {
+          throw new Error(body.error || resp.status + " " + resp.statusText);
+        });
+      }
+      return resp.json();
+    });
+  }
+
+  function showLogin(message) {
+    stopTimer();
+    $("main").hidden = true;
+    $("login").hidden = false;
+    $("logout").hidden = true;
+    $("login-error").textContent = message || "";
+    $("token").focus();
+  }
+
+  function showMain() {
+    $("login").hidden = true;
+    $("main").hidden = false;
+    $("logout").hidden = false;
+  }
+
+  $("login-form").addEventListener("submit", function (event) {
+    event.preventDefault();
+    state.token = $("token").value.trim();
+    api("/metrics?limit=1").then(function () {
+      localStorage.setItem(TOKEN_KEY, state.token);
+      $("token").value = "";
+      showMain();
+      refresh();
+    }).catch(function (err) {
+      showLogin(err.unauthorized ? "Invalid token" : err.message);
+    });
+  });
+
+  $("logout").addEventListener("click", function () {
+    localStorage.removeItem(TOKEN_KEY);
+    state.token = "";
+    showLogin();
+  });
+
+  // Charts
+
+  // lineChart draws series of {t, v} points into container as an SVG, with
+  // horizontal grid lines, time labels and a cursor showing the values under
+  // the pointer.
+  function lineChart(container, series, options) {
+    var width = container.clientWidth || 400;
+    var height = container.clientHeight || 200;
+    var pad = { top: 8, right: 8, bottom: 20, left: 40 };
+    var plotW = width - pad.left - pad.right;
+    var plotH = height - pad.top - pad.bottom;
+
+    var minT = Infinity;
+    var maxT = -Infinity;
+    var maxV = options.max || 0;
+    series.forEach(function (s) {
+      s.points.forEach(function (p) {
+        minT = Math.min(minT, p.t);
+        maxT = Math.max(maxT, p.t);
+        if (!options.max) {
+          maxV = Math.max(maxV, p.v);
+        }
+      });
+    });
+    if (!isFinite(minT)) {
+      container.textContent = "";
+      container.appendChild(el("p", { "class": "muted" }, ["No data"]));
+      return;
+    }
+    if (maxT === minT) {
+      maxT = minT + 1;
+    }
+    if (maxV <= 0) {
+      maxV = 1;
+    }
+    maxV = niceCeil(maxV);
+
+    function x(t) {
+      return pad.left + ((t - minT) / (maxT - minT)) * plotW;
+    }
+    function y(v) {
+      return pad.top + plotH - (v / maxV) * plotH;
+    }
+
+    var root = svg("svg", { viewBox: "0 0 " + width + " " + height, preserveAspectRatio: "none" });
+
+    for (var i = 0; i <= 4; i++) {
+      var v = (maxV / 4) * i;
+      root.appendChild(svg("line", { "class": "grid", x1: pad.left, x2: width - pad.right, y1: y(v), y2: y(v) }));
+      var label = svg("text", { "class": "axis", x: pad.left - 4, y: y(v) + 4, "text-anchor": "end" });
+      label.textContent = fixed(v, maxV < 4 ? 1 : 0);
+      root.appendChild(label);
+    }
+
+    [[minT, "start"], [maxT, "end"]].forEach(function (tick) {
+      var text = svg("text", { "class": "axis", x: x(tick[0]), y: height - 4, "text-anchor": tick[1] });
+      text.textContent = new Date(tick[0]).toLocaleTimeString();
+      root.appendChild(text);
+    });
+
+    series.forEach(function (s) {
+      var d = s.points.map(function (p, idx) {
+        return (idx === 0 ? "M" : "L") + x(p.t).toFixed(1) + "," + y(Math.min(p.v, maxV)).toFixed(1);
+      }).join("");
+      root.appendChild(svg("path", { "class": "line", d: d, stroke: s.color }));
+    });
+
+    var cursor = svg("line", { "class": "cursor", y1: pad.top, y2: pad.top + plotH, visibility: "hidden" });
+    root.appendChild(cursor);
+
+    var tooltip = el("div", { "class": "tooltip" });
+    tooltip.hidden = true;
+
+    root.addEventListener("mousemove", function (event) {
+      var rect = root.getBoundingClientRect();
+      var px = ((event.clientX - rect.left) / rect.width) * width;
+      var t = minT + ((px - pad.left) / plotW) * (maxT - minT);
+      var lines = [];
+      var at = null;
+      series.forEach(function (s) {
+        var nearest = closest(s.points, t);
+        if (nearest) {
+          at = nearest.t;
+          lines.push(s.name + ": " + fixed(nearest.v, 2) + (options.unit || ""));
+        }
+      });
+      if (at === null) {
+        return;
+      }
+      cursor.setAttribute("x1", x(at));
+      cursor.setAttribute("x2", x(at));
+      cursor.setAttribute("visibility", "visible");
+      tooltip.textContent = new Date(at).toLocaleTimeString() + "  " + lines.join("  ");
+      tooltip.hidden = false;
+      var left = (x(at) / width) * rect.width;
+      tooltip.style.left = left < rect.width / 2 ? left + 8 + "px" : "";
+      tooltip.style.right = left < rect.width / 2 ? "" : rect.width - left + 8 + "px";
+    });
+    root.addEventListener("mouseleave", function () {
+      cursor.setAttribute("visibility", "hidden");
+      tooltip.hidden = true;
+    });
+
+    var legend = el("div", { "class": "legend" }, series.map(function (s) {
+      var swatch = el("i");
+      swatch.style.background = s.color;
+      return el("span", {}, [swatch, s.name]);
+    }));
+
+    container.textContent = "";
+    container.appendChild(root);
+    container.appendChild(tooltip);
+    container.parentNode.querySelectorAll(".legend").forEach(function (old) {
+      old.remove();
+    });
+    container.parentNode.appendChild(legend);
+  }
+
+  function niceCeil(value) {
+    var magnitude = Math.pow(10, Math.floor(Math.log10(value)));
+    var steps = [1, 2, 2.5, 5, 10];
+    for (var i = 0; i < steps.length; i++) {
+      if (steps[i] * magnitude >= value) {
+        return steps[i] * magnitude;
+      }
+    }
+    return 10 * magnitude;
+  }
+
+  function closest(points, t) {
+    var best = null;
+    points.forEach(function (p) {
+      if (best === null || Math.abs(p.t - t) < Math.abs(best.t - t)) {
+        best = p;
+      }
+    });
+    return best;
+  }
+
+  function cssVar(name) {
+    return getComputedStyle(document.documentElement).getPropertyValue(name).trim();
+  }
+
+  // Host
+
+  function stat(label, value, detail) {
+    return el("div", { "class": "stat" }, [
+      el("div", { "class": "label" }, [label]),
+      el("div", { "class": "value" }, [value]),
+      detail ? el("div", { "class": "detail" }, [detail]) : null
+    ]);
+  }
+
+  function renderHost(metrics) {
+    var stats = $("stats");
+    stats.textContent = "";
+    if (metrics.length === 0) {
+      stats.appendChild(el("p", { "class": "muted" }, ["No host metrics yet"]));
+      lineChart($("chart-usage"), [], {});
+      lineChart($("chart-network"), [], {});
+      return;
+    }
+
+    var last = metrics[metrics.length - 1];
+    $("host").textContent = [last.distro || last.os, last.arch].filter(Boolean).join(" · ");
+
+    stats.appendChild(stat("CPU", fixed(last.cpu) + "%", last.cpuCores + " cores · " + (last.cpuModel || "")));
+    stats.appendChild(stat("Memory", fixed(last.memUsed) + "%", fixed(last.memUsedGB) + " / " + fixed(last.memTotal) + " GB"));
+    stats.appendChild(stat("Disk", fixed(last.diskUsed) + "%", fixed(last.totalDisk) + " GB total"));
+    stats.appendChild(stat("Network", "↑ " + fixed(last.uploadRate, 2) + " ↓ " + fixed(last.downloadRate, 2), "MB/s"));
+    stats.appendChild(stat("Uptime", formatUptime(last.uptime), last.kernel));
+    stats.appendChild(stat("Last sample", formatAge(last.timestamp), formatTime(last.timestamp)));
+
+    function points(field) {
+      return metrics.map(function (m) {
+        return { t: new Date(m.timestamp).getTime(), v: Number(m[field]) || 0 };
+      }).filter(function (p) {
+        return !isNaN(p.t);
+      });
+    }
+
+    lineChart($("chart-usage"), [
+      { name: "CPU", color: cssVar("--cpu"), points: points("cpu") },
+      { name: "Memory", color: cssVar("--memory"), points: points("memUsed") }
+    ], { max: 100, unit: "%" });
+    lineChart($("chart-network"), [
+      { name: "Upload", color: cssVar("--up"), points: points("uploadRate") },
+      { name: "Download", color: cssVar("--down"), points: points("downloadRate") }
+    ], { unit: " MB/s" });
+  }
+
+  // Alerts
+
+  function renderAlerts(alerts) {
+    $("alerts-count").textContent = alerts.length ? "(" + alerts.length + ")" : "";
+    replaceRows($("alerts"), alerts.map(function (a) {
+      return el("tr", {}, [
+        el("td", {}, [el("span", { "class": "badge fail" }, [a.type])]),
+        el("td", { "class": "message" }, [a.message]),
+        el("td", { "class": "num" }, [fixed(a.value, 2)]),
+        el("td", { "class": "num" }, [fixed(a.threshold, 2)]),
+        el("td", { title: formatTime(a.since) }, [formatAge(a.since)])
+      ]);
+    }), 5, "No active alerts");
+  }
+
+  // Containers
+
+  function loadServices(services) {
+    return Promise.all(services.map(function (s) {
+      if (!s.monitored) {
+        return Promise.resolve(s);
+      }
+      return api("/metrics/containers?appName=" + encodeURIComponent(s.service) + "&limit=1").then(function (metrics) {
+        s.latest = metrics.length ? metrics[metrics.length - 1] : null;
+        return s;
+      }, function () {
+        return s;
+      });
+    }));
+  }
+
+  function sortValue(s, key) {
+    switch (key) {
+      case "cpu":
+        return s.latest ? s.latest.cpu : -1;
+      case "memory":
+        return s.latest ? s.latest.memoryPercent : -1;
+      case "replicas":
+        return s.replicas;
+      case "lastSample":
+        return s.lastSample || "";
+      default:
+        return s.service;
+    }
+  }
+
+  function renderContainers() {
+    var key = state.sort.key;
+    var services = state.services.slice().sort(function (a, b) {
+      var va = sortValue(a, key);
+      var vb = sortValue(b, key);
+      var order = va < vb ? -1 : va > vb ? 1 : a.service.localeCompare(b.service);
+      return state.sort.desc ? -order : order;
+    });
+
+    document.querySelectorAll(".sortable th[data-sort]").forEach(function (th) {
+      th.classList.remove("asc", "desc");
+      if (th.dataset.sort === key) {
+        th.classList.add(state.sort.desc ? "desc" : "asc");
+      }
+    });
+
+    replaceRows($("containers"), services.map(function (s) {
+      var m = s.latest;
+      var name = el("td", { title: (s.images || []).join(", ") }, [
+        s.service,
+        s.project ? el("span", { "class": "muted" }, [" " + s.project]) : null
+      ]);
+      if (!m) {
+        return el("tr", {}, [
+          name,
+          el("td", { "class": "num" }, [s.replicas]),
+          el("td", { "class": "num muted", colspan: 5 }, [s.monitored ? "No samples yet" : "Not monitored"]),
+          el("td", {}, ["-"])
+        ]);
+      }
+      return el("tr", {}, [
+        name,
+        el("td", { "class": "num" }, [s.replicas]),
+        el("td", { "class": "num" }, [fixed(m.cpu, 2)]),
+        el("td", { "class": "num" }, [fixed(m.memoryPercent, 2)]),
+        el("td", { "class": "num" }, [formatBytes(m.memoryUsedBytes) + " / " + formatBytes(m.memoryLimitBytes)]),
+        el("td", { "class": "num" }, [formatBytes(m.networkInBytes) + " / " + formatBytes(m.networkOutBytes)]),
+        el("td", { "class": "num" }, [formatBytes(m.blockReadBytes) + " / " + formatBytes(m.blockWriteBytes)]),
+        el("td", { title: formatTime(m.timestamp) }, [formatAge(m.timestamp)])
+      ]);
+    }), 8, "No containers");
+  }
+
+  document.querySelectorAll(".sortable th[data-sort]").forEach(function (th) {
+    th.addEventListener("click", function () {
+      var key = th.dataset.sort;
+      if (state.sort.key === key) {
+        state.sort.desc = !state.sort.desc;
+      } else {
+        state.sort = { key: key, desc: key !== "service" };
+      }
+      renderContainers();
+    });
+  });
+
+  // Probes
+
+  function uptime(value) {
+    return value === null || value === undefined ? "-" : fixed(value, 2) + "%";
+  }
+
+  function renderProbes(probes) {
+    replaceRows($("probes"), probes.map(function (p) {
+      var r = p.lastResult;
+      var status = !r
+        ? el("span", { "class": "badge warn" }, ["pending"])
+        : el("span", { "class": "badge " + (p.up ? "ok" : "fail"), title: r.error || "" }, [p.up ? "up" : "down"]);
+      return el("tr", {}, [
+        el("td", {}, [p.name]),
+        el("td", {}, [p.type]),
+        el("td", {}, [p.target]),
+        el("td", {}, [status]),
+        el("td", { "class": "num" }, [r ? fixed(r.latencyMs, 0) + " ms" : "-"]),
+        el("td", { "class": "num" }, [p.consecutiveFailures]),
+        el("td", { "class": "num" }, [uptime(p.uptime24h)]),
+        el("td", { "class": "num" }, [uptime(p.uptime7d)]),
+        el("td", { title: r ? formatTime(r.timestamp) : "" }, [r ? formatAge(r.timestamp) : "-"])
+      ]);
+    }), 9, "No probes configured");
+  }
+
+  // Refresh
+
+  // refresh loads every section independently, so an unavailable source,
+  // such as Docker, only leaves its own section stale.
+  function refresh() {
+    var errors = [];
+    function section(name, request, render) {
+      return request.then(function (data) {
+        render(data || []);
+      }, function (err) {
+        if (err.unauthorized) {
+          throw err;
+        }
+        errors.push(name + ": " + err.message);
+      });
+    }
+
+    return Promise.all([
+      section("Host", api("/metrics?limit=" + $("samples").value), renderHost),
+      section("Alerts", api("/alerts/active"), renderAlerts),
+      section("Containers", api("/services").then(function (services) {
+        return loadServices(services || []);
+      }), function (services) {
+        state.services = services;
+        renderContainers();
+      }),
+      section("Probes", api("/probes"), renderProbes)
+    ]).then(function () {
+      $("error").textContent = errors.join(" · ");
+      $("error").hidden = errors.length === 0;
+      $("updated").textContent = "Updated " + new Date().toLocaleTimeString();
+    }).catch(function (err) {
+      if (err.unauthorized) {
+        showLogin("Session expired, sign in again");
+        return;
+      }
+      $("error").textContent = "Error loading data: " + err.message;
+      $("error").hidden = false;
+    }).then(schedule);
+  }
+
+  function stopTimer() {
+    clearTimeout(state.timer);
+    state.timer = null;
+  }
+
+  function schedule() {
+    stopTimer();
+    var seconds = Number($("refresh").value);
+    if (seconds > 0 && !$("main").hidden && !document.hidden) {
+      state.timer = setTimeout(refresh, seconds * 1000);
+    }
+  }
+
+  $("refresh").addEventListener("change", schedule);
+  $("samples").addEventListener("change", refresh);
+
+  // Background tabs don't poll; they refresh as soon as they are shown.
+  document.addEventListener("visibilitychange", function () {
+    if (document.hidden) {
+      stopTimer();
+    } else if (!$("main").hidden) {
+      refresh();
+    }
+  });
+
+  var resizeTimer = null;
+  window.addEventListener("resize", function () {
+    clearTimeout(resizeTimer);
+    resizeTimer = setTimeout(function () {
+      if (!$("main").hidden) {
+        refresh();
+      }
+    }, 200);
+  });
+
+  if (state.token) {
+    showMain();
+    refresh();
+  } else {
+    showLogin();
+  }
+})();
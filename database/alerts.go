@@ -0,0 +1,168 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// ActiveAlert is an alert whose condition still holds. Since is when the
+// condition was first raised; LastSeen is updated every time it is raised
+// again.
+type ActiveAlert struct {
+	Key       string  `json:"key"`
+	Type      string  `json:"type"`
+	Message   string  `json:"message"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	Since     string  `json:"since"`
+	LastSeen  string  `json:"lastSeen"`
+}
+
+func (db *DB) InitActiveAlertsTable() error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS active_alerts (
+			key TEXT PRIMARY KEY,
+			type TEXT NOT NULL,
+			message TEXT NOT NULL,
+			value REAL NOT NULL,
+			threshold REAL NOT NULL,
+			since TEXT NOT NULL,
+			last_seen TEXT NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating active_alerts table: %v", err)
+	}
+	return nil
+}
+
+func (db *DB) SaveActiveAlert(alert ActiveAlert) (err error) {
+	defer observeWrite("active_alerts", time.Now(), &err)
+
+	_, err = db.Exec(`
+		INSERT INTO active_alerts (key, type, message, value, threshold, since, last_seen)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET
+			type = excluded.type,
+			message = excluded.message,
+			value = excluded.value,
+			threshold = excluded.threshold,
+			last_seen = excluded.last_seen
+	`, alert.Key, alert.Type, alert.Message, alert.Value, alert.Threshold, alert.Since, alert.LastSeen)
+	return err
+}
+
+func (db *DB) DeleteActiveAlert(key string) (err error) {
+	defer observeWrite("active_alerts", time.Now(), &err)
+
+	_, err = db.Exec(`DELETE FROM active_alerts WHERE key = ?`, key)
+	return err
+}
+
+// ClearActiveAlerts deletes every active alert, e.g. those left by a previous
+// run of the agent.
+func (db *DB) ClearActiveAlerts() error {
+	_, err := db.Exec(`DELETE FROM active_alerts`)
+	return err
+}
+
+// GetActiveAlerts returns the active alerts, oldest first.
+func (db *DB) GetActiveAlerts() ([]ActiveAlert, error) {
+	rows, err := db.Query(`
+		SELECT key, type, message, value, threshold, since, last_seen
+		FROM active_alerts
+		ORDER BY since ASC, key ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := []ActiveAlert{}
+	for rows.Next() {
+		var a ActiveAlert
+		if err := rows.Scan(&a.Key, &a.Type, &a.Message, &a.Value, &a.Threshold, &a.Since, &a.LastSeen); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, rows.Err()
+}
+
+// InitAlertHistoryTable creates the table recording every time an alert
+// started firing. timestamp is when it started and resolved_at when it
+// cleared, empty while it fires.
+func (db *DB) InitAlertHistoryTable() error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS alert_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp TEXT NOT NULL,
+			key TEXT NOT NULL,
+			type TEXT NOT NULL,
+			message TEXT NOT NULL,
+			value REAL NOT NULL,
+			threshold REAL NOT NULL,
+			resolved_at TEXT NOT NULL DEFAULT ''
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating alert_history table: %v", err)
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_alert_history_timestamp ON alert_history(timestamp)`)
+	if err != nil {
+		return fmt.Errorf("error creating timestamp index: %v", err)
+	}
+
+	return nil
+}
+
+// SaveAlertStart records that an alert started firing.
+func (db *DB) SaveAlertStart(alert ActiveAlert) (err error) {
+	defer observeWrite("alert_history", time.Now(), &err)
+
+	_, err = db.Exec(`
+		INSERT INTO alert_history (timestamp, key, type, message, value, threshold)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, alert.Since, alert.Key, alert.Type, alert.Message, alert.Value, alert.Threshold)
+	return err
+}
+
+// ResolveAlertHistory records that the alerts identified by key stopped
+// firing; an empty key resolves every alert, e.g. those left firing by a
+// previous run of the agent.
+func (db *DB) ResolveAlertHistory(key string, at time.Time) (err error) {
+	defer observeWrite("alert_history", time.Now(), &err)
+
+	_, err = db.Exec(`
+		UPDATE alert_history SET resolved_at = ?
+		WHERE resolved_at = '' AND (? = '' OR key = ?)
+	`, at.UTC().Format(time.RFC3339Nano), key, key)
+	return err
+}
+
+// CountAlerts returns how many alerts of each type started firing in a time
+// range.
+func (db *DB) CountAlerts(start, end time.Time) (map[string]int, error) {
+	rows, err := db.Query(`
+		SELECT type, COUNT(*)
+		FROM alert_history
+		WHERE timestamp BETWEEN ? AND ?
+		GROUP BY type
+	`, start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var alertType string
+		var n int
+		if err := rows.Scan(&alertType, &n); err != nil {
+			return nil, err
+		}
+		counts[alertType] = n
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type CertificateCheck struct {
+	Timestamp       string   `json:"timestamp"`
+	Source          string   `json:"source"`
+	Target          string   `json:"target"`
+	Subject         string   `json:"subject"`
+	Issuer          string   `json:"issuer"`
+	SANs            []string `json:"sans"`
+	NotBefore       string   `json:"notBefore"`
+	NotAfter        string   `json:"notAfter"`
+	DaysUntilExpiry float64  `json:"daysUntilExpiry"`
+	Verified        *bool    `json:"verified,omitempty"`
+	Error           string   `json:"error,omitempty"`
+}
+
+func (db *DB) InitCertificateChecksTable() error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS certificate_checks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp TEXT NOT NULL,
+			source TEXT NOT NULL,
+			target TEXT NOT NULL,
+			check_json TEXT NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating certificate_checks table: %v", err)
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_certificate_checks_target_timestamp ON certificate_checks(target, timestamp)`)
+	if err != nil {
+		return fmt.Errorf("error creating target index: %v", err)
+	}
+
+	return nil
+}
+
+func (db *DB) SaveCertificateCheck(check CertificateCheck) (err error) {
+	defer observeWrite("certificate_checks", time.Now(), &err)
+
+	checkJSON, err := json.Marshal(check)
+	if err != nil {
+		return fmt.Errorf("error marshaling certificate check: %v", err)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO certificate_checks (timestamp, source, target, check_json)
+		VALUES (?, ?, ?, ?)
+	`, check.Timestamp, check.Source, check.Target, string(checkJSON))
+	return err
+}
+
+// GetLatestCertificateChecks returns the most recent check of every target.
+func (db *DB) GetLatestCertificateChecks() ([]CertificateCheck, error) {
+	rows, err := db.Query(`
+		SELECT check_json
+		FROM certificate_checks c
+		WHERE timestamp = (
+			SELECT MAX(timestamp) FROM certificate_checks WHERE target = c.target
+		)
+		ORDER BY target ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checks := []CertificateCheck{}
+	for rows.Next() {
+		var checkJSON string
+		if err := rows.Scan(&checkJSON); err != nil {
+			return nil, err
+		}
+
+		var check CertificateCheck
+		if err := json.Unmarshal([]byte(checkJSON), &check); err != nil {
+			return nil, err
+		}
+		checks = append(checks, check)
+	}
+	return checks, rows.Err()
+}
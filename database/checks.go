@@ -0,0 +1,107 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+type CheckResult struct {
+	Timestamp  string  `json:"timestamp"`
+	Name       string  `json:"name"`
+	Status     int     `json:"status"`
+	StatusText string  `json:"statusText"`
+	Output     string  `json:"output"`
+	DurationMs float64 `json:"durationMs"`
+}
+
+func (db *DB) InitCheckResultsTable() error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS check_results (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp TEXT NOT NULL,
+			name TEXT NOT NULL,
+			status INTEGER NOT NULL,
+			status_text TEXT NOT NULL,
+			output TEXT NOT NULL,
+			duration_ms REAL NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating check_results table: %v", err)
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_check_results_name_timestamp ON check_results(name, timestamp)`)
+	if err != nil {
+		return fmt.Errorf("error creating name index: %v", err)
+	}
+
+	return nil
+}
+
+func (db *DB) SaveCheckResult(result CheckResult) (err error) {
+	defer observeWrite("check_results", time.Now(), &err)
+
+	_, err = db.Exec(`
+		INSERT INTO check_results (timestamp, name, status, status_text, output, duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, result.Timestamp, result.Name, result.Status, result.StatusText, result.Output, result.DurationMs)
+	return err
+}
+
+func (db *DB) GetLastNCheckResults(name string, limit int) ([]CheckResult, error) {
+	rows, err := db.Query(`
+		WITH recent_results AS (
+			SELECT timestamp, name, status, status_text, output, duration_ms
+			FROM check_results
+			WHERE name = ?
+			ORDER BY timestamp DESC
+			LIMIT ?
+		)
+		SELECT * FROM recent_results
+		ORDER BY timestamp ASC
+	`, name, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []CheckResult{}
+	for rows.Next() {
+		var r CheckResult
+		if err := rows.Scan(&r.Timestamp, &r.Name, &r.Status, &r.StatusText, &r.Output, &r.DurationMs); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// GetCheckResultsPage returns a page of the results of a check with the
+// cursor of the next page.
+func (db *DB) GetCheckResultsPage(name string, p Page) ([]CheckResult, string, error) {
+	cond, args, next, err := db.pageFilter("check_results", "name = ?", []interface{}{name}, p)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows, err := db.Query(`
+		SELECT timestamp, name, status, status_text, output, duration_ms
+		FROM check_results
+		WHERE `+cond+`
+		ORDER BY timestamp ASC
+	`, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	results := []CheckResult{}
+	for rows.Next() {
+		var r CheckResult
+		if err := rows.Scan(&r.Timestamp, &r.Name, &r.Status, &r.StatusText, &r.Output, &r.DurationMs); err != nil {
+			return nil, "", err
+		}
+		results = append(results, r)
+	}
+	return results, next, rows.Err()
+}
@@ -2,41 +2,92 @@ package database
 
 import (
 	"database/sql"
-	"log"
 	"time"
 
 	"github.com/robfig/cron/v3"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/logging"
 )
 
+var logger = logging.New("database")
+
+// retentionTables lists the tables whose rows expire after the retention
+// period. All of them have a RFC3339 timestamp column.
+var retentionTables = []string{
+	"container_metrics",
+	"server_metrics",
+	"container_log_metrics",
+	"probe_results",
+	"certificate_checks",
+	"custom_metrics",
+	"check_results",
+	"fleet_server_metrics",
+	"fleet_container_metrics",
+	"alert_history",
+}
+
 // CleanupMetrics deletes metrics older than the retention period
 func CleanupMetrics(db *sql.DB, retentionDays int) error {
-	cutoffDate := time.Now().AddDate(0, 0, -retentionDays)
-	cutoffDateStr := cutoffDate.UTC().Format(time.RFC3339Nano)
-
-	containerQuery := `DELETE FROM container_metrics WHERE timestamp < ?`
-	_, err := db.Exec(containerQuery, cutoffDateStr)
+	deleted, err := DeleteExpiredMetrics(db, retentionDays)
 	if err != nil {
 		return err
 	}
 
-	serverQuery := `DELETE FROM server_metrics WHERE timestamp < ?`
-	_, err = db.Exec(serverQuery, cutoffDateStr)
-	if err != nil {
-		return err
+	var rows int64
+	for _, n := range deleted {
+		rows += n
 	}
-
-	log.Printf("Metrics deleted (older than %d days)", retentionDays)
-	log.Printf("Cutoff date for both tables: %s", cutoffDateStr)
+	logger.Info("Metrics deleted", "retention_days", retentionDays, "rows", rows)
 	return nil
 }
 
+// DeleteExpiredMetrics deletes the rows older than the retention period and
+// returns how many were deleted from each table.
+func DeleteExpiredMetrics(db *sql.DB, retentionDays int) (map[string]int64, error) {
+	cutoff := retentionCutoff(retentionDays)
+
+	deleted := make(map[string]int64, len(retentionTables))
+	for _, table := range retentionTables {
+		result, err := db.Exec(`DELETE FROM `+table+` WHERE timestamp < ?`, cutoff)
+		if err != nil {
+			return nil, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		deleted[table] = n
+	}
+	return deleted, nil
+}
+
+// CountExpiredMetrics returns how many rows of each table DeleteExpiredMetrics
+// would delete.
+func CountExpiredMetrics(db *sql.DB, retentionDays int) (map[string]int64, error) {
+	cutoff := retentionCutoff(retentionDays)
+
+	counts := make(map[string]int64, len(retentionTables))
+	for _, table := range retentionTables {
+		var n int64
+		if err := db.QueryRow(`SELECT COUNT(*) FROM `+table+` WHERE timestamp < ?`, cutoff).Scan(&n); err != nil {
+			return nil, err
+		}
+		counts[table] = n
+	}
+	return counts, nil
+}
+
+func retentionCutoff(retentionDays int) string {
+	return time.Now().AddDate(0, 0, -retentionDays).UTC().Format(time.RFC3339Nano)
+}
+
 // StartMetricsCleanup starts a cron job to periodically clean up metrics
 func StartMetricsCleanup(db *sql.DB, retentionDays int, cronExpression string) (*cron.Cron, error) {
 	c := cron.New()
 
 	_, err := c.AddFunc(cronExpression, func() {
 		if err := CleanupMetrics(db, retentionDays); err != nil {
-			log.Printf("Error during metrics cleanup: %v", err)
+			logger.Error("Error during metrics cleanup", "error", err)
 		}
 	})
 
@@ -45,8 +96,7 @@ func StartMetricsCleanup(db *sql.DB, retentionDays int, cronExpression string) (
 	}
 
 	c.Start()
-	log.Printf("Started metrics cleanup job (retention: %d days, cron: %s)",
-		retentionDays, cronExpression)
+	logger.Info("Started metrics cleanup job", "retention_days", retentionDays, "cron", cronExpression)
 
 	return c, nil
 }
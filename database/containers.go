@@ -4,8 +4,51 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 )
 
+// serviceSeparators may follow the name of a service in the names of its
+// containers: "app-1" with Compose, "app_1" with older Compose versions and
+// "app.1.xyz" for Swarm tasks.
+const serviceSeparators = "-_."
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// IsServiceContainer reports whether a container belongs to a service: its
+// name is the service name, alone or followed by a separator, so "app" does
+// not match "app2-1".
+func IsServiceContainer(name, service string) bool {
+	name = strings.TrimPrefix(name, "/")
+	if !strings.HasPrefix(name, service) {
+		return false
+	}
+	rest := name[len(service):]
+	return rest == "" || strings.ContainsRune(serviceSeparators, rune(rest[0]))
+}
+
+// serviceCondition returns the SQL condition of IsServiceContainer on
+// column, and its arguments. LIKE wildcards in the service name are escaped.
+func serviceCondition(column, service string) (string, []interface{}) {
+	conditions := []string{column + " = ?"}
+	args := []interface{}{service}
+	for _, sep := range serviceSeparators {
+		conditions = append(conditions, column+` LIKE ? ESCAPE '\'`)
+		args = append(args, likeEscaper.Replace(service+string(sep))+"%")
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+// AppService returns the service of a Dokploy app name such as
+// "myapp-xyz123" by dropping its last "-suffix". Names without one are kept.
+func AppService(appName string) string {
+	name := strings.TrimPrefix(appName, "/")
+	parts := strings.Split(name, "-")
+	if len(parts) > 1 {
+		return strings.Join(parts[:len(parts)-1], "-")
+	}
+	return appName
+}
+
 func (db *DB) InitContainerMetricsTable() error {
 	_, err := db.Exec(`
 		CREATE TABLE IF NOT EXISTS container_metrics (
@@ -34,7 +77,9 @@ func (db *DB) InitContainerMetricsTable() error {
 	return nil
 }
 
-func (db *DB) SaveContainerMetric(metric *ContainerMetric) error {
+func (db *DB) SaveContainerMetric(metric *ContainerMetric) (err error) {
+	defer observeWrite("container_metrics", time.Now(), &err)
+
 	metricsJSON, err := json.Marshal(metric)
 	if err != nil {
 		return fmt.Errorf("error marshaling metrics: %v", err)
@@ -48,23 +93,20 @@ func (db *DB) SaveContainerMetric(metric *ContainerMetric) error {
 }
 
 func (db *DB) GetLastNContainerMetrics(containerName string, limit int) ([]ContainerMetric, error) {
-	name := strings.TrimPrefix(containerName, "/")
-	parts := strings.Split(name, "-")
-	if len(parts) > 1 {
-		containerName = strings.Join(parts[:len(parts)-1], "-")
-	}
+	containerName = AppService(containerName)
 
+	cond, args := serviceCondition("container_name", containerName)
 	query := `
 		WITH recent_metrics AS (
 			SELECT metrics_json
 			FROM container_metrics
-			WHERE container_name LIKE ? || '%'
+			WHERE ` + cond + `
 			ORDER BY timestamp DESC
 			LIMIT ?
 		)
 		SELECT metrics_json FROM recent_metrics ORDER BY json_extract(metrics_json, '$.timestamp') ASC
 	`
-	rows, err := db.Query(query, containerName, limit)
+	rows, err := db.Query(query, append(args, limit)...)
 	if err != nil {
 		return nil, err
 	}
@@ -87,23 +129,68 @@ func (db *DB) GetLastNContainerMetrics(containerName string, limit int) ([]Conta
 	return metrics, nil
 }
 
-func (db *DB) GetAllMetricsContainer(containerName string) ([]ContainerMetric, error) {
-	name := strings.TrimPrefix(containerName, "/")
-	parts := strings.Split(name, "-")
-	if len(parts) > 1 {
-		containerName = strings.Join(parts[:len(parts)-1], "-")
+// GetContainerMetricsPage returns a page of the metrics of the containers of
+// a service with the cursor of the next page.
+func (db *DB) GetContainerMetricsPage(containerName string, p Page) ([]ContainerMetric, string, error) {
+	containerName = AppService(containerName)
+
+	where, whereArgs := serviceCondition("container_name", containerName)
+	cond, args, next, err := db.pageFilter("container_metrics", where, whereArgs, p)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows, err := db.Query(`
+		SELECT metrics_json
+		FROM container_metrics
+		WHERE `+cond+`
+		ORDER BY timestamp ASC
+	`, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	metrics := []ContainerMetric{}
+	for rows.Next() {
+		var metricsJSON string
+		if err := rows.Scan(&metricsJSON); err != nil {
+			return nil, "", err
+		}
+
+		var metric ContainerMetric
+		if err := json.Unmarshal([]byte(metricsJSON), &metric); err != nil {
+			return nil, "", err
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics, next, rows.Err()
+}
+
+// GetContainerMetricsInRange returns the samples of every container of one of
+// the given services, as matched by IsServiceContainer, ordered by timestamp.
+func (db *DB) GetContainerMetricsInRange(services []string, start, end time.Time) ([]ContainerMetric, error) {
+	if len(services) == 0 {
+		return []ContainerMetric{}, nil
+	}
+
+	conditions := make([]string, len(services))
+	var args []interface{}
+	for i, service := range services {
+		var serviceArgs []interface{}
+		conditions[i], serviceArgs = serviceCondition("container_name", service)
+		args = append(args, serviceArgs...)
 	}
+	args = append(args, start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano))
 
 	query := `
-		WITH recent_metrics AS (
-			SELECT metrics_json
-			FROM container_metrics
-			WHERE container_name LIKE ? || '%'
-			ORDER BY timestamp DESC
-		)
-		SELECT metrics_json FROM recent_metrics ORDER BY json_extract(metrics_json, '$.timestamp') ASC
+		SELECT metrics_json
+		FROM container_metrics
+		WHERE (` + strings.Join(conditions, " OR ") + `)
+		AND timestamp BETWEEN ? AND ?
+		ORDER BY timestamp ASC
 	`
-	rows, err := db.Query(query, containerName)
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -112,8 +199,7 @@ func (db *DB) GetAllMetricsContainer(containerName string) ([]ContainerMetric, e
 	var metrics []ContainerMetric
 	for rows.Next() {
 		var metricsJSON string
-		err := rows.Scan(&metricsJSON)
-		if err != nil {
+		if err := rows.Scan(&metricsJSON); err != nil {
 			return nil, err
 		}
 
@@ -123,7 +209,64 @@ func (db *DB) GetAllMetricsContainer(containerName string) ([]ContainerMetric, e
 		}
 		metrics = append(metrics, metric)
 	}
-	return metrics, nil
+	return metrics, rows.Err()
+}
+
+// GetLastContainerSampleTimes returns the timestamp of the most recent sample
+// stored for each container name.
+func (db *DB) GetLastContainerSampleTimes() (map[string]string, error) {
+	rows, err := db.Query(`
+		SELECT container_name, MAX(timestamp)
+		FROM container_metrics
+		GROUP BY container_name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]string)
+	for rows.Next() {
+		var name, timestamp string
+		if err := rows.Scan(&name, &timestamp); err != nil {
+			return nil, err
+		}
+		result[name] = timestamp
+	}
+	return result, rows.Err()
+}
+
+// GetLatestContainerMetrics returns the most recent sample of every container
+// name stored since the given time.
+func (db *DB) GetLatestContainerMetrics(since time.Time) ([]ContainerMetric, error) {
+	rows, err := db.Query(`
+		SELECT metrics_json
+		FROM container_metrics
+		WHERE id IN (
+			SELECT MAX(id) FROM container_metrics
+			WHERE timestamp >= ?
+			GROUP BY container_name
+		)
+	`, since.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	metrics := []ContainerMetric{}
+	for rows.Next() {
+		var metricsJSON string
+		if err := rows.Scan(&metricsJSON); err != nil {
+			return nil, err
+		}
+
+		var metric ContainerMetric
+		if err := json.Unmarshal([]byte(metricsJSON), &metric); err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics, rows.Err()
 }
 
 type ContainerMetric struct {
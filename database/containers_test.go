@@ -0,0 +1,71 @@
+package database
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestIsServiceContainer(t *testing.T) {
+	for _, tc := range []struct {
+		name, service string
+		want          bool
+	}{
+		{"app", "app", true},
+		{"/app-1", "app", true},
+		{"app_1", "app", true},
+		{"app.1.k2j3h4", "app", true},
+		{"app-api-1", "app", true},
+		{"app2-1", "app", false},
+		{"application-1", "app", false},
+		{"ap", "app", false},
+	} {
+		if got := IsServiceContainer(tc.name, tc.service); got != tc.want {
+			t.Errorf("IsServiceContainer(%q, %q) = %v, want %v", tc.name, tc.service, got, tc.want)
+		}
+	}
+}
+
+func TestGetContainerMetricsInRangeMatchesServices(t *testing.T) {
+	db := openTestDB(t)
+	names := []string{"app-1", "app_2", "app.1.abc", "app", "app2-1", "my_app-1", "myxapp-1", "100%-1", "100x-1"}
+	for _, name := range names {
+		if err := db.SaveContainerMetric(&ContainerMetric{Timestamp: "2026-10-15T10:00:00Z", Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start, _ := time.Parse(time.RFC3339, "2026-10-15T09:00:00Z")
+	end, _ := time.Parse(time.RFC3339, "2026-10-15T11:00:00Z")
+	for _, tc := range []struct {
+		services []string
+		want     []string
+	}{
+		{[]string{"app"}, []string{"app", "app-1", "app.1.abc", "app_2"}},
+		{[]string{"my_app"}, []string{"my_app-1"}},
+		{[]string{"100%"}, []string{"100%-1"}},
+		{[]string{"app2", "myxapp"}, []string{"app2-1", "myxapp-1"}},
+	} {
+		metrics, err := db.GetContainerMetricsInRange(tc.services, start, end)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, m := range metrics {
+			got = append(got, m.Name)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%v: got containers %v, want %v", tc.services, got, tc.want)
+		}
+	}
+
+	page, _, err := db.GetContainerMetricsPage("my_app-1", Page{Size: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 1 || page[0].Name != "my_app-1" {
+		t.Errorf("got page %+v for my_app-1, want only my_app-1", page)
+	}
+}
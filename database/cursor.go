@@ -0,0 +1,154 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// The cursors of the pusher, which point into this database. The cursors of
+// the aggregator point into the databases of the agents it pulls from.
+const (
+	PushServerCursor     = "push.server"
+	PushContainersCursor = "push.containers"
+)
+
+// pushCursorTables maps the push cursors to the table whose ids they hold.
+var pushCursorTables = map[string]string{
+	PushServerCursor:     "server_metrics",
+	PushContainersCursor: "container_metrics",
+}
+
+func (db *DB) InitCursorsTable() error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS cursors (
+			name TEXT PRIMARY KEY,
+			value INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating cursors table: %v", err)
+	}
+	return nil
+}
+
+// GetCursor returns the stored position of a named cursor, or 0.
+func (db *DB) GetCursor(name string) (int64, error) {
+	var value int64
+	err := db.QueryRow(`SELECT value FROM cursors WHERE name = ?`, name).Scan(&value)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return value, err
+}
+
+func (db *DB) SaveCursor(name string, value int64) error {
+	return saveCursor(db, name, value)
+}
+
+// execer is implemented by both the database and its transactions.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func saveCursor(db execer, name string, value int64) error {
+	_, err := db.Exec(`
+		INSERT INTO cursors (name, value) VALUES (?, ?)
+		ON CONFLICT(name) DO UPDATE SET value = excluded.value
+	`, name, value)
+	return err
+}
+
+// GetPushCursors returns the positions of the push cursors.
+func (db *DB) GetPushCursors() (map[string]int64, error) {
+	cursors := make(map[string]int64, len(pushCursorTables))
+	for name := range pushCursorTables {
+		value, err := db.GetCursor(name)
+		if err != nil {
+			return nil, err
+		}
+		cursors[name] = value
+	}
+	return cursors, nil
+}
+
+// PushCursorRows returns the timestamp of the row each push cursor points
+// at: the last one at or before its position, or "" when there is none.
+// Rewriting the database must not change it, or the pusher would resend or
+// skip rows.
+func (db *DB) PushCursorRows(cursors map[string]int64) (map[string]string, error) {
+	rows := make(map[string]string, len(cursors))
+	for name, value := range cursors {
+		table, ok := pushCursorTables[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown push cursor %q", name)
+		}
+		var timestamp string
+		err := db.QueryRow(`SELECT timestamp FROM `+table+` WHERE id <= ? ORDER BY id DESC LIMIT 1`, value).Scan(&timestamp)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		rows[name] = timestamp
+	}
+	return rows, nil
+}
+
+// GetServerMetricsAfter returns up to limit server metrics stored after the
+// given id, with the id of the last one returned.
+func (db *DB) GetServerMetricsAfter(id int64, limit int) ([]ServerMetric, int64, error) {
+	rows, err := db.Query(`
+		SELECT id, timestamp, cpu, cpu_model, cpu_cores, cpu_physical_cores, cpu_speed, os, distro, kernel, arch, mem_used, mem_used_gb, mem_total, uptime, disk_used, total_disk, network_in, network_out, upload_rate, download_rate
+		FROM server_metrics
+		WHERE id > ?
+		ORDER BY id ASC
+		LIMIT ?
+	`, id, limit)
+	if err != nil {
+		return nil, id, err
+	}
+	defer rows.Close()
+
+	metrics := []ServerMetric{}
+	last := id
+	for rows.Next() {
+		var m ServerMetric
+		err := rows.Scan(&last, &m.Timestamp, &m.CPU, &m.CPUModel, &m.CPUCores, &m.CPUPhysicalCores, &m.CPUSpeed, &m.OS, &m.Distro, &m.Kernel, &m.Arch, &m.MemUsed, &m.MemUsedGB, &m.MemTotal, &m.Uptime, &m.DiskUsed, &m.TotalDisk, &m.NetworkIn, &m.NetworkOut, &m.UploadRate, &m.DownloadRate)
+		if err != nil {
+			return nil, id, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, last, rows.Err()
+}
+
+// GetContainerMetricsAfter returns up to limit container metrics stored after
+// the given id, with the id of the last one returned.
+func (db *DB) GetContainerMetricsAfter(id int64, limit int) ([]ContainerMetric, int64, error) {
+	rows, err := db.Query(`
+		SELECT id, metrics_json
+		FROM container_metrics
+		WHERE id > ?
+		ORDER BY id ASC
+		LIMIT ?
+	`, id, limit)
+	if err != nil {
+		return nil, id, err
+	}
+	defer rows.Close()
+
+	metrics := []ContainerMetric{}
+	last := id
+	for rows.Next() {
+		var metricsJSON string
+		if err := rows.Scan(&last, &metricsJSON); err != nil {
+			return nil, id, err
+		}
+
+		var metric ContainerMetric
+		if err := json.Unmarshal([]byte(metricsJSON), &metric); err != nil {
+			return nil, id, err
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics, last, rows.Err()
+}
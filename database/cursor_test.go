@@ -0,0 +1,145 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// openTestDB returns an empty database with the metrics and cursors tables.
+func openTestDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := open(filepath.Join(t.TempDir(), "monitoring.db"), "mode=rwc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(`CREATE TABLE server_metrics (` + serverMetricsColumns + `)`); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.InitContainerMetricsTable(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.InitCursorsTable(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func saveTestMetrics(t *testing.T, db *DB, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		timestamp := fmt.Sprintf("2026-10-15T10:00:%02dZ", i)
+		if err := db.SaveMetric(ServerMetric{Timestamp: timestamp, CPU: float64(i)}); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.SaveContainerMetric(&ContainerMetric{Timestamp: timestamp, Name: "app", CPU: float64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestVacuumKeepsPushCursors(t *testing.T) {
+	db := openTestDB(t)
+	saveTestMetrics(t, db, 10)
+
+	// Expired rows leave holes in the ids, which VACUUM renumbers in a
+	// table without an integer primary key.
+	for _, table := range []string{"server_metrics", "container_metrics"} {
+		if _, err := db.Exec(`DELETE FROM ` + table + ` WHERE timestamp < '2026-10-15T10:00:04Z'`); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, server, err := db.GetServerMetricsAfter(0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, containers, err := db.GetContainerMetricsAfter(0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveCursor(PushServerCursor, server); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveCursor(PushContainersCursor, containers); err != nil {
+		t.Fatal(err)
+	}
+
+	cursors, err := db.GetPushCursors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := db.PushCursorRows(cursors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`VACUUM`); err != nil {
+		t.Fatal(err)
+	}
+	after, err := db.PushCursorRows(cursors)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		PushServerCursor:     "2026-10-15T10:00:06Z",
+		PushContainersCursor: "2026-10-15T10:00:06Z",
+	} {
+		if before[name] != want || after[name] != want {
+			t.Errorf("cursor %s points at %q before and %q after VACUUM, want %q", name, before[name], after[name], want)
+		}
+	}
+
+	metrics, _, err := db.GetServerMetricsAfter(server, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics) != 3 || metrics[0].Timestamp != "2026-10-15T10:00:07Z" {
+		t.Errorf("got %d server metrics after the cursor starting at %+v, want 3 starting at 10:00:07", len(metrics), metrics)
+	}
+}
+
+func TestMigrateServerMetricsID(t *testing.T) {
+	db, err := open(filepath.Join(t.TempDir(), "monitoring.db"), "mode=rwc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`CREATE TABLE server_metrics (timestamp TEXT PRIMARY KEY, cpu REAL, cpu_model TEXT, cpu_cores INTEGER, cpu_physical_cores INTEGER, cpu_speed REAL, os TEXT, distro TEXT, kernel TEXT, arch TEXT, mem_used REAL, mem_used_gb REAL, mem_total REAL, uptime INTEGER, disk_used REAL, total_disk REAL, network_in REAL, network_out REAL, upload_rate REAL, download_rate REAL)`); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := db.Exec(`INSERT INTO server_metrics VALUES (?, ?, '', 0, 0, 0, '', '', '', '', 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)`, fmt.Sprintf("2026-10-15T10:00:%02dZ", i), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec(`DELETE FROM server_metrics WHERE cpu < 2`); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := migrateServerMetricsID(db.DB); err != nil {
+			t.Fatalf("migration %d: %v", i+1, err)
+		}
+	}
+
+	// The ids are the old rowids, so a stored cursor keeps its position.
+	metrics, last, err := db.GetServerMetricsAfter(3, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics) != 2 || metrics[0].Timestamp != "2026-10-15T10:00:03Z" || last != 5 {
+		t.Errorf("got %+v up to %d after id 3, want 10:00:03 and 10:00:04 up to 5", metrics, last)
+	}
+
+	// New rows continue after the old ones.
+	if err := db.SaveMetric(ServerMetric{Timestamp: "2026-10-15T10:00:05Z"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, last, err = db.GetServerMetricsAfter(5, 10); err != nil || last != 6 {
+		t.Errorf("got id %d (%v) for the new row, want 6", last, err)
+	}
+}
@@ -0,0 +1,143 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CustomMetric is a labeled sample recorded by an external process through
+// the ingestion endpoints.
+type CustomMetric struct {
+	Timestamp string            `json:"timestamp"`
+	Name      string            `json:"name"`
+	Labels    map[string]string `json:"labels"`
+	Value     float64           `json:"value"`
+}
+
+func (db *DB) InitCustomMetricsTable() error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS custom_metrics (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp TEXT NOT NULL,
+			name TEXT NOT NULL,
+			labels TEXT NOT NULL,
+			value REAL NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating custom_metrics table: %v", err)
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_custom_metrics_name_timestamp ON custom_metrics(name, timestamp)`)
+	if err != nil {
+		return fmt.Errorf("error creating name index: %v", err)
+	}
+
+	return nil
+}
+
+// SaveCustomMetrics stores a batch of samples in a single transaction.
+func (db *DB) SaveCustomMetrics(metrics []CustomMetric) (err error) {
+	defer observeWrite("custom_metrics", time.Now(), &err)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO custom_metrics (timestamp, name, labels, value)
+		VALUES (?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, m := range metrics {
+		if m.Timestamp == "" {
+			m.Timestamp = time.Now().UTC().Format(time.RFC3339Nano)
+		}
+		labels := m.Labels
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		// encoding/json sorts map keys, so equal label sets always produce
+		// the same string and can be grouped on.
+		labelsJSON, err := json.Marshal(labels)
+		if err != nil {
+			return fmt.Errorf("error marshaling labels: %v", err)
+		}
+		if _, err := stmt.Exec(m.Timestamp, m.Name, string(labelsJSON), m.Value); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetCustomMetricsInRange returns the samples of a metric whose labels match
+// every given label value, ordered by timestamp.
+func (db *DB) GetCustomMetricsInRange(name string, labels map[string]string, start, end time.Time) ([]CustomMetric, error) {
+	query := `
+		SELECT timestamp, name, labels, value
+		FROM custom_metrics
+		WHERE name = ?
+		AND timestamp BETWEEN ? AND ?
+	`
+	args := []interface{}{name, start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano)}
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		query += ` AND json_extract(labels, ?) = ?`
+		args = append(args, `$."`+strings.ReplaceAll(key, `"`, `""`)+`"`, labels[key])
+	}
+	query += ` ORDER BY timestamp ASC`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	metrics := []CustomMetric{}
+	for rows.Next() {
+		var m CustomMetric
+		var labelsJSON string
+		if err := rows.Scan(&m.Timestamp, &m.Name, &labelsJSON, &m.Value); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(labelsJSON), &m.Labels); err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, rows.Err()
+}
+
+// GetCustomMetricNames returns the names of all stored custom metrics.
+func (db *DB) GetCustomMetricNames() ([]string, error) {
+	rows, err := db.Query(`SELECT DISTINCT name FROM custom_metrics ORDER BY name ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
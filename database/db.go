@@ -2,48 +2,116 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// File is the path of the SQLite database.
+const File = "./monitoring.db"
+
 type DB struct {
 	*sql.DB
 }
 
+// OpenReadOnly opens an existing database without creating or changing
+// anything, e.g. to read the database of a running agent.
+func OpenReadOnly(path string) (*DB, error) {
+	return open(path, "mode=ro")
+}
+
+// Open opens an existing database for the administration commands. Unlike
+// InitDB it doesn't create the database or its tables.
+func Open(path string) (*DB, error) {
+	return open(path, "mode=rw")
+}
+
+func open(path, mode string) (*DB, error) {
+	// The busy timeout lets the commands wait for the writes of a running
+	// agent instead of failing with "database is locked".
+	db, err := sql.Open("sqlite3", "file:"+path+"?"+mode+"&_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &DB{db}, nil
+}
+
+// serverMetricsColumns are the columns of server_metrics. The id gives the
+// push and aggregator cursors a stable position: unlike the implicit rowid
+// of a table with a TEXT primary key, VACUUM keeps it and AUTOINCREMENT
+// never reuses the ids of deleted rows.
+const serverMetricsColumns = `
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp TEXT NOT NULL UNIQUE,
+	cpu REAL,
+	cpu_model TEXT,
+	cpu_cores INTEGER,
+	cpu_physical_cores INTEGER,
+	cpu_speed REAL,
+	os TEXT,
+	distro TEXT,
+	kernel TEXT,
+	arch TEXT,
+	mem_used REAL,
+	mem_used_gb REAL,
+	mem_total REAL,
+	uptime INTEGER,
+	disk_used REAL,
+	total_disk REAL,
+	network_in REAL,
+	network_out REAL,
+	upload_rate REAL,
+	download_rate REAL
+`
+
 func InitDB() (*DB, error) {
-	db, err := sql.Open("sqlite3", "./monitoring.db")
+	db, err := sql.Open("sqlite3", File)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create metrics table if it doesn't exist
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS server_metrics (
-			timestamp TEXT PRIMARY KEY,
-			cpu REAL,
-			cpu_model TEXT,
-			cpu_cores INTEGER,
-			cpu_physical_cores INTEGER,
-			cpu_speed REAL,
-			os TEXT,
-			distro TEXT,
-			kernel TEXT,
-			arch TEXT,
-			mem_used REAL,
-			mem_used_gb REAL,
-			mem_total REAL,
-			uptime INTEGER,
-			disk_used REAL,
-			total_disk REAL,
-			network_in REAL,
-			network_out REAL,
-			upload_rate REAL,
-			download_rate REAL
-		)
-	`)
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS server_metrics (` + serverMetricsColumns + `)`)
 	if err != nil {
 		return nil, err
 	}
+	if err := migrateServerMetricsID(db); err != nil {
+		return nil, fmt.Errorf("error adding id to server_metrics: %v", err)
+	}
 
 	return &DB{db}, nil
 }
+
+// migrateServerMetricsID rebuilds a server_metrics table created before it
+// had an id column. The ids are the old rowids, which the stored cursors
+// point to.
+func migrateServerMetricsID(db *sql.DB) error {
+	var hasID bool
+	err := db.QueryRow(`SELECT COUNT(*) > 0 FROM pragma_table_info('server_metrics') WHERE name = 'id'`).Scan(&hasID)
+	if err != nil || hasID {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	const columns = `timestamp, cpu, cpu_model, cpu_cores, cpu_physical_cores, cpu_speed, os, distro, kernel, arch, mem_used, mem_used_gb, mem_total, uptime, disk_used, total_disk, network_in, network_out, upload_rate, download_rate`
+	for _, stmt := range []string{
+		`CREATE TABLE server_metrics_new (` + serverMetricsColumns + `)`,
+		`INSERT INTO server_metrics_new (id, ` + columns + `) SELECT rowid, ` + columns + ` FROM server_metrics ORDER BY rowid`,
+		`DROP TABLE server_metrics`,
+		`ALTER TABLE server_metrics_new RENAME TO server_metrics`,
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,239 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type FleetHost struct {
+	Host     string        `json:"host"`
+	Source   string        `json:"source"`
+	LastSeen string        `json:"lastSeen"`
+	Latest   *ServerMetric `json:"latest"`
+}
+
+type FleetHostValue struct {
+	Host  string  `json:"host"`
+	Value float64 `json:"value"`
+}
+
+type FleetServiceHost struct {
+	Host       string   `json:"host"`
+	Containers []string `json:"containers"`
+	LastSample string   `json:"lastSample"`
+}
+
+// fleetColumns maps the metrics that can be ranked across hosts to the
+// columns of fleet_server_metrics.
+var fleetColumns = map[string]string{
+	"cpu":      "cpu",
+	"memory":   "mem_used",
+	"disk":     "disk_used",
+	"upload":   "upload_rate",
+	"download": "download_rate",
+}
+
+func (db *DB) InitFleetTables() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS fleet_hosts (
+			host TEXT PRIMARY KEY,
+			source TEXT NOT NULL,
+			last_seen TEXT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS fleet_server_metrics (
+			host TEXT NOT NULL,
+			timestamp TEXT NOT NULL,
+			cpu REAL,
+			mem_used REAL,
+			disk_used REAL,
+			upload_rate REAL,
+			download_rate REAL,
+			metrics_json TEXT NOT NULL,
+			PRIMARY KEY (host, timestamp)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_fleet_server_metrics_timestamp ON fleet_server_metrics(timestamp)`,
+		`CREATE TABLE IF NOT EXISTS fleet_container_metrics (
+			host TEXT NOT NULL,
+			timestamp TEXT NOT NULL,
+			container_name TEXT NOT NULL,
+			metrics_json TEXT NOT NULL,
+			PRIMARY KEY (host, container_name, timestamp)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_fleet_container_metrics_name_timestamp ON fleet_container_metrics(container_name, timestamp)`,
+	}
+
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			return fmt.Errorf("error creating fleet tables: %v", err)
+		}
+	}
+	return nil
+}
+
+// SaveFleetSamples stores the samples received from an agent. Samples that
+// were already stored, e.g. because a push was retried, are ignored. The
+// given cursors are saved in the same transaction, so a pull cannot move
+// past samples that were not stored, nor store samples twice because its
+// cursor was not saved.
+func (db *DB) SaveFleetSamples(host, source string, server []ServerMetric, containers []ContainerMetric, cursors map[string]int64) (err error) {
+	defer observeWrite("fleet_metrics", time.Now(), &err)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, m := range server {
+		metricJSON, err := json.Marshal(m)
+		if err != nil {
+			return fmt.Errorf("error marshaling server metric: %v", err)
+		}
+		_, err = tx.Exec(`
+			INSERT OR IGNORE INTO fleet_server_metrics (host, timestamp, cpu, mem_used, disk_used, upload_rate, download_rate, metrics_json)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, host, m.Timestamp, m.CPU, m.MemUsed, m.DiskUsed, m.UploadRate, m.DownloadRate, string(metricJSON))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, m := range containers {
+		metricJSON, err := json.Marshal(m)
+		if err != nil {
+			return fmt.Errorf("error marshaling container metric: %v", err)
+		}
+		_, err = tx.Exec(`
+			INSERT OR IGNORE INTO fleet_container_metrics (host, timestamp, container_name, metrics_json)
+			VALUES (?, ?, ?, ?)
+		`, host, m.Timestamp, m.Name, string(metricJSON))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO fleet_hosts (host, source, last_seen) VALUES (?, ?, ?)
+		ON CONFLICT(host) DO UPDATE SET source = excluded.source, last_seen = excluded.last_seen
+	`, host, source, time.Now().UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return err
+	}
+
+	for name, value := range cursors {
+		if err := saveCursor(tx, name, value); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetFleetHosts returns every known host with its latest server sample.
+func (db *DB) GetFleetHosts() ([]FleetHost, error) {
+	rows, err := db.Query(`
+		SELECT h.host, h.source, h.last_seen, (
+			SELECT metrics_json FROM fleet_server_metrics m
+			WHERE m.host = h.host
+			ORDER BY timestamp DESC
+			LIMIT 1
+		)
+		FROM fleet_hosts h
+		ORDER BY h.host ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hosts := []FleetHost{}
+	for rows.Next() {
+		var h FleetHost
+		var latest *string
+		if err := rows.Scan(&h.Host, &h.Source, &h.LastSeen, &latest); err != nil {
+			return nil, err
+		}
+		if latest != nil {
+			var m ServerMetric
+			if err := json.Unmarshal([]byte(*latest), &m); err != nil {
+				return nil, err
+			}
+			h.Latest = &m
+		}
+		hosts = append(hosts, h)
+	}
+	return hosts, rows.Err()
+}
+
+// GetFleetTopHosts ranks hosts by the average of a metric over a time range.
+func (db *DB) GetFleetTopHosts(metric string, start, end time.Time, limit int) ([]FleetHostValue, error) {
+	column, ok := fleetColumns[metric]
+	if !ok {
+		names := make([]string, 0, len(fleetColumns))
+		for name := range fleetColumns {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown metric %q (expected one of %s)", metric, strings.Join(names, ", "))
+	}
+
+	rows, err := db.Query(`
+		SELECT host, AVG(`+column+`) AS value
+		FROM fleet_server_metrics
+		WHERE timestamp BETWEEN ? AND ?
+		GROUP BY host
+		ORDER BY value DESC
+		LIMIT ?
+	`, start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []FleetHostValue{}
+	for rows.Next() {
+		var v FleetHostValue
+		if err := rows.Scan(&v.Host, &v.Value); err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+	return result, rows.Err()
+}
+
+// GetFleetServiceHosts returns the hosts that reported containers whose name
+// starts with service since the given time.
+func (db *DB) GetFleetServiceHosts(service string, since time.Time) ([]FleetServiceHost, error) {
+	rows, err := db.Query(`
+		SELECT host, container_name, MAX(timestamp)
+		FROM fleet_container_metrics
+		WHERE container_name LIKE ? || '%'
+		AND timestamp >= ?
+		GROUP BY host, container_name
+		ORDER BY host ASC, container_name ASC
+	`, service, since.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []FleetServiceHost{}
+	for rows.Next() {
+		var host, name, timestamp string
+		if err := rows.Scan(&host, &name, &timestamp); err != nil {
+			return nil, err
+		}
+		if len(result) == 0 || result[len(result)-1].Host != host {
+			result = append(result, FleetServiceHost{Host: host, Containers: []string{}})
+		}
+		last := &result[len(result)-1]
+		last.Containers = append(last.Containers, name)
+		if timestamp > last.LastSample {
+			last.LastSample = timestamp
+		}
+	}
+	return result, rows.Err()
+}
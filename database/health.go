@@ -0,0 +1,30 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func (db *DB) InitHealthTable() error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS health_checks (
+			id INTEGER PRIMARY KEY,
+			timestamp TEXT NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating health_checks table: %v", err)
+	}
+	return nil
+}
+
+// CheckWrite updates the single row of health_checks, failing when the
+// database cannot be written, e.g. because it is locked or the disk is full.
+func (db *DB) CheckWrite(ctx context.Context) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO health_checks (id, timestamp) VALUES (1, ?)
+		ON CONFLICT(id) DO UPDATE SET timestamp = excluded.timestamp
+	`, time.Now().UTC().Format(time.RFC3339Nano))
+	return err
+}
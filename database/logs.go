@@ -0,0 +1,108 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+type ContainerLogMetric struct {
+	Timestamp string `json:"timestamp"`
+	Service   string `json:"service"`
+	Pattern   string `json:"pattern"`
+	Count     int    `json:"count"`
+	Lines     int    `json:"lines"`
+}
+
+func (db *DB) InitContainerLogMetricsTable() error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS container_log_metrics (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp TEXT NOT NULL,
+			service TEXT NOT NULL,
+			pattern TEXT NOT NULL,
+			count INTEGER NOT NULL,
+			lines INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating container_log_metrics table: %v", err)
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_container_log_metrics_service_timestamp ON container_log_metrics(service, timestamp)`)
+	if err != nil {
+		return fmt.Errorf("error creating service index: %v", err)
+	}
+
+	return nil
+}
+
+func (db *DB) SaveContainerLogMetric(metric ContainerLogMetric) (err error) {
+	defer observeWrite("container_log_metrics", time.Now(), &err)
+
+	_, err = db.Exec(`
+		INSERT INTO container_log_metrics (timestamp, service, pattern, count, lines)
+		VALUES (?, ?, ?, ?, ?)
+	`, metric.Timestamp, metric.Service, metric.Pattern, metric.Count, metric.Lines)
+	return err
+}
+
+// GetContainerLogMetricsInRange returns the log match counts of the service
+// of an app name, as matched by AppService and IsServiceContainer.
+func (db *DB) GetContainerLogMetricsInRange(appName string, start, end time.Time) ([]ContainerLogMetric, error) {
+	cond, args := serviceCondition("service", AppService(appName))
+	args = append(args, start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano))
+	rows, err := db.Query(`
+		SELECT timestamp, service, pattern, count, lines
+		FROM container_log_metrics
+		WHERE `+cond+`
+		AND timestamp BETWEEN ? AND ?
+		ORDER BY timestamp ASC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	metrics := []ContainerLogMetric{}
+	for rows.Next() {
+		var m ContainerLogMetric
+		if err := rows.Scan(&m.Timestamp, &m.Service, &m.Pattern, &m.Count, &m.Lines); err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, rows.Err()
+}
+
+// GetContainerLogMetricsPage returns a page of the log match counts of the
+// service of an app name within a time range, with the cursor of the next
+// page.
+func (db *DB) GetContainerLogMetricsPage(appName string, start, end time.Time, p Page) ([]ContainerLogMetric, string, error) {
+	where, whereArgs := serviceCondition("service", AppService(appName))
+	whereArgs = append(whereArgs, start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano))
+	cond, args, next, err := db.pageFilter("container_log_metrics", where+" AND timestamp BETWEEN ? AND ?", whereArgs, p)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows, err := db.Query(`
+		SELECT timestamp, service, pattern, count, lines
+		FROM container_log_metrics
+		WHERE `+cond+`
+		ORDER BY timestamp ASC
+	`, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	metrics := []ContainerLogMetric{}
+	for rows.Next() {
+		var m ContainerLogMetric
+		if err := rows.Scan(&m.Timestamp, &m.Service, &m.Pattern, &m.Count, &m.Lines); err != nil {
+			return nil, "", err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, next, rows.Err()
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestContainerLogMetricsMatchServices(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.InitContainerLogMetricsTable(); err != nil {
+		t.Fatal(err)
+	}
+	for _, service := range []string{"myapp", "myapp2", "myapplication", "my_pp", "myxpp"} {
+		err := db.SaveContainerLogMetric(ContainerLogMetric{Timestamp: "2026-10-15T10:00:00Z", Service: service, Pattern: "error", Count: 1, Lines: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start, _ := time.Parse(time.RFC3339, "2026-10-15T09:00:00Z")
+	end, _ := time.Parse(time.RFC3339, "2026-10-15T11:00:00Z")
+	services := func(metrics []ContainerLogMetric) []string {
+		var names []string
+		for _, m := range metrics {
+			names = append(names, m.Service)
+		}
+		sort.Strings(names)
+		return names
+	}
+	for _, tc := range []struct {
+		appName string
+		want    []string
+	}{
+		{"myapp-xyz123", []string{"myapp"}},
+		{"myapp", []string{"myapp"}},
+		{"my_pp-abc", []string{"my_pp"}},
+	} {
+		metrics, err := db.GetContainerLogMetricsInRange(tc.appName, start, end)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := services(metrics); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s in range: got services %v, want %v", tc.appName, got, tc.want)
+		}
+
+		page, _, err := db.GetContainerLogMetricsPage(tc.appName, start, end, Page{Size: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := services(page); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s page: got services %v, want %v", tc.appName, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+// Page selects the rows stored after a cursor, oldest first. The cursor is
+// the timestamp of the last row of the previous page.
+type Page struct {
+	After string
+	Size  int
+}
+
+// ParsePageCursor parses the after parameter of the paginated endpoints,
+// accepting the formats of ParseTimeRange. Cursors returned as next_cursor
+// are RFC3339 timestamps, which are returned unchanged.
+func ParsePageCursor(after string, now time.Time) (string, error) {
+	if after == "" {
+		return "", nil
+	}
+	t, err := parseTimeParam(after, now.UTC())
+	if err != nil {
+		return "", fmt.Errorf("invalid after: %v", err)
+	}
+	return t.UTC().Format(time.RFC3339Nano), nil
+}
+
+// pageFilter returns the condition selecting the rows of a page among the
+// rows of table matching where, if any, and the cursor of the next page,
+// empty on the last page. A page ends on a timestamp boundary: rows sharing
+// the timestamp of its last row, such as the log counts of several patterns,
+// are all part of it, so it may hold more than p.Size rows.
+func (db *DB) pageFilter(table, where string, args []interface{}, p Page) (string, []interface{}, string, error) {
+	cond := "timestamp > ?"
+	if where != "" {
+		cond = where + " AND " + cond
+	}
+	condArgs := append(append([]interface{}{}, args...), p.After)
+
+	var last string
+	err := db.QueryRow(`
+		SELECT timestamp FROM `+table+`
+		WHERE `+cond+`
+		ORDER BY timestamp ASC
+		LIMIT 1 OFFSET ?
+	`, append(condArgs, p.Size-1)...).Scan(&last)
+	if err == sql.ErrNoRows {
+		return cond, condArgs, "", nil
+	}
+	if err != nil {
+		return "", nil, "", err
+	}
+	return cond + " AND timestamp <= ?", append(condArgs, last), last, nil
+}
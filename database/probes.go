@@ -0,0 +1,129 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+type ProbeResult struct {
+	Timestamp  string  `json:"timestamp"`
+	Name       string  `json:"name"`
+	Type       string  `json:"type"`
+	Target     string  `json:"target"`
+	Success    bool    `json:"success"`
+	LatencyMs  float64 `json:"latencyMs"`
+	StatusCode int     `json:"statusCode,omitempty"`
+	Error      string  `json:"error,omitempty"`
+}
+
+func (db *DB) InitProbeResultsTable() error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS probe_results (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp TEXT NOT NULL,
+			name TEXT NOT NULL,
+			type TEXT NOT NULL,
+			target TEXT NOT NULL,
+			success INTEGER NOT NULL,
+			latency_ms REAL NOT NULL,
+			status_code INTEGER NOT NULL,
+			error TEXT NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating probe_results table: %v", err)
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_probe_results_name_timestamp ON probe_results(name, timestamp)`)
+	if err != nil {
+		return fmt.Errorf("error creating name index: %v", err)
+	}
+
+	return nil
+}
+
+func (db *DB) SaveProbeResult(result ProbeResult) (err error) {
+	defer observeWrite("probe_results", time.Now(), &err)
+
+	_, err = db.Exec(`
+		INSERT INTO probe_results (timestamp, name, type, target, success, latency_ms, status_code, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, result.Timestamp, result.Name, result.Type, result.Target, result.Success, result.LatencyMs, result.StatusCode, result.Error)
+	return err
+}
+
+func (db *DB) GetLastNProbeResults(name string, limit int) ([]ProbeResult, error) {
+	rows, err := db.Query(`
+		WITH recent_results AS (
+			SELECT timestamp, name, type, target, success, latency_ms, status_code, error
+			FROM probe_results
+			WHERE name = ?
+			ORDER BY timestamp DESC
+			LIMIT ?
+		)
+		SELECT * FROM recent_results
+		ORDER BY timestamp ASC
+	`, name, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []ProbeResult{}
+	for rows.Next() {
+		var r ProbeResult
+		if err := rows.Scan(&r.Timestamp, &r.Name, &r.Type, &r.Target, &r.Success, &r.LatencyMs, &r.StatusCode, &r.Error); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// GetProbeResultsPage returns a page of the results of a probe with the
+// cursor of the next page.
+func (db *DB) GetProbeResultsPage(name string, p Page) ([]ProbeResult, string, error) {
+	cond, args, next, err := db.pageFilter("probe_results", "name = ?", []interface{}{name}, p)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows, err := db.Query(`
+		SELECT timestamp, name, type, target, success, latency_ms, status_code, error
+		FROM probe_results
+		WHERE `+cond+`
+		ORDER BY timestamp ASC
+	`, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	results := []ProbeResult{}
+	for rows.Next() {
+		var r ProbeResult
+		if err := rows.Scan(&r.Timestamp, &r.Name, &r.Type, &r.Target, &r.Success, &r.LatencyMs, &r.StatusCode, &r.Error); err != nil {
+			return nil, "", err
+		}
+		results = append(results, r)
+	}
+	return results, next, rows.Err()
+}
+
+// GetProbeUptime returns the percentage of successful checks of a probe since
+// the given time, or -1 when there are no results.
+func (db *DB) GetProbeUptime(name string, since time.Time) (float64, error) {
+	var total, successful int
+	err := db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(success), 0)
+		FROM probe_results
+		WHERE name = ? AND timestamp >= ?
+	`, name, since.UTC().Format(time.RFC3339Nano)).Scan(&total, &successful)
+	if err != nil {
+		return 0, err
+	}
+	if total == 0 {
+		return -1, nil
+	}
+	return float64(successful) / float64(total) * 100, nil
+}
@@ -0,0 +1,140 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRangeDuration = time.Hour
+	// defaultRangePoints is the number of buckets used when no step is given.
+	defaultRangePoints = 120
+	// maxRangePoints bounds the number of buckets a single query may produce.
+	maxRangePoints = 11000
+)
+
+// TimeRange describes a query window split into buckets of Step length.
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+	Step  time.Duration
+}
+
+// ParseTimeRange parses the from, to and step query parameters shared by the
+// range endpoints. Times may be RFC3339 timestamps, unix seconds, "now" or a
+// duration relative to now such as "-6h" or "7d"; step is a duration. Empty
+// values default to the last hour with an automatically sized step.
+func ParseTimeRange(from, to, step string, now time.Time) (TimeRange, error) {
+	now = now.UTC()
+
+	end := now
+	if to != "" {
+		t, err := parseTimeParam(to, now)
+		if err != nil {
+			return TimeRange{}, fmt.Errorf("invalid to: %v", err)
+		}
+		end = t
+	}
+
+	start := end.Add(-defaultRangeDuration)
+	if from != "" {
+		t, err := parseTimeParam(from, now)
+		if err != nil {
+			return TimeRange{}, fmt.Errorf("invalid from: %v", err)
+		}
+		start = t
+	}
+
+	if !start.Before(end) {
+		return TimeRange{}, fmt.Errorf("from must be before to")
+	}
+
+	var stepDuration time.Duration
+	if step != "" {
+		d, err := ParseDuration(step)
+		if err != nil {
+			return TimeRange{}, fmt.Errorf("invalid step: %v", err)
+		}
+		if d <= 0 {
+			return TimeRange{}, fmt.Errorf("step must be positive")
+		}
+		stepDuration = d
+	} else {
+		stepDuration = end.Sub(start) / defaultRangePoints
+		if stepDuration < time.Second {
+			stepDuration = time.Second
+		}
+		stepDuration = stepDuration.Round(time.Second)
+	}
+
+	if int64(end.Sub(start)/stepDuration) > maxRangePoints {
+		return TimeRange{}, fmt.Errorf("range too large for step %v (max %d points)", stepDuration, maxRangePoints)
+	}
+
+	return TimeRange{Start: start, End: end, Step: stepDuration}, nil
+}
+
+// Buckets returns the number of Step sized buckets between Start and End.
+func (r TimeRange) Buckets() int {
+	n := int(r.End.Sub(r.Start) / r.Step)
+	if r.Start.Add(time.Duration(n) * r.Step).Before(r.End) {
+		n++
+	}
+	return n
+}
+
+// BucketIndex returns the bucket a timestamp falls into, or -1 when it is
+// outside the range.
+func (r TimeRange) BucketIndex(t time.Time) int {
+	if t.Before(r.Start) || t.After(r.End) {
+		return -1
+	}
+	i := int(t.Sub(r.Start) / r.Step)
+	if i >= r.Buckets() {
+		i = r.Buckets() - 1
+	}
+	return i
+}
+
+// Timestamps returns the start time of every bucket formatted like stored
+// sample timestamps.
+func (r TimeRange) Timestamps() []string {
+	n := r.Buckets()
+	result := make([]string, n)
+	for i := 0; i < n; i++ {
+		result[i] = r.Start.Add(time.Duration(i) * r.Step).Format(time.RFC3339Nano)
+	}
+	return result
+}
+
+// ParseDuration extends time.ParseDuration with a "d" suffix for days.
+func ParseDuration(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(value, "d"), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return time.Duration(days * float64(24*time.Hour)), nil
+	}
+	return time.ParseDuration(value)
+}
+
+func parseTimeParam(value string, now time.Time) (time.Time, error) {
+	if value == "now" {
+		return now, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t.UTC(), nil
+	}
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+
+	d, err := ParseDuration(strings.TrimPrefix(value, "-"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unrecognized time %q", value)
+	}
+	return now.Add(-d), nil
+}
@@ -0,0 +1,157 @@
+package database
+
+import "time"
+
+// HostSample holds the server metric values the reports are computed from.
+type HostSample struct {
+	Timestamp time.Time
+	CPU       float64
+	Memory    float64
+	Disk      float64
+	Upload    float64
+	Download  float64
+}
+
+// HostStats holds the number of host samples of a time range with the
+// average and maximum of each metric. Their timestamps are zero.
+type HostStats struct {
+	Samples int
+	Avg     HostSample
+	Max     HostSample
+}
+
+// GetHostStats computes the statistics of the host samples of a time range
+// without reading the samples.
+func (db *DB) GetHostStats(start, end time.Time) (HostStats, error) {
+	var s HostStats
+	err := db.QueryRow(`
+		SELECT COUNT(*),
+			COALESCE(AVG(cpu), 0), COALESCE(AVG(mem_used), 0), COALESCE(AVG(disk_used), 0),
+			COALESCE(AVG(upload_rate), 0), COALESCE(AVG(download_rate), 0),
+			COALESCE(MAX(cpu), 0), COALESCE(MAX(mem_used), 0), COALESCE(MAX(disk_used), 0),
+			COALESCE(MAX(upload_rate), 0), COALESCE(MAX(download_rate), 0)
+		FROM server_metrics
+		WHERE timestamp BETWEEN ? AND ?
+	`, start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano)).Scan(&s.Samples,
+		&s.Avg.CPU, &s.Avg.Memory, &s.Avg.Disk, &s.Avg.Upload, &s.Avg.Download,
+		&s.Max.CPU, &s.Max.Memory, &s.Max.Disk, &s.Max.Upload, &s.Max.Download)
+	return s, err
+}
+
+// EachHostSample calls fn with the host samples of a time range, oldest
+// first, as they are read. It stops at the first error returned by fn.
+func (db *DB) EachHostSample(start, end time.Time, fn func(HostSample) error) error {
+	rows, err := db.Query(`
+		SELECT timestamp, cpu, mem_used, disk_used, upload_rate, download_rate
+		FROM server_metrics
+		WHERE timestamp BETWEEN ? AND ?
+		ORDER BY timestamp ASC
+	`, start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s HostSample
+		var timestamp string
+		if err := rows.Scan(&timestamp, &s.CPU, &s.Memory, &s.Disk, &s.Upload, &s.Download); err != nil {
+			return err
+		}
+		if s.Timestamp, err = time.Parse(time.RFC3339Nano, timestamp); err != nil {
+			continue
+		}
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetHostSamples returns the host samples of a time range, oldest first.
+func (db *DB) GetHostSamples(start, end time.Time) ([]HostSample, error) {
+	var samples []HostSample
+	err := db.EachHostSample(start, end, func(s HostSample) error {
+		samples = append(samples, s)
+		return nil
+	})
+	return samples, err
+}
+
+type ProbeUptime struct {
+	Name          string  `json:"name"`
+	Type          string  `json:"type"`
+	Target        string  `json:"target"`
+	Checks        int     `json:"checks"`
+	Failures      int     `json:"failures"`
+	UptimePercent float64 `json:"uptimePercent"`
+	AvgLatencyMs  float64 `json:"avgLatencyMs"`
+}
+
+// GetProbeUptimes returns the share of successful checks of every probe with
+// results in a time range. Type and target are those of the last result.
+func (db *DB) GetProbeUptimes(start, end time.Time) ([]ProbeUptime, error) {
+	rows, err := db.Query(`
+		SELECT name, type, target, MAX(timestamp), COUNT(*), COALESCE(SUM(success), 0), COALESCE(AVG(latency_ms), 0)
+		FROM probe_results
+		WHERE timestamp BETWEEN ? AND ?
+		GROUP BY name
+		ORDER BY name ASC
+	`, start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	uptimes := []ProbeUptime{}
+	for rows.Next() {
+		var u ProbeUptime
+		var last string
+		var successful int
+		if err := rows.Scan(&u.Name, &u.Type, &u.Target, &last, &u.Checks, &successful, &u.AvgLatencyMs); err != nil {
+			return nil, err
+		}
+		u.Failures = u.Checks - successful
+		u.UptimePercent = float64(successful) / float64(u.Checks) * 100
+		uptimes = append(uptimes, u)
+	}
+	return uptimes, rows.Err()
+}
+
+type ContainerUsage struct {
+	Name             string  `json:"name"`
+	Samples          int     `json:"samples"`
+	AvgCPU           float64 `json:"avgCpu"`
+	MaxCPU           float64 `json:"maxCpu"`
+	AvgMemoryPercent float64 `json:"avgMemoryPercent"`
+	MaxMemoryPercent float64 `json:"maxMemoryPercent"`
+}
+
+// GetContainerUsage returns the average and peak usage of every container
+// with samples in a time range.
+func (db *DB) GetContainerUsage(start, end time.Time) ([]ContainerUsage, error) {
+	rows, err := db.Query(`
+		SELECT container_name, COUNT(*),
+			COALESCE(AVG(json_extract(metrics_json, '$.CPU')), 0),
+			COALESCE(MAX(json_extract(metrics_json, '$.CPU')), 0),
+			COALESCE(AVG(json_extract(metrics_json, '$.Memory.percentage')), 0),
+			COALESCE(MAX(json_extract(metrics_json, '$.Memory.percentage')), 0)
+		FROM container_metrics
+		WHERE timestamp BETWEEN ? AND ?
+		GROUP BY container_name
+	`, start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := []ContainerUsage{}
+	for rows.Next() {
+		var u ContainerUsage
+		if err := rows.Scan(&u.Name, &u.Samples, &u.AvgCPU, &u.MaxCPU, &u.AvgMemoryPercent, &u.MaxMemoryPercent); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
@@ -29,12 +29,14 @@ type ServerMetric struct {
 	DownloadRate     float64 `json:"downloadRate"` // 下载速率 (MB/s)
 }
 
-func (db *DB) SaveMetric(metric ServerMetric) error {
+func (db *DB) SaveMetric(metric ServerMetric) (err error) {
+	defer observeWrite("server_metrics", time.Now(), &err)
+
 	if metric.Timestamp == "" {
 		metric.Timestamp = time.Now().UTC().Format(time.RFC3339Nano)
 	}
 
-	_, err := db.Exec(`
+	_, err = db.Exec(`
 		INSERT INTO server_metrics (timestamp, cpu, cpu_model, cpu_cores, cpu_physical_cores, cpu_speed, os, distro, kernel, arch, mem_used, mem_used_gb, mem_total, uptime, disk_used, total_disk, network_in, network_out, upload_rate, download_rate)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, metric.Timestamp, metric.CPU, metric.CPUModel, metric.CPUCores, metric.CPUPhysicalCores, metric.CPUSpeed, metric.OS, metric.Distro, metric.Kernel, metric.Arch, metric.MemUsed, metric.MemUsedGB, metric.MemTotal, metric.Uptime, metric.DiskUsed, metric.TotalDisk, metric.NetworkIn, metric.NetworkOut, metric.UploadRate, metric.DownloadRate)
@@ -93,25 +95,33 @@ func (db *DB) GetLastNMetrics(n int) ([]ServerMetric, error) {
 	return metrics, nil
 }
 
-func (db *DB) GetAllMetrics() ([]ServerMetric, error) {
+// GetMetricsPage returns a page of server metrics with the cursor of the
+// next page.
+func (db *DB) GetMetricsPage(p Page) ([]ServerMetric, string, error) {
+	cond, args, next, err := db.pageFilter("server_metrics", "", nil, p)
+	if err != nil {
+		return nil, "", err
+	}
+
 	rows, err := db.Query(`
 		SELECT timestamp, cpu, cpu_model, cpu_cores, cpu_physical_cores, cpu_speed, os, distro, kernel, arch, mem_used, mem_used_gb, mem_total, uptime, disk_used, total_disk, network_in, network_out, upload_rate, download_rate
 		FROM server_metrics
+		WHERE `+cond+`
 		ORDER BY timestamp ASC
-	`)
+	`, args...)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	defer rows.Close()
 
-	var metrics []ServerMetric
+	metrics := []ServerMetric{}
 	for rows.Next() {
 		var m ServerMetric
 		err := rows.Scan(&m.Timestamp, &m.CPU, &m.CPUModel, &m.CPUCores, &m.CPUPhysicalCores, &m.CPUSpeed, &m.OS, &m.Distro, &m.Kernel, &m.Arch, &m.MemUsed, &m.MemUsedGB, &m.MemTotal, &m.Uptime, &m.DiskUsed, &m.TotalDisk, &m.NetworkIn, &m.NetworkOut, &m.UploadRate, &m.DownloadRate)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		metrics = append(metrics, m)
 	}
-	return metrics, nil
+	return metrics, next, rows.Err()
 }
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/telemetry"
+)
+
+// FileSize returns the size of the database from its page count, which does
+// not depend on where the file is.
+func (db *DB) FileSize() (int64, error) {
+	var size int64
+	err := db.QueryRow(`SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()`).Scan(&size)
+	return size, err
+}
+
+// RowCounts returns the number of rows of every table.
+func (db *DB) RowCounts() (map[string]int64, error) {
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(tables))
+	for _, table := range tables {
+		var count int64
+		if err := db.QueryRow(`SELECT COUNT(*) FROM "` + table + `"`).Scan(&count); err != nil {
+			return nil, err
+		}
+		counts[table] = count
+	}
+	return counts, nil
+}
+
+// rowCountInterval is how often RowCounter counts the rows. Counting scans
+// every table, which takes too long on a large database to do on every
+// scrape of the internal metrics.
+const rowCountInterval = 10 * time.Minute
+
+// RowCounter serves the row counts of a database from a cache refreshed in
+// the background, and its size, which is cheap to read, directly. It
+// implements telemetry.Storage.
+type RowCounter struct {
+	*DB
+	mu       sync.Mutex
+	counts   map[string]int64
+	err      error
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+func NewRowCounter(db *DB) *RowCounter {
+	return &RowCounter{
+		DB:       db,
+		counts:   map[string]int64{},
+		stopChan: make(chan struct{}),
+	}
+}
+
+func (rc *RowCounter) Start() error {
+	rc.wg.Add(1)
+	go func() {
+		defer rc.wg.Done()
+		ticker := time.NewTicker(rowCountInterval)
+		defer ticker.Stop()
+
+		rc.count()
+		for {
+			select {
+			case <-ticker.C:
+				rc.count()
+			case <-rc.stopChan:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (rc *RowCounter) Stop() {
+	close(rc.stopChan)
+	rc.wg.Wait()
+}
+
+func (rc *RowCounter) count() {
+	counts, err := rc.DB.RowCounts()
+	if err != nil {
+		logger.Error("Error counting rows", "error", err)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.err = err
+	if err == nil {
+		rc.counts = counts
+	}
+}
+
+// RowCounts returns the last counted rows of every table, empty until the
+// first count completes, and the error of the last count.
+func (rc *RowCounter) RowCounts() (map[string]int64, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	counts := make(map[string]int64, len(rc.counts))
+	for table, n := range rc.counts {
+		counts[table] = n
+	}
+	return counts, rc.err
+}
+
+// observeWrite records the latency of a write to table. It is deferred with
+// the write's named error result.
+func observeWrite(table string, start time.Time, err *error) {
+	telemetry.ObserveWrite(table, time.Since(start), *err)
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// APIToken is a token accepted by the API besides server.token. Only the
+// hash of the token is stored.
+type APIToken struct {
+	Name      string `json:"name"`
+	CreatedAt string `json:"createdAt"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+}
+
+func (db *DB) InitAPITokensTable() error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS api_tokens (
+			name TEXT PRIMARY KEY,
+			hash TEXT NOT NULL UNIQUE,
+			created_at TEXT NOT NULL,
+			expires_at TEXT NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating api_tokens table: %v", err)
+	}
+	return nil
+}
+
+// CreateAPIToken generates and stores a token. The token is returned only
+// here; it cannot be read back from the database. A zero expiresAt never
+// expires.
+func (db *DB) CreateAPIToken(name string, expiresAt time.Time) (string, APIToken, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", APIToken{}, fmt.Errorf("error generating token: %v", err)
+	}
+	token := hex.EncodeToString(secret)
+
+	t := APIToken{
+		Name:      name,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	if !expiresAt.IsZero() {
+		t.ExpiresAt = expiresAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	var exists bool
+	if err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM api_tokens WHERE name = ?)`, name).Scan(&exists); err != nil {
+		return "", APIToken{}, err
+	}
+	if exists {
+		return "", APIToken{}, fmt.Errorf("a token named %q already exists", name)
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO api_tokens (name, hash, created_at, expires_at)
+		VALUES (?, ?, ?, ?)
+	`, t.Name, hashToken(token), t.CreatedAt, t.ExpiresAt)
+	if err != nil {
+		return "", APIToken{}, err
+	}
+	return token, t, nil
+}
+
+func (db *DB) GetAPITokens() ([]APIToken, error) {
+	rows, err := db.Query(`SELECT name, created_at, expires_at FROM api_tokens ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []APIToken{}
+	for rows.Next() {
+		var t APIToken
+		if err := rows.Scan(&t.Name, &t.CreatedAt, &t.ExpiresAt); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+// DeleteAPIToken revokes a token, reporting whether it existed.
+func (db *DB) DeleteAPIToken(name string) (bool, error) {
+	result, err := db.Exec(`DELETE FROM api_tokens WHERE name = ?`, name)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// ValidAPIToken reports whether token is a stored token that has not
+// expired.
+func (db *DB) ValidAPIToken(token string) (bool, error) {
+	var expiresAt string
+	err := db.QueryRow(`SELECT expires_at FROM api_tokens WHERE hash = ?`, hashToken(token)).Scan(&expiresAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if expiresAt == "" {
+		return true, nil
+	}
+	expires, err := time.Parse(time.RFC3339Nano, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	return time.Now().Before(expires), nil
+}
+
+// The tokens are random, so a plain hash is enough to make a copy of the
+// database useless for authenticating.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,148 @@
+package exporters
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/logging"
+)
+
+var logger = logging.New("exporters")
+
+// Sample is a single value of a series handed to the exporters. Names follow
+// the Prometheus conventions (base units, _total for counters).
+type Sample struct {
+	Name      string            `json:"name"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Value     float64           `json:"value"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// Exporter forwards samples to an external system. Export is called from the
+// collectors and must not block on the network; it may block briefly to
+// apply backpressure when the exporter falls behind.
+type Exporter interface {
+	Name() string
+	Export(samples []Sample)
+	Stats() QueueStats
+	Stop()
+}
+
+var (
+	mu         sync.RWMutex
+	registered []Exporter
+	// commonLabels are added to every published sample.
+	commonLabels map[string]string
+)
+
+// Start creates the exporters enabled in the configuration. Collectors can
+// publish before Start is called; samples are dropped until then.
+func Start() error {
+	cfg := config.GetMetricsConfig().Exporters
+
+	labels := make(map[string]string, len(cfg.Labels)+1)
+	for k, v := range cfg.Labels {
+		labels[k] = v
+	}
+	if _, ok := labels["host"]; !ok {
+		if hostname, err := os.Hostname(); err == nil {
+			labels["host"] = hostname
+		}
+	}
+
+	var started []Exporter
+	if cfg.RemoteWrite.Enabled {
+		e, err := NewRemoteWriteExporter(cfg.RemoteWrite)
+		if err != nil {
+			return fmt.Errorf("error creating remote write exporter: %v", err)
+		}
+		started = append(started, e)
+	}
+	if cfg.OTLP.Enabled {
+		e, err := NewOTLPExporter(cfg.OTLP)
+		if err != nil {
+			return fmt.Errorf("error creating OTLP exporter: %v", err)
+		}
+		started = append(started, e)
+	}
+	if cfg.Influx.Enabled {
+		e, err := NewInfluxExporter(cfg.Influx)
+		if err != nil {
+			return fmt.Errorf("error creating InfluxDB exporter: %v", err)
+		}
+		started = append(started, e)
+	}
+	if cfg.Graphite.Enabled {
+		e, err := NewGraphiteExporter(cfg.Graphite)
+		if err != nil {
+			return fmt.Errorf("error creating Graphite exporter: %v", err)
+		}
+		started = append(started, e)
+	}
+
+	mu.Lock()
+	commonLabels = labels
+	registered = append(registered, started...)
+	mu.Unlock()
+
+	for _, e := range started {
+		logger.Info("Exporting metrics", "exporter", e.Name())
+	}
+	return nil
+}
+
+// Stop stops every exporter, persisting what they have not sent yet.
+func Stop() {
+	mu.Lock()
+	stopping := registered
+	registered = nil
+	mu.Unlock()
+
+	for _, e := range stopping {
+		e.Stop()
+	}
+}
+
+// Stats returns the queue statistics of every running exporter by name.
+func Stats() map[string]QueueStats {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	stats := make(map[string]QueueStats, len(registered))
+	for _, e := range registered {
+		stats[e.Name()] = e.Stats()
+	}
+	return stats
+}
+
+// Publish hands samples to every running exporter. Collectors publish once
+// per cycle: every call may block for the block timeout of each exporter
+// whose queue is full.
+func Publish(samples []Sample) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if len(registered) == 0 || len(samples) == 0 {
+		return
+	}
+
+	labeled := make([]Sample, len(samples))
+	for i, s := range samples {
+		labels := make(map[string]string, len(s.Labels)+len(commonLabels))
+		for k, v := range commonLabels {
+			labels[k] = v
+		}
+		for k, v := range s.Labels {
+			labels[k] = v
+		}
+		s.Labels = labels
+		labeled[i] = s
+	}
+
+	for _, e := range registered {
+		e.Export(labeled)
+	}
+}
@@ -0,0 +1,103 @@
+package exportertest
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/exporters"
+)
+
+// GraphiteReceiver is a TCP server accepting the Graphite plaintext protocol
+// and keeping the received samples. Tags of tagged paths become labels.
+type GraphiteReceiver struct {
+	listener net.Listener
+
+	mu      sync.Mutex
+	samples []exporters.Sample
+	lines   []string
+}
+
+func NewGraphiteReceiver() (*GraphiteReceiver, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	r := &GraphiteReceiver{listener: listener}
+	go r.accept()
+	return r, nil
+}
+
+// Addr returns the address to configure as exporters.graphite.address.
+func (r *GraphiteReceiver) Addr() string {
+	return r.listener.Addr().String()
+}
+
+func (r *GraphiteReceiver) Close() error {
+	return r.listener.Close()
+}
+
+// Samples returns the samples received so far.
+func (r *GraphiteReceiver) Samples() []exporters.Sample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]exporters.Sample(nil), r.samples...)
+}
+
+// Lines returns the raw lines received so far.
+func (r *GraphiteReceiver) Lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.lines...)
+}
+
+func (r *GraphiteReceiver) accept() {
+	for {
+		conn, err := r.listener.Accept()
+		if err != nil {
+			return
+		}
+		go r.handle(conn)
+	}
+}
+
+func (r *GraphiteReceiver) handle(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		secs, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		parts := strings.Split(fields[0], ";")
+		sample := exporters.Sample{Name: parts[0], Value: value, Timestamp: time.Unix(secs, 0).UTC()}
+		if len(parts) > 1 {
+			sample.Labels = make(map[string]string, len(parts)-1)
+			for _, tag := range parts[1:] {
+				if k, v, ok := strings.Cut(tag, "="); ok {
+					sample.Labels[k] = v
+				}
+			}
+		}
+
+		r.mu.Lock()
+		r.lines = append(r.lines, line)
+		r.samples = append(r.samples, sample)
+		r.mu.Unlock()
+	}
+}
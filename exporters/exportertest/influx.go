@@ -0,0 +1,92 @@
+package exportertest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/exporters"
+	"github.com/mauriciogm/dokploy/apps/monitoring/ingest"
+)
+
+// InfluxReceiver is an HTTP server accepting InfluxDB v1 (/write) and v2
+// (/api/v2/write) line protocol writes and keeping the decoded samples.
+type InfluxReceiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	samples  []exporters.Sample
+	requests int
+	failures []int
+}
+
+func NewInfluxReceiver() *InfluxReceiver {
+	r := &InfluxReceiver{}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.handle))
+	return r
+}
+
+// FailNext makes the next requests fail with the given status codes, in
+// order.
+func (r *InfluxReceiver) FailNext(statuses ...int) {
+	r.mu.Lock()
+	r.failures = append(r.failures, statuses...)
+	r.mu.Unlock()
+}
+
+// Samples returns the samples received so far.
+func (r *InfluxReceiver) Samples() []exporters.Sample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]exporters.Sample(nil), r.samples...)
+}
+
+// Requests returns the number of requests received, failed ones included.
+func (r *InfluxReceiver) Requests() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requests
+}
+
+func (r *InfluxReceiver) handle(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	r.requests++
+	if len(r.failures) > 0 {
+		status := r.failures[0]
+		r.failures = r.failures[1:]
+		r.mu.Unlock()
+		http.Error(w, "injected failure", status)
+		return
+	}
+	r.mu.Unlock()
+
+	if req.URL.Path != "/write" && req.URL.Path != "/api/v2/write" {
+		http.NotFound(w, req)
+		return
+	}
+	precision := req.URL.Query().Get("precision")
+	if precision == "" {
+		precision = "ns"
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	metrics, err := ingest.ParseLineProtocol(body, precision)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	for _, m := range metrics {
+		ts, _ := time.Parse(time.RFC3339Nano, m.Timestamp)
+		r.samples = append(r.samples, exporters.Sample{Name: m.Name, Labels: m.Labels, Value: m.Value, Timestamp: ts})
+	}
+	r.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,84 @@
+package exportertest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/exporters"
+)
+
+// OTLPReceiver is an HTTP server accepting OTLP/HTTP JSON metric exports on
+// /v1/metrics and keeping the decoded requests.
+type OTLPReceiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	received []exporters.OTLPRequest
+	requests int
+	failures []int
+}
+
+func NewOTLPReceiver() *OTLPReceiver {
+	r := &OTLPReceiver{}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.handle))
+	return r
+}
+
+// FailNext makes the next requests fail with the given status codes, in
+// order.
+func (r *OTLPReceiver) FailNext(statuses ...int) {
+	r.mu.Lock()
+	r.failures = append(r.failures, statuses...)
+	r.mu.Unlock()
+}
+
+// Received returns the export requests accepted so far.
+func (r *OTLPReceiver) Received() []exporters.OTLPRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]exporters.OTLPRequest(nil), r.received...)
+}
+
+// Requests returns the number of requests received, failed ones included.
+func (r *OTLPReceiver) Requests() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requests
+}
+
+func (r *OTLPReceiver) handle(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	r.requests++
+	if len(r.failures) > 0 {
+		status := r.failures[0]
+		r.failures = r.failures[1:]
+		r.mu.Unlock()
+		http.Error(w, "injected failure", status)
+		return
+	}
+	r.mu.Unlock()
+
+	if req.URL.Path != "/v1/metrics" {
+		http.NotFound(w, req)
+		return
+	}
+	if req.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "expected application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var export exporters.OTLPRequest
+	if err := json.NewDecoder(req.Body).Decode(&export); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	r.received = append(r.received, export)
+	r.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{}`))
+}
@@ -0,0 +1,201 @@
+// Package exportertest provides local receivers for the protocols spoken by
+// the exporters, to check what an exporter sends without a real backend.
+package exportertest
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/exporters"
+)
+
+// RemoteWriteReceiver is an HTTP server accepting Prometheus remote_write
+// requests and keeping the decoded samples.
+type RemoteWriteReceiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	samples  []exporters.Sample
+	requests int
+	failures []int
+}
+
+func NewRemoteWriteReceiver() *RemoteWriteReceiver {
+	r := &RemoteWriteReceiver{}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.handle))
+	return r
+}
+
+// FailNext makes the next requests fail with the given status codes, in
+// order.
+func (r *RemoteWriteReceiver) FailNext(statuses ...int) {
+	r.mu.Lock()
+	r.failures = append(r.failures, statuses...)
+	r.mu.Unlock()
+}
+
+// Samples returns the samples received so far.
+func (r *RemoteWriteReceiver) Samples() []exporters.Sample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]exporters.Sample(nil), r.samples...)
+}
+
+// Requests returns the number of requests received, failed ones included.
+func (r *RemoteWriteReceiver) Requests() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requests
+}
+
+func (r *RemoteWriteReceiver) handle(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	r.requests++
+	if len(r.failures) > 0 {
+		status := r.failures[0]
+		r.failures = r.failures[1:]
+		r.mu.Unlock()
+		http.Error(w, "injected failure", status)
+		return
+	}
+	r.mu.Unlock()
+
+	if req.Header.Get("Content-Encoding") != "snappy" || req.Header.Get("Content-Type") != "application/x-protobuf" {
+		http.Error(w, "expected snappy compressed protobuf", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	compressed, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	samples, err := DecodeWriteRequest(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	r.samples = append(r.samples, samples...)
+	r.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DecodeWriteRequest decodes a prometheus.WriteRequest protobuf message.
+func DecodeWriteRequest(data []byte) ([]exporters.Sample, error) {
+	var result []exporters.Sample
+	err := forEachField(data, func(field int, value []byte) error {
+		if field != 1 {
+			return nil
+		}
+		samples, err := decodeTimeSeries(value)
+		result = append(result, samples...)
+		return err
+	})
+	return result, err
+}
+
+func decodeTimeSeries(data []byte) ([]exporters.Sample, error) {
+	labels := make(map[string]string)
+	var samples []exporters.Sample
+
+	err := forEachField(data, func(field int, value []byte) error {
+		switch field {
+		case 1:
+			var name, val string
+			err := forEachField(value, func(field int, value []byte) error {
+				if field == 1 {
+					name = string(value)
+				} else if field == 2 {
+					val = string(value)
+				}
+				return nil
+			})
+			labels[name] = val
+			return err
+		case 2:
+			s, err := decodeSample(value)
+			samples = append(samples, s)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	name := labels["__name__"]
+	delete(labels, "__name__")
+	for i := range samples {
+		samples[i].Name = name
+		samples[i].Labels = labels
+	}
+	return samples, nil
+}
+
+func decodeSample(data []byte) (exporters.Sample, error) {
+	var s exporters.Sample
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return s, fmt.Errorf("invalid sample tag")
+		}
+		data = data[n:]
+		switch tag {
+		case 1<<3 | 1:
+			if len(data) < 8 {
+				return s, fmt.Errorf("truncated sample value")
+			}
+			s.Value = math.Float64frombits(binary.LittleEndian.Uint64(data))
+			data = data[8:]
+		case 2<<3 | 0:
+			ms, n := binary.Uvarint(data)
+			if n <= 0 {
+				return s, fmt.Errorf("invalid sample timestamp")
+			}
+			s.Timestamp = time.UnixMilli(int64(ms)).UTC()
+			data = data[n:]
+		default:
+			return s, fmt.Errorf("unexpected sample field %d", tag>>3)
+		}
+	}
+	return s, nil
+}
+
+// forEachField calls fn for every length-delimited field of a message.
+func forEachField(data []byte, fn func(field int, value []byte) error) error {
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("invalid tag")
+		}
+		data = data[n:]
+		if tag&7 != 2 {
+			return fmt.Errorf("unexpected wire type %d for field %d", tag&7, tag>>3)
+		}
+		length, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < length {
+			return fmt.Errorf("invalid length for field %d", tag>>3)
+		}
+		data = data[n:]
+		if err := fn(int(tag>>3), data[:length]); err != nil {
+			return err
+		}
+		data = data[length:]
+	}
+	return nil
+}
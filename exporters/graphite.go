@@ -0,0 +1,118 @@
+package exporters
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+)
+
+const (
+	defaultGraphiteTimeout = 10
+	defaultGraphiteWALDir  = "./wal/graphite"
+)
+
+var graphiteSanitizer = strings.NewReplacer(".", "_", " ", "_", ";", "_", "=", "_", "~", "_", "\n", "_")
+
+// GraphiteExporter writes samples with the Graphite plaintext protocol over
+// TCP, opening one connection per batch.
+type GraphiteExporter struct {
+	*queuedExporter
+	cfg     config.GraphiteConfig
+	timeout time.Duration
+}
+
+func NewGraphiteExporter(cfg config.GraphiteConfig) (*GraphiteExporter, error) {
+	if cfg.Address == "" {
+		return nil, fmt.Errorf("exporters.graphite.address is required")
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultGraphiteTimeout
+	}
+
+	e := &GraphiteExporter{
+		cfg:     cfg,
+		timeout: time.Duration(timeout) * time.Second,
+	}
+	q, err := newQueuedExporter("graphite", defaultGraphiteWALDir, cfg.Queue, e.send)
+	if err != nil {
+		return nil, err
+	}
+	e.queuedExporter = q
+	return e, nil
+}
+
+func (e *GraphiteExporter) send(ctx context.Context, samples []Sample) error {
+	data := EncodeGraphite(samples, e.cfg.Prefix, e.cfg.Tagged)
+
+	dialer := net.Dialer{Timeout: e.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", e.cfg.Address)
+	if err != nil {
+		return fmt.Errorf("error connecting to Graphite: %v", err)
+	}
+	defer conn.Close()
+
+	conn.SetWriteDeadline(time.Now().Add(e.timeout))
+	if _, err := conn.Write(data); err != nil {
+		return fmt.Errorf("error writing to Graphite: %v", err)
+	}
+	return nil
+}
+
+// EncodeGraphite formats samples as `path value <unix seconds>` lines. Plain
+// paths are prefix.host.container.name with the labels that exist; tagged
+// paths use the Graphite 1.1 `name;tag=value` syntax with every label.
+func EncodeGraphite(samples []Sample, prefix string, tagged bool) []byte {
+	var b bytes.Buffer
+	for _, s := range samples {
+		if tagged {
+			name := s.Name
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+			b.WriteString(name)
+
+			keys := make([]string, 0, len(s.Labels))
+			for k := range s.Labels {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if s.Labels[k] == "" {
+					continue
+				}
+				b.WriteByte(';')
+				b.WriteString(graphiteSanitizer.Replace(k))
+				b.WriteByte('=')
+				b.WriteString(graphiteSanitizer.Replace(s.Labels[k]))
+			}
+		} else {
+			var parts []string
+			if prefix != "" {
+				parts = append(parts, prefix)
+			}
+			for _, k := range []string{"host", "container"} {
+				if v := s.Labels[k]; v != "" {
+					parts = append(parts, graphiteSanitizer.Replace(v))
+				}
+			}
+			parts = append(parts, s.Name)
+			b.WriteString(strings.Join(parts, "."))
+		}
+
+		b.WriteByte(' ')
+		b.WriteString(strconv.FormatFloat(s.Value, 'f', -1, 64))
+		b.WriteByte(' ')
+		b.WriteString(strconv.FormatInt(s.Timestamp.Unix(), 10))
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,82 @@
+package exporters_test
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/exporters"
+	"github.com/mauriciogm/dokploy/apps/monitoring/exporters/exportertest"
+)
+
+func TestGraphiteExporter(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		prefix string
+		tagged bool
+		want   []string
+	}{
+		{
+			name:   "plain",
+			prefix: "dokploy",
+			want: []string{
+				"dokploy.web-1.app.container_cpu_percent 12.5 1760522400",
+				"dokploy.web-1.app.container_cpu_percent 13.25 1760522401",
+				"dokploy.web-1.node_memory_used_bytes 2147483648 1760522400",
+			},
+		},
+		{
+			name:   "tagged",
+			tagged: true,
+			want: []string{
+				"container_cpu_percent;container=app;host=web-1 12.5 1760522400",
+				"container_cpu_percent;container=app;host=web-1 13.25 1760522401",
+				"node_memory_used_bytes;host=web-1 2147483648 1760522400",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			receiver, err := exportertest.NewGraphiteReceiver()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer receiver.Close()
+
+			e, err := exporters.NewGraphiteExporter(config.GraphiteConfig{
+				Enabled: true,
+				Address: receiver.Addr(),
+				Prefix:  tc.prefix,
+				Tagged:  tc.tagged,
+				Queue:   config.QueueConfig{FlushInterval: 1, WALDir: t.TempDir()},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer e.Stop()
+
+			e.Export(testSamples())
+			if !waitFor(t, 5*time.Second, func() bool { return len(receiver.Lines()) == len(tc.want) }) {
+				t.Fatalf("received %d lines, want %d", len(receiver.Lines()), len(tc.want))
+			}
+			// Shards send concurrently, so only the order within a series is
+			// kept.
+			lines := receiver.Lines()
+			sort.Strings(lines)
+			if !reflect.DeepEqual(lines, tc.want) {
+				t.Errorf("received\n%q\nwant\n%q", lines, tc.want)
+			}
+		})
+	}
+}
+
+func TestEncodeGraphiteSanitizesPaths(t *testing.T) {
+	samples := []exporters.Sample{
+		{Name: "cpu", Labels: map[string]string{"host": "web.example.com", "container": "my app"}, Value: 0.5, Timestamp: time.Unix(1760522400, 0)},
+	}
+	want := "web_example_com.my_app.cpu 0.5 1760522400\n"
+	if got := string(exporters.EncodeGraphite(samples, "", false)); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
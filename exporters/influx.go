@@ -0,0 +1,132 @@
+package exporters
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+)
+
+const (
+	defaultInfluxTimeout = 30
+	defaultInfluxWALDir  = "./wal/influx"
+)
+
+var (
+	influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	influxTagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+// InfluxExporter writes samples in InfluxDB line protocol, one measurement
+// per sample name with a single value field. It uses the v2 write API when a
+// bucket is configured and the v1 API otherwise.
+type InfluxExporter struct {
+	*queuedExporter
+	cfg      config.InfluxConfig
+	client   *http.Client
+	writeURL string
+}
+
+func NewInfluxExporter(cfg config.InfluxConfig) (*InfluxExporter, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("exporters.influx.url is required")
+	}
+
+	query := url.Values{}
+	query.Set("precision", "ms")
+	var path string
+	switch {
+	case cfg.Bucket != "":
+		path = "/api/v2/write"
+		query.Set("org", cfg.Org)
+		query.Set("bucket", cfg.Bucket)
+	case cfg.Database != "":
+		path = "/write"
+		query.Set("db", cfg.Database)
+	default:
+		return nil, fmt.Errorf("exporters.influx.bucket or exporters.influx.database is required")
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultInfluxTimeout
+	}
+
+	e := &InfluxExporter{
+		cfg:      cfg,
+		client:   &http.Client{Timeout: time.Duration(timeout) * time.Second},
+		writeURL: strings.TrimSuffix(cfg.URL, "/") + path + "?" + query.Encode(),
+	}
+	q, err := newQueuedExporter("influx", defaultInfluxWALDir, cfg.Queue, e.send)
+	if err != nil {
+		return nil, err
+	}
+	e.queuedExporter = q
+	return e, nil
+}
+
+func (e *InfluxExporter) send(ctx context.Context, samples []Sample) error {
+	cfg := e.cfg
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.writeURL, bytes.NewReader(EncodeLineProtocol(samples)))
+	if err != nil {
+		return permanent(err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("User-Agent", "dokploy-monitoring")
+	if cfg.Token != "" {
+		req.Header.Set("Authorization", "Token "+cfg.Token)
+	} else if cfg.Username != "" {
+		req.SetBasicAuth(cfg.Username, cfg.Password)
+	}
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("InfluxDB request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp)
+}
+
+// EncodeLineProtocol formats samples as `name,tag=value value=<v> <ms>`
+// lines with millisecond timestamps.
+func EncodeLineProtocol(samples []Sample) []byte {
+	var b bytes.Buffer
+	for _, s := range samples {
+		b.WriteString(influxMeasurementEscaper.Replace(s.Name))
+
+		keys := make([]string, 0, len(s.Labels))
+		for k := range s.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			// Empty tag values are not allowed by the protocol.
+			if s.Labels[k] == "" {
+				continue
+			}
+			b.WriteByte(',')
+			b.WriteString(influxTagEscaper.Replace(k))
+			b.WriteByte('=')
+			b.WriteString(influxTagEscaper.Replace(s.Labels[k]))
+		}
+
+		b.WriteString(" value=")
+		b.WriteString(strconv.FormatFloat(s.Value, 'g', -1, 64))
+		b.WriteByte(' ')
+		b.WriteString(strconv.FormatInt(s.Timestamp.UnixMilli(), 10))
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
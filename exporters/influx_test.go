@@ -0,0 +1,64 @@
+package exporters_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/exporters"
+	"github.com/mauriciogm/dokploy/apps/monitoring/exporters/exportertest"
+)
+
+func TestEncodeLineProtocol(t *testing.T) {
+	samples := []exporters.Sample{
+		{Name: "container_cpu_percent", Labels: map[string]string{"host": "web-1", "container": "my app", "empty": ""}, Value: 12.5, Timestamp: time.UnixMilli(1760522400123)},
+		{Name: "requests total", Labels: map[string]string{"path": "a,b=c"}, Value: 3, Timestamp: time.UnixMilli(1760522401000)},
+	}
+	want := strings.Join([]string{
+		`container_cpu_percent,container=my\ app,host=web-1 value=12.5 1760522400123`,
+		`requests\ total,path=a\,b\=c value=3 1760522401000`,
+		``,
+	}, "\n")
+	if got := string(exporters.EncodeLineProtocol(samples)); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestInfluxExporter(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  config.InfluxConfig
+	}{
+		{"v1", config.InfluxConfig{Database: "metrics"}},
+		{"v2", config.InfluxConfig{Org: "dokploy", Bucket: "metrics", Token: "secret"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			receiver := exportertest.NewInfluxReceiver()
+			defer receiver.Close()
+
+			cfg := tc.cfg
+			cfg.Enabled = true
+			cfg.URL = receiver.URL
+			cfg.Queue = config.QueueConfig{FlushInterval: 1, WALDir: t.TempDir()}
+			e, err := exporters.NewInfluxExporter(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer e.Stop()
+
+			e.Export(testSamples())
+			if !waitFor(t, 5*time.Second, func() bool { return len(receiver.Samples()) == 3 }) {
+				t.Fatalf("received %d samples, want 3", len(receiver.Samples()))
+			}
+			checkSamples(t, receiver.Samples(), testSamples())
+		})
+	}
+}
+
+func TestInfluxExporterRequiresTarget(t *testing.T) {
+	_, err := exporters.NewInfluxExporter(config.InfluxConfig{URL: "http://localhost:8086", Queue: config.QueueConfig{WALDir: t.TempDir()}})
+	if err == nil {
+		t.Fatal("got no error without bucket or database")
+	}
+}
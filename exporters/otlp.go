@@ -0,0 +1,283 @@
+package exporters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+)
+
+const (
+	defaultOTLPTimeout = 30
+	defaultOTLPWALDir  = "./wal/otlp"
+	otlpScopeName      = "github.com/mauriciogm/dokploy/apps/monitoring"
+
+	// OTLP aggregation temporality.
+	temporalityCumulative = 2
+)
+
+// otlpMetric describes how a sample maps to an OpenTelemetry semantic
+// convention metric.
+type otlpMetric struct {
+	name  string
+	unit  string
+	scale float64
+	// sum metrics are reported as cumulative sums, the rest as gauges.
+	sum        bool
+	monotonic  bool
+	attributes map[string]string
+}
+
+// otlpMetrics maps sample names to semantic convention metrics. Percentages
+// become ratios. Samples missing here, such as the network rates that
+// backends derive from system.network.io, are not exported.
+var otlpMetrics = map[string]otlpMetric{
+	"server_cpu_usage_percent":            {name: "system.cpu.utilization", unit: "1", scale: 0.01},
+	"server_cpu_cores":                    {name: "system.cpu.logical.count", unit: "{cpu}", scale: 1, sum: true},
+	"server_memory_usage_percent":         {name: "system.memory.utilization", unit: "1", scale: 0.01, attributes: map[string]string{"system.memory.state": "used"}},
+	"server_memory_used_bytes":            {name: "system.memory.usage", unit: "By", scale: 1, sum: true, attributes: map[string]string{"system.memory.state": "used"}},
+	"server_memory_total_bytes":           {name: "system.memory.limit", unit: "By", scale: 1, sum: true},
+	"server_disk_usage_percent":           {name: "system.filesystem.utilization", unit: "1", scale: 0.01, attributes: map[string]string{"system.filesystem.mountpoint": "/"}},
+	"server_disk_total_bytes":             {name: "system.filesystem.limit", unit: "By", scale: 1, sum: true, attributes: map[string]string{"system.filesystem.mountpoint": "/"}},
+	"server_network_receive_bytes_total":  {name: "system.network.io", unit: "By", scale: 1, sum: true, monotonic: true, attributes: map[string]string{"network.io.direction": "receive"}},
+	"server_network_transmit_bytes_total": {name: "system.network.io", unit: "By", scale: 1, sum: true, monotonic: true, attributes: map[string]string{"network.io.direction": "transmit"}},
+	"server_uptime_seconds":               {name: "system.uptime", unit: "s", scale: 1},
+
+	"container_cpu_usage_percent":            {name: "container.cpu.usage", unit: "{cpu}", scale: 0.01},
+	"container_memory_usage_percent":         {name: "container.memory.percent", unit: "%", scale: 1},
+	"container_memory_used_bytes":            {name: "container.memory.usage", unit: "By", scale: 1, sum: true},
+	"container_network_receive_bytes_total":  {name: "container.network.io", unit: "By", scale: 1, sum: true, monotonic: true, attributes: map[string]string{"network.io.direction": "receive"}},
+	"container_network_transmit_bytes_total": {name: "container.network.io", unit: "By", scale: 1, sum: true, monotonic: true, attributes: map[string]string{"network.io.direction": "transmit"}},
+	"container_block_read_bytes_total":       {name: "container.disk.io", unit: "By", scale: 1, sum: true, monotonic: true, attributes: map[string]string{"disk.io.direction": "read"}},
+	"container_block_write_bytes_total":      {name: "container.disk.io", unit: "By", scale: 1, sum: true, monotonic: true, attributes: map[string]string{"disk.io.direction": "write"}},
+}
+
+// otlpResourceKeys renames sample labels to resource attributes. Labels not
+// listed keep their name.
+var otlpResourceKeys = map[string]string{
+	"host":         "host.name",
+	"container":    "container.name",
+	"container_id": "container.id",
+	"service":      "dokploy.service",
+}
+
+// OTLPExporter sends samples to an OpenTelemetry collector with OTLP/HTTP
+// using the JSON encoding.
+type OTLPExporter struct {
+	*queuedExporter
+	cfg      config.OTLPConfig
+	client   *http.Client
+	endpoint string
+	started  time.Time
+}
+
+func NewOTLPExporter(cfg config.OTLPConfig) (*OTLPExporter, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("exporters.otlp.endpoint is required")
+	}
+
+	endpoint := strings.TrimSuffix(cfg.Endpoint, "/")
+	if !strings.HasSuffix(endpoint, "/v1/metrics") {
+		endpoint += "/v1/metrics"
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultOTLPTimeout
+	}
+
+	e := &OTLPExporter{
+		cfg:      cfg,
+		client:   &http.Client{Timeout: time.Duration(timeout) * time.Second},
+		endpoint: endpoint,
+		started:  time.Now(),
+	}
+	q, err := newQueuedExporter("otlp", defaultOTLPWALDir, cfg.Queue, e.send)
+	if err != nil {
+		return nil, err
+	}
+	e.queuedExporter = q
+	return e, nil
+}
+
+func (e *OTLPExporter) send(ctx context.Context, samples []Sample) error {
+	body, err := json.Marshal(EncodeOTLP(samples, e.started))
+	if err != nil {
+		return permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dokploy-monitoring")
+	for k, v := range e.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("OTLP request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp)
+}
+
+// OTLP JSON messages, limited to the fields used by the exporter.
+
+type OTLPRequest struct {
+	ResourceMetrics []OTLPResourceMetrics `json:"resourceMetrics"`
+}
+
+type OTLPResourceMetrics struct {
+	Resource struct {
+		Attributes []OTLPAttribute `json:"attributes"`
+	} `json:"resource"`
+	ScopeMetrics []OTLPScopeMetrics `json:"scopeMetrics"`
+}
+
+type OTLPScopeMetrics struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Metrics []OTLPMetric `json:"metrics"`
+}
+
+type OTLPMetric struct {
+	Name  string     `json:"name"`
+	Unit  string     `json:"unit"`
+	Gauge *OTLPGauge `json:"gauge,omitempty"`
+	Sum   *OTLPSum   `json:"sum,omitempty"`
+}
+
+type OTLPGauge struct {
+	DataPoints []OTLPDataPoint `json:"dataPoints"`
+}
+
+type OTLPSum struct {
+	AggregationTemporality int             `json:"aggregationTemporality"`
+	IsMonotonic            bool            `json:"isMonotonic"`
+	DataPoints             []OTLPDataPoint `json:"dataPoints"`
+}
+
+type OTLPDataPoint struct {
+	Attributes        []OTLPAttribute `json:"attributes,omitempty"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	AsDouble          float64         `json:"asDouble"`
+}
+
+type OTLPAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+// EncodeOTLP groups samples by resource and metric into an OTLP export
+// request. Cumulative sums start at started.
+func EncodeOTLP(samples []Sample, started time.Time) OTLPRequest {
+	type resourceEntry struct {
+		resource *OTLPResourceMetrics
+		metrics  map[string]*OTLPMetric
+		order    []string
+	}
+
+	resources := make(map[string]*resourceEntry)
+	var order []string
+
+	for _, s := range samples {
+		m, ok := otlpMetrics[s.Name]
+		if !ok {
+			continue
+		}
+
+		attrs := make(map[string]string, len(s.Labels))
+		for k, v := range s.Labels {
+			if renamed, ok := otlpResourceKeys[k]; ok {
+				k = renamed
+			}
+			attrs[k] = v
+		}
+		attributes := otlpAttributes(attrs)
+		key := resourceKey(attributes)
+
+		entry, ok := resources[key]
+		if !ok {
+			rm := &OTLPResourceMetrics{ScopeMetrics: []OTLPScopeMetrics{{}}}
+			rm.Resource.Attributes = attributes
+			rm.ScopeMetrics[0].Scope.Name = otlpScopeName
+			entry = &resourceEntry{resource: rm, metrics: make(map[string]*OTLPMetric)}
+			resources[key] = entry
+			order = append(order, key)
+		}
+
+		metric, ok := entry.metrics[m.name]
+		if !ok {
+			metric = &OTLPMetric{Name: m.name, Unit: m.unit}
+			if m.sum {
+				metric.Sum = &OTLPSum{AggregationTemporality: temporalityCumulative, IsMonotonic: m.monotonic}
+			} else {
+				metric.Gauge = &OTLPGauge{}
+			}
+			entry.metrics[m.name] = metric
+			entry.order = append(entry.order, m.name)
+		}
+
+		point := OTLPDataPoint{
+			Attributes:   otlpAttributes(m.attributes),
+			TimeUnixNano: strconv.FormatInt(s.Timestamp.UnixNano(), 10),
+			AsDouble:     s.Value * m.scale,
+		}
+		if metric.Sum != nil {
+			point.StartTimeUnixNano = strconv.FormatInt(started.UnixNano(), 10)
+			metric.Sum.DataPoints = append(metric.Sum.DataPoints, point)
+		} else {
+			metric.Gauge.DataPoints = append(metric.Gauge.DataPoints, point)
+		}
+	}
+
+	request := OTLPRequest{ResourceMetrics: []OTLPResourceMetrics{}}
+	for _, key := range order {
+		entry := resources[key]
+		for _, name := range entry.order {
+			entry.resource.ScopeMetrics[0].Metrics = append(entry.resource.ScopeMetrics[0].Metrics, *entry.metrics[name])
+		}
+		request.ResourceMetrics = append(request.ResourceMetrics, *entry.resource)
+	}
+	return request
+}
+
+func otlpAttributes(values map[string]string) []OTLPAttribute {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attributes := make([]OTLPAttribute, len(keys))
+	for i, k := range keys {
+		attributes[i].Key = k
+		attributes[i].Value.StringValue = values[k]
+	}
+	return attributes
+}
+
+func resourceKey(attributes []OTLPAttribute) string {
+	var b strings.Builder
+	for _, a := range attributes {
+		b.WriteString(a.Key)
+		b.WriteByte(0)
+		b.WriteString(a.Value.StringValue)
+		b.WriteByte(0)
+	}
+	return b.String()
+}
@@ -0,0 +1,435 @@
+package exporters
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+)
+
+const (
+	defaultShards        = 2
+	defaultBatchSize     = 500
+	defaultFlushInterval = 10
+	defaultMaxRetries    = 5
+	defaultMaxSegments   = 1000
+	defaultCapacity      = 100
+	defaultBlockTimeout  = 1000
+
+	minBackoff = time.Second
+	maxBackoff = 30 * time.Second
+)
+
+// sendFunc delivers one batch. Errors wrapped with permanent are not retried.
+type sendFunc func(ctx context.Context, samples []Sample) error
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// checkResponse classifies a response: 5xx and 429 are retried, any other
+// non-2xx status is permanent.
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err := fmt.Errorf("endpoint returned %s: %s", resp.Status, bytes.TrimSpace(body))
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return err
+	}
+	return permanent(err)
+}
+
+type QueueStats struct {
+	PendingSegments int   `json:"pendingSegments"`
+	SentSamples     int64 `json:"sentSamples"`
+	FailedBatches   int64 `json:"failedBatches"`
+	RejectedSamples int64 `json:"rejectedSamples"`
+	DroppedSamples  int64 `json:"droppedSamples"`
+}
+
+// queue buffers exported samples in a WAL and delivers closed segments in
+// order. Each segment is split into shards by series, so samples of one
+// series are always sent in order by the same shard, and shards are sent
+// concurrently with exponential backoff. A segment is removed once every
+// shard has been delivered or rejected permanently; otherwise it is retried
+// as a whole on the next flush, which may resend the shards that succeeded.
+//
+// Samples reach the WAL through a buffer of capacity batches. Once the WAL
+// holds maxSegments undelivered segments the writer waits for deliveries,
+// the buffer fills up and enqueue blocks the collectors for at most
+// blockTimeout before dropping samples.
+type queue struct {
+	name         string
+	wal          *wal
+	send         sendFunc
+	shards       int
+	batchSize    int
+	retries      int
+	interval     time.Duration
+	blockTimeout time.Duration
+
+	pending   chan []Sample
+	flushChan chan struct{}
+	spaceChan chan struct{}
+	stopChan  chan struct{}
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	sent     int64
+	failed   int64
+	rejected int64
+	dropped  int64
+}
+
+func newQueue(name, defaultWALDir string, cfg config.QueueConfig, send sendFunc) (*queue, error) {
+	dir := cfg.WALDir
+	if dir == "" {
+		dir = defaultWALDir
+	}
+	maxSegments := cfg.MaxSegments
+	if maxSegments <= 0 {
+		maxSegments = defaultMaxSegments
+	}
+	capacity := cfg.Capacity
+	if capacity <= 0 {
+		capacity = defaultCapacity
+	}
+	blockTimeout := cfg.BlockTimeout
+	if blockTimeout <= 0 {
+		blockTimeout = defaultBlockTimeout
+	}
+
+	w, err := openWAL(dir, maxSegments)
+	if err != nil {
+		return nil, fmt.Errorf("error opening WAL %s: %v", dir, err)
+	}
+
+	q := &queue{
+		name:         name,
+		wal:          w,
+		send:         send,
+		shards:       cfg.Shards,
+		batchSize:    cfg.BatchSize,
+		retries:      cfg.MaxRetries,
+		interval:     time.Duration(cfg.FlushInterval) * time.Second,
+		blockTimeout: time.Duration(blockTimeout) * time.Millisecond,
+		pending:      make(chan []Sample, capacity),
+		flushChan:    make(chan struct{}, 1),
+		spaceChan:    make(chan struct{}, 1),
+		stopChan:     make(chan struct{}),
+	}
+	if q.shards <= 0 {
+		q.shards = defaultShards
+	}
+	if q.batchSize <= 0 {
+		q.batchSize = defaultBatchSize
+	}
+	if q.retries <= 0 {
+		q.retries = defaultMaxRetries
+	}
+	if q.interval <= 0 {
+		q.interval = defaultFlushInterval * time.Second
+	}
+	q.ctx, q.cancel = context.WithCancel(context.Background())
+
+	q.wg.Add(2)
+	go q.write()
+	go q.run()
+	return q, nil
+}
+
+// enqueue hands samples to the WAL writer, waiting up to blockTimeout when
+// the buffer is full.
+func (q *queue) enqueue(samples []Sample) {
+	select {
+	case q.pending <- samples:
+		return
+	case <-q.stopChan:
+		return
+	default:
+	}
+
+	timer := time.NewTimer(q.blockTimeout)
+	defer timer.Stop()
+
+	select {
+	case q.pending <- samples:
+	case <-q.stopChan:
+	case <-timer.C:
+		atomic.AddInt64(&q.dropped, int64(len(samples)))
+		logger.Warn("Export queue is full, dropping samples", "exporter", q.name, "samples", len(samples))
+	}
+}
+
+func (q *queue) stats() QueueStats {
+	return QueueStats{
+		PendingSegments: q.wal.pending(),
+		SentSamples:     atomic.LoadInt64(&q.sent),
+		FailedBatches:   atomic.LoadInt64(&q.failed),
+		RejectedSamples: atomic.LoadInt64(&q.rejected),
+		DroppedSamples:  atomic.LoadInt64(&q.dropped),
+	}
+}
+
+// stop writes the buffered samples to the WAL, closes the open segment and
+// waits for the delivery in progress. Undelivered segments are sent after
+// the next start.
+func (q *queue) stop() {
+	q.stopOnce.Do(func() {
+		close(q.stopChan)
+		q.cancel()
+		q.wg.Wait()
+		if err := q.wal.rotate(); err != nil {
+			logger.Error("Error closing WAL", "exporter", q.name, "error", err)
+		}
+	})
+}
+
+// write appends the buffered samples to the WAL, waiting while it is full.
+func (q *queue) write() {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case samples := <-q.pending:
+			q.waitForSpace()
+			q.append(samples)
+		case <-q.stopChan:
+			for {
+				select {
+				case samples := <-q.pending:
+					q.append(samples)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (q *queue) append(samples []Sample) {
+	count, err := q.wal.append(samples)
+	if err != nil {
+		logger.Error("Error writing WAL", "exporter", q.name, "error", err)
+		return
+	}
+	if count >= q.batchSize {
+		select {
+		case q.flushChan <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (q *queue) waitForSpace() {
+	for q.wal.full() {
+		select {
+		case <-q.spaceChan:
+		case <-time.After(time.Second):
+		case <-q.stopChan:
+			return
+		}
+	}
+}
+
+func (q *queue) run() {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(q.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-q.flushChan:
+		case <-q.stopChan:
+			return
+		}
+
+		// Keep filling the open segment while the WAL is full, so it is not
+		// rotated into an extra segment.
+		if !q.wal.full() {
+			if err := q.wal.rotate(); err != nil {
+				logger.Error("Error rotating WAL", "exporter", q.name, "error", err)
+				continue
+			}
+		}
+		q.drain()
+	}
+}
+
+// drain sends the closed segments in order, stopping at the first one that
+// could not be delivered.
+func (q *queue) drain() {
+	segments, err := q.wal.closed()
+	if err != nil {
+		logger.Error("Error reading WAL", "exporter", q.name, "error", err)
+		return
+	}
+
+	for _, segment := range segments {
+		samples, err := readSegment(segment)
+		if err != nil {
+			logger.Error("Error reading WAL segment", "exporter", q.name, "error", err)
+			return
+		}
+		if err := q.sendSharded(samples); err != nil {
+			if q.ctx.Err() == nil {
+				atomic.AddInt64(&q.failed, 1)
+				logger.Warn("Error sending batch, will retry", "exporter", q.name, "error", err)
+			}
+			return
+		}
+		if err := q.wal.remove(segment); err != nil {
+			logger.Error("Error removing WAL segment", "exporter", q.name, "error", err)
+			return
+		}
+		select {
+		case q.spaceChan <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (q *queue) sendSharded(samples []Sample) error {
+	shards := make([][]Sample, q.shards)
+	for _, s := range samples {
+		i := seriesHash(s) % uint64(q.shards)
+		shards[i] = append(shards[i], s)
+	}
+
+	errs := make([]error, q.shards)
+	var wg sync.WaitGroup
+	for i, shard := range shards {
+		if len(shard) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, shard []Sample) {
+			defer wg.Done()
+			for start := 0; start < len(shard); start += q.batchSize {
+				end := start + q.batchSize
+				if end > len(shard) {
+					end = len(shard)
+				}
+				if err := q.sendWithRetry(shard[start:end]); err != nil {
+					errs[i] = err
+					return
+				}
+			}
+		}(i, shard)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *queue) sendWithRetry(batch []Sample) error {
+	backoff := minBackoff
+	var err error
+	for attempt := 0; attempt <= q.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-q.ctx.Done():
+				return q.ctx.Err()
+			}
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+
+		err = q.send(q.ctx, batch)
+		if err == nil {
+			atomic.AddInt64(&q.sent, int64(len(batch)))
+			return nil
+		}
+		var perm permanentError
+		if errors.As(err, &perm) {
+			atomic.AddInt64(&q.rejected, int64(len(batch)))
+			logger.Error("Dropping rejected samples", "exporter", q.name, "samples", len(batch), "error", err)
+			return nil
+		}
+	}
+	return err
+}
+
+// queuedExporter implements Exporter on top of a queue, leaving only the
+// delivery of a batch to each protocol.
+type queuedExporter struct {
+	name  string
+	queue *queue
+}
+
+func newQueuedExporter(name, defaultWALDir string, cfg config.QueueConfig, send sendFunc) (*queuedExporter, error) {
+	q, err := newQueue(name, defaultWALDir, cfg, send)
+	if err != nil {
+		return nil, err
+	}
+	return &queuedExporter{name: name, queue: q}, nil
+}
+
+func (e *queuedExporter) Name() string {
+	return e.name
+}
+
+func (e *queuedExporter) Export(samples []Sample) {
+	e.queue.enqueue(samples)
+}
+
+func (e *queuedExporter) Stats() QueueStats {
+	return e.queue.stats()
+}
+
+func (e *queuedExporter) Stop() {
+	e.queue.stop()
+}
+
+// seriesHash identifies the series of a sample by its name and labels.
+func seriesHash(s Sample) uint64 {
+	keys := make([]string, 0, len(s.Labels))
+	for k := range s.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := fnv.New64a()
+	h.Write([]byte(s.Name))
+	for _, k := range keys {
+		h.Write([]byte{0})
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write([]byte(s.Labels[k]))
+	}
+	return h.Sum64()
+}
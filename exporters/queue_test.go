@@ -0,0 +1,67 @@
+package exporters_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/exporters"
+	"github.com/mauriciogm/dokploy/apps/monitoring/exporters/exportertest"
+)
+
+func TestQueueDropsSamplesWhenFull(t *testing.T) {
+	receiver := exportertest.NewInfluxReceiver()
+	defer receiver.Close()
+	failures := make([]int, 100)
+	for i := range failures {
+		failures[i] = http.StatusServiceUnavailable
+	}
+	receiver.FailNext(failures...)
+
+	// Every batch closes a segment that can't be delivered, so the WAL is
+	// full after the first one and the buffer after the next.
+	blockTimeout := 50 * time.Millisecond
+	e, err := exporters.NewInfluxExporter(config.InfluxConfig{
+		Enabled:  true,
+		URL:      receiver.URL,
+		Database: "metrics",
+		Queue: config.QueueConfig{
+			BatchSize:    1,
+			MaxRetries:   1,
+			MaxSegments:  1,
+			Capacity:     1,
+			BlockTimeout: int(blockTimeout / time.Millisecond),
+			WALDir:       t.TempDir(),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Stop()
+
+	samples := testSamples()
+	var slowest time.Duration
+	for i := 0; i < 50 && e.Stats().DroppedSamples == 0; i++ {
+		start := time.Now()
+		e.Export(samples)
+		if d := time.Since(start); d > slowest {
+			slowest = d
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	stats := e.Stats()
+	if stats.DroppedSamples == 0 {
+		t.Fatalf("no samples dropped with a full queue, stats %+v", stats)
+	}
+	if stats.DroppedSamples%int64(len(samples)) != 0 {
+		t.Errorf("dropped %d samples, want whole batches of %d", stats.DroppedSamples, len(samples))
+	}
+	if slowest < blockTimeout || slowest > blockTimeout+time.Second {
+		t.Errorf("Export blocked up to %s, want about the block timeout of %s", slowest, blockTimeout)
+	}
+	if stats.SentSamples != 0 {
+		t.Errorf("sent %d samples to a failing receiver", stats.SentSamples)
+	}
+}
@@ -0,0 +1,149 @@
+package exporters
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+)
+
+const (
+	defaultRemoteWriteTimeout = 30
+	defaultRemoteWriteWALDir  = "./wal/remote-write"
+)
+
+// RemoteWriteExporter sends samples with the Prometheus remote_write 1.0
+// protocol: a snappy compressed protobuf WriteRequest per batch.
+type RemoteWriteExporter struct {
+	*queuedExporter
+	cfg    config.RemoteWriteConfig
+	client *http.Client
+}
+
+func NewRemoteWriteExporter(cfg config.RemoteWriteConfig) (*RemoteWriteExporter, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("exporters.remoteWrite.url is required")
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultRemoteWriteTimeout
+	}
+
+	e := &RemoteWriteExporter{
+		cfg:    cfg,
+		client: &http.Client{Timeout: time.Duration(timeout) * time.Second},
+	}
+	q, err := newQueuedExporter("remote_write", defaultRemoteWriteWALDir, cfg.Queue, e.send)
+	if err != nil {
+		return nil, err
+	}
+	e.queuedExporter = q
+	return e, nil
+}
+
+func (e *RemoteWriteExporter) send(ctx context.Context, samples []Sample) error {
+	cfg := e.cfg
+	body := snappy.Encode(nil, EncodeWriteRequest(samples))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	req.Header.Set("User-Agent", "dokploy-monitoring")
+	if cfg.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.BearerToken)
+	} else if cfg.Username != "" {
+		req.SetBasicAuth(cfg.Username, cfg.Password)
+	}
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("remote write request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp)
+}
+
+// EncodeWriteRequest encodes samples as a prometheus.WriteRequest protobuf
+// message, grouping them into one TimeSeries per series.
+func EncodeWriteRequest(samples []Sample) []byte {
+	type series struct {
+		labels  [][2]string
+		samples []Sample
+	}
+
+	index := make(map[uint64]*series)
+	var order []uint64
+	for _, s := range samples {
+		h := seriesHash(s)
+		ts, ok := index[h]
+		if !ok {
+			labels := make([][2]string, 0, len(s.Labels)+1)
+			labels = append(labels, [2]string{"__name__", s.Name})
+			for k, v := range s.Labels {
+				labels = append(labels, [2]string{k, v})
+			}
+			sort.Slice(labels, func(i, j int) bool { return labels[i][0] < labels[j][0] })
+			ts = &series{labels: labels}
+			index[h] = ts
+			order = append(order, h)
+		}
+		ts.samples = append(ts.samples, s)
+	}
+
+	var out []byte
+	for _, h := range order {
+		ts := index[h]
+		sort.SliceStable(ts.samples, func(i, j int) bool {
+			return ts.samples[i].Timestamp.Before(ts.samples[j].Timestamp)
+		})
+
+		var msg []byte
+		for _, l := range ts.labels {
+			var label []byte
+			label = appendString(label, 1, l[0])
+			label = appendString(label, 2, l[1])
+			msg = appendBytes(msg, 1, label)
+		}
+		for _, s := range ts.samples {
+			var sample []byte
+			sample = appendTag(sample, 1, 1)
+			sample = binary.LittleEndian.AppendUint64(sample, math.Float64bits(s.Value))
+			sample = appendTag(sample, 2, 0)
+			sample = binary.AppendUvarint(sample, uint64(s.Timestamp.UnixMilli()))
+			msg = appendBytes(msg, 2, sample)
+		}
+		out = appendBytes(out, 1, msg)
+	}
+	return out
+}
+
+func appendTag(b []byte, field int, wireType int) []byte {
+	return binary.AppendUvarint(b, uint64(field<<3|wireType))
+}
+
+func appendBytes(b []byte, field int, value []byte) []byte {
+	b = appendTag(b, field, 2)
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+func appendString(b []byte, field int, value string) []byte {
+	return appendBytes(b, field, []byte(value))
+}
@@ -0,0 +1,144 @@
+package exporters_test
+
+import (
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/exporters"
+	"github.com/mauriciogm/dokploy/apps/monitoring/exporters/exportertest"
+)
+
+func testSamples() []exporters.Sample {
+	at := time.UnixMilli(1760522400123).UTC()
+	return []exporters.Sample{
+		{Name: "container_cpu_percent", Labels: map[string]string{"container": "app", "host": "web-1"}, Value: 12.5, Timestamp: at},
+		{Name: "container_cpu_percent", Labels: map[string]string{"container": "app", "host": "web-1"}, Value: 13.25, Timestamp: at.Add(time.Second)},
+		{Name: "node_memory_used_bytes", Labels: map[string]string{"host": "web-1"}, Value: 2147483648, Timestamp: at},
+	}
+}
+
+func remoteWriteConfig(url, walDir string) config.RemoteWriteConfig {
+	return config.RemoteWriteConfig{
+		Enabled: true,
+		URL:     url,
+		Timeout: 5,
+		Queue:   config.QueueConfig{FlushInterval: 1, WALDir: walDir},
+	}
+}
+
+// waitFor polls cond until it holds or the timeout expires.
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) bool {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return cond()
+}
+
+func sortSamples(samples []exporters.Sample) {
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].Name != samples[j].Name {
+			return samples[i].Name < samples[j].Name
+		}
+		return samples[i].Timestamp.Before(samples[j].Timestamp)
+	})
+}
+
+func checkSamples(t *testing.T, got, want []exporters.Sample) {
+	t.Helper()
+	sortSamples(got)
+	sortSamples(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("received samples\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestRemoteWriteRoundTrip(t *testing.T) {
+	receiver := exportertest.NewRemoteWriteReceiver()
+	defer receiver.Close()
+
+	e, err := exporters.NewRemoteWriteExporter(remoteWriteConfig(receiver.URL, t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Stop()
+
+	e.Export(testSamples())
+	if !waitFor(t, 5*time.Second, func() bool { return len(receiver.Samples()) == 3 }) {
+		t.Fatalf("received %d samples, want 3", len(receiver.Samples()))
+	}
+	checkSamples(t, receiver.Samples(), testSamples())
+	if stats := e.Stats(); stats.SentSamples != 3 || stats.FailedBatches != 0 {
+		t.Errorf("got stats %+v, want 3 sent and no failures", stats)
+	}
+}
+
+func TestRemoteWriteRetriesServerErrors(t *testing.T) {
+	receiver := exportertest.NewRemoteWriteReceiver()
+	defer receiver.Close()
+	receiver.FailNext(http.StatusServiceUnavailable)
+
+	cfg := remoteWriteConfig(receiver.URL, t.TempDir())
+	cfg.Queue.Shards = 1
+	e, err := exporters.NewRemoteWriteExporter(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Stop()
+
+	e.Export(testSamples())
+	if !waitFor(t, 10*time.Second, func() bool { return len(receiver.Samples()) == 3 }) {
+		t.Fatalf("received %d samples after %d requests, want 3", len(receiver.Samples()), receiver.Requests())
+	}
+	checkSamples(t, receiver.Samples(), testSamples())
+	if n := receiver.Requests(); n != 2 {
+		t.Errorf("got %d requests, want the failed one and its retry", n)
+	}
+	if stats := e.Stats(); stats.RejectedSamples != 0 {
+		t.Errorf("got %d rejected samples, want the 503 to be retried", stats.RejectedSamples)
+	}
+}
+
+func TestRemoteWriteReplaysWALAfterRestart(t *testing.T) {
+	walDir := filepath.Join(t.TempDir(), "wal")
+
+	// Nothing listens on the first URL and the flush interval is long, so
+	// the samples only reach the WAL before the exporter stops.
+	cfg := remoteWriteConfig("http://127.0.0.1:1/api/v1/write", walDir)
+	cfg.Queue.FlushInterval = 3600
+	e, err := exporters.NewRemoteWriteExporter(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Export(testSamples())
+	e.Stop()
+
+	receiver := exportertest.NewRemoteWriteReceiver()
+	defer receiver.Close()
+
+	e, err = exporters.NewRemoteWriteExporter(remoteWriteConfig(receiver.URL, walDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Stop()
+	if pending := e.Stats().PendingSegments; pending != 1 {
+		t.Errorf("got %d pending segments after the restart, want 1", pending)
+	}
+
+	if !waitFor(t, 5*time.Second, func() bool { return len(receiver.Samples()) == 3 }) {
+		t.Fatalf("received %d samples, want the 3 of the WAL", len(receiver.Samples()))
+	}
+	checkSamples(t, receiver.Samples(), testSamples())
+	if !waitFor(t, time.Second, func() bool { return e.Stats().PendingSegments == 0 }) {
+		t.Errorf("got %d pending segments after delivery, want 0", e.Stats().PendingSegments)
+	}
+}
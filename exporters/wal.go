@@ -0,0 +1,194 @@
+package exporters
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const walSegmentExt = ".wal"
+
+// wal is an append-only log of samples split into numbered segment files.
+// Samples are appended to the open segment; closed segments are read back in
+// order by the queue and removed once delivered, so unsent samples survive
+// restarts.
+type wal struct {
+	dir         string
+	maxSegments int
+
+	mu      sync.Mutex
+	current *os.File
+	writer  *bufio.Writer
+	count   int
+	nextID  uint64
+	closedN int
+}
+
+func openWAL(dir string, maxSegments int) (*wal, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	w := &wal{dir: dir, maxSegments: maxSegments}
+	segments, err := w.segments()
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) > 0 {
+		last, _ := segmentID(segments[len(segments)-1])
+		w.nextID = last + 1
+	}
+	w.closedN = len(segments)
+	return w, nil
+}
+
+// append writes samples to the open segment and returns the number of
+// samples it holds.
+func (w *wal) append(samples []Sample) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.current == nil {
+		path := filepath.Join(w.dir, fmt.Sprintf("%020d%s.tmp", w.nextID, walSegmentExt))
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return 0, err
+		}
+		w.current = f
+		w.writer = bufio.NewWriter(f)
+		w.count = 0
+	}
+
+	enc := json.NewEncoder(w.writer)
+	for _, s := range samples {
+		if err := enc.Encode(s); err != nil {
+			return w.count, err
+		}
+	}
+	w.count += len(samples)
+	return w.count, w.writer.Flush()
+}
+
+// rotate closes the open segment, making it visible to closed.
+func (w *wal) rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.current == nil {
+		return nil
+	}
+
+	path := w.current.Name()
+	if err := w.writer.Flush(); err != nil {
+		return err
+	}
+	if err := w.current.Close(); err != nil {
+		return err
+	}
+	w.current = nil
+	w.writer = nil
+	w.nextID++
+
+	if err := os.Rename(path, strings.TrimSuffix(path, ".tmp")); err != nil {
+		return err
+	}
+	w.closedN++
+	return nil
+}
+
+// full reports whether maxSegments closed segments are waiting for delivery.
+func (w *wal) full() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.maxSegments > 0 && w.closedN >= w.maxSegments
+}
+
+// pending returns the number of closed segments waiting for delivery.
+func (w *wal) pending() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.closedN
+}
+
+// remove deletes a delivered segment.
+func (w *wal) remove(path string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	w.closedN--
+	return nil
+}
+
+// closed returns the closed segments, oldest first.
+func (w *wal) closed() ([]string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.segments()
+}
+
+// segments returns the closed segments, oldest first. Segments left open by
+// a crash are closed first so their samples are not lost. Callers must hold
+// w.mu unless the WAL is not shared yet.
+func (w *wal) segments() ([]string, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var open string
+	if w.current != nil {
+		open = filepath.Base(w.current.Name())
+	}
+
+	var result []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasSuffix(name, walSegmentExt+".tmp") && name != open {
+			closed := strings.TrimSuffix(name, ".tmp")
+			if err := os.Rename(filepath.Join(w.dir, name), filepath.Join(w.dir, closed)); err != nil {
+				return nil, err
+			}
+			name = closed
+		}
+		if strings.HasSuffix(name, walSegmentExt) {
+			result = append(result, filepath.Join(w.dir, name))
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func readSegment(path string) ([]Sample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var samples []Sample
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var s Sample
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			// A crash can leave a partially written last line.
+			continue
+		}
+		samples = append(samples, s)
+	}
+	return samples, scanner.Err()
+}
+
+func segmentID(path string) (uint64, error) {
+	name := strings.TrimSuffix(filepath.Base(path), walSegmentExt)
+	return strconv.ParseUint(name, 10, 64)
+}
@@ -0,0 +1,194 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/containers"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/telemetry"
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusFail     = "fail"
+
+	defaultMinFreeDiskMB  = 100
+	defaultStaleIntervals = 3
+	defaultTimeout        = 5
+)
+
+type Check struct {
+	Name       string  `json:"name"`
+	Status     string  `json:"status"`
+	Message    string  `json:"message,omitempty"`
+	DurationMs float64 `json:"durationMs,omitempty"`
+}
+
+type Report struct {
+	Status    string  `json:"status"`
+	Timestamp string  `json:"timestamp"`
+	Checks    []Check `json:"checks"`
+}
+
+// Checker runs the readiness checks of the agent: the database can be read
+// and written, there is disk space left for it, Docker can be reached and
+// every collector succeeded recently.
+type Checker struct {
+	db     *database.DB
+	docker *containers.DockerClient
+}
+
+func NewChecker(db *database.DB) (*Checker, error) {
+	if err := db.InitHealthTable(); err != nil {
+		return nil, fmt.Errorf("failed to initialize health table: %v", err)
+	}
+
+	return &Checker{
+		db:     db,
+		docker: containers.NewDockerClient(),
+	}, nil
+}
+
+// Ready runs every check concurrently. The report fails when any check
+// fails, and is degraded when a check is only degraded.
+func (hc *Checker) Ready(ctx context.Context) Report {
+	timeout := config.GetMetricsConfig().Health.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	checks := []struct {
+		name string
+		run  func(context.Context) (string, string)
+	}{
+		{"database", hc.checkDatabase},
+		{"disk", hc.checkDisk},
+		{"docker", hc.checkDocker},
+	}
+
+	results := make([]Check, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, name string, run func(context.Context) (string, string)) {
+			defer wg.Done()
+			start := time.Now()
+			status, message := run(ctx)
+			results[i] = Check{
+				Name:       name,
+				Status:     status,
+				Message:    message,
+				DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+		}(i, check.name, check.run)
+	}
+	wg.Wait()
+
+	results = append(results, checkCollectors(time.Now())...)
+
+	report := Report{
+		Status:    StatusOK,
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Checks:    results,
+	}
+	for _, r := range results {
+		switch {
+		case r.Status == StatusFail:
+			report.Status = StatusFail
+		case r.Status == StatusDegraded && report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+func (hc *Checker) checkDatabase(ctx context.Context) (string, string) {
+	if err := hc.db.PingContext(ctx); err != nil {
+		return StatusFail, fmt.Sprintf("ping failed: %v", err)
+	}
+	if err := hc.db.CheckWrite(ctx); err != nil {
+		return StatusFail, fmt.Sprintf("write failed: %v", err)
+	}
+	return StatusOK, ""
+}
+
+func (hc *Checker) checkDisk(ctx context.Context) (string, string) {
+	minFree := config.GetMetricsConfig().Health.MinFreeDiskMB
+	if minFree <= 0 {
+		minFree = defaultMinFreeDiskMB
+	}
+
+	usage, err := disk.UsageWithContext(ctx, filepath.Dir(database.File))
+	if err != nil {
+		return StatusFail, fmt.Sprintf("error reading disk usage: %v", err)
+	}
+
+	freeMB := usage.Free / 1024 / 1024
+	message := fmt.Sprintf("%d MB free on %s", freeMB, usage.Path)
+	if freeMB < uint64(minFree) {
+		return StatusFail, fmt.Sprintf("%s, below the minimum of %d MB", message, minFree)
+	}
+	return StatusOK, message
+}
+
+// checkDocker checks both the docker CLI, used to collect container stats,
+// and the Engine API, used for the inventory, logs and scrape discovery.
+// Without container monitoring an unreachable Docker only degrades the
+// report.
+func (hc *Checker) checkDocker(ctx context.Context) (string, string) {
+	failed := StatusDegraded
+	if len(config.GetMetricsConfig().Containers.Services.Include) > 0 {
+		failed = StatusFail
+	}
+
+	if err := containers.CheckDockerCLI(ctx); err != nil {
+		return failed, err.Error()
+	}
+	if err := hc.docker.Ping(ctx); err != nil {
+		return failed, err.Error()
+	}
+	return StatusOK, ""
+}
+
+// checkCollectors fails the collectors whose last success is older than
+// staleIntervals times their interval. Collectors that never succeeded are
+// measured from when they started. Remote collectors, which fail when the
+// servers they talk to are unreachable, only degrade the report.
+func checkCollectors(now time.Time) []Check {
+	staleIntervals := config.GetMetricsConfig().Health.StaleIntervals
+	if staleIntervals <= 0 {
+		staleIntervals = defaultStaleIntervals
+	}
+
+	var result []Check
+	for _, e := range telemetry.Expectations() {
+		check := Check{Name: "collector:" + e.Collector, Status: StatusOK}
+		last := e.Since
+		if !e.LastSuccess.IsZero() {
+			last = e.LastSuccess
+			check.Message = "last success " + e.LastSuccess.UTC().Format(time.RFC3339)
+		}
+		if age := now.Sub(last); age > time.Duration(staleIntervals)*e.Interval {
+			check.Status = StatusFail
+			if e.Remote {
+				check.Status = StatusDegraded
+			}
+			if e.LastSuccess.IsZero() {
+				check.Message = fmt.Sprintf("no successful collection since start %s ago", age.Round(time.Second))
+			} else {
+				check.Message = fmt.Sprintf("no successful collection for %s (interval %s)", age.Round(time.Second), e.Interval)
+			}
+		}
+		result = append(result, check)
+	}
+	return result
+}
@@ -0,0 +1,255 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+const (
+	maxNameLength = 200
+	maxLabels     = 32
+)
+
+type jsonSample struct {
+	Name      string            `json:"name"`
+	Value     *float64          `json:"value"`
+	Labels    map[string]string `json:"labels"`
+	Timestamp json.RawMessage   `json:"timestamp"`
+}
+
+// ParseJSON parses a single sample or an array of samples. Timestamps may be
+// RFC3339 strings or unix seconds and default to now.
+func ParseJSON(body []byte) ([]database.CustomMetric, error) {
+	var samples []jsonSample
+	trimmed := strings.TrimSpace(string(body))
+	if strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal(body, &samples); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %v", err)
+		}
+	} else {
+		var sample jsonSample
+		if err := json.Unmarshal(body, &sample); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %v", err)
+		}
+		samples = []jsonSample{sample}
+	}
+
+	now := time.Now().UTC()
+	metrics := make([]database.CustomMetric, 0, len(samples))
+	for i, s := range samples {
+		if s.Value == nil {
+			return nil, fmt.Errorf("sample %d: value is required", i)
+		}
+		ts, err := parseJSONTimestamp(s.Timestamp, now)
+		if err != nil {
+			return nil, fmt.Errorf("sample %d: %v", i, err)
+		}
+		m := database.CustomMetric{
+			Timestamp: ts.Format(time.RFC3339Nano),
+			Name:      s.Name,
+			Labels:    s.Labels,
+			Value:     *s.Value,
+		}
+		if err := Validate(m); err != nil {
+			return nil, fmt.Errorf("sample %d: %v", i, err)
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+func parseJSONTimestamp(raw json.RawMessage, now time.Time) (time.Time, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return now, nil
+	}
+
+	var seconds float64
+	if err := json.Unmarshal(raw, &seconds); err == nil {
+		return time.Unix(0, int64(seconds*1e9)).UTC(), nil
+	}
+
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %s", string(raw))
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", value)
+	}
+	return t.UTC(), nil
+}
+
+// ParseLineProtocol parses InfluxDB line protocol. Every numeric or boolean
+// field becomes a sample named <measurement>_<field>, or <measurement> for a
+// field called "value"; string fields are ignored. precision is one of ns,
+// us, ms or s and defaults to ns.
+func ParseLineProtocol(body []byte, precision string) ([]database.CustomMetric, error) {
+	var multiplier int64
+	switch precision {
+	case "", "ns", "n":
+		multiplier = 1
+	case "us", "u":
+		multiplier = int64(time.Microsecond)
+	case "ms":
+		multiplier = int64(time.Millisecond)
+	case "s":
+		multiplier = int64(time.Second)
+	default:
+		return nil, fmt.Errorf("invalid precision %q", precision)
+	}
+
+	now := time.Now().UTC()
+	var metrics []database.CustomMetric
+	for lineNumber, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parsed, err := parseLine(line, multiplier, now)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber+1, err)
+		}
+		metrics = append(metrics, parsed...)
+	}
+	return metrics, nil
+}
+
+func parseLine(line string, multiplier int64, now time.Time) ([]database.CustomMetric, error) {
+	sections := splitUnescaped(line, ' ')
+	if len(sections) < 2 || len(sections) > 3 {
+		return nil, fmt.Errorf("expected measurement, fields and optional timestamp")
+	}
+
+	keyParts := splitUnescaped(sections[0], ',')
+	measurement := unescape(keyParts[0])
+	labels := make(map[string]string, len(keyParts)-1)
+	for _, tag := range keyParts[1:] {
+		kv := splitUnescaped(tag, '=')
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid tag %q", tag)
+		}
+		labels[unescape(kv[0])] = unescape(kv[1])
+	}
+
+	timestamp := now
+	if len(sections) == 3 {
+		ts, err := strconv.ParseInt(sections[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q", sections[2])
+		}
+		timestamp = time.Unix(0, ts*multiplier).UTC()
+	}
+
+	var metrics []database.CustomMetric
+	for _, field := range splitUnescaped(sections[1], ',') {
+		kv := splitUnescaped(field, '=')
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid field %q", field)
+		}
+
+		value, ok, err := parseFieldValue(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %v", kv[0], err)
+		}
+		if !ok {
+			continue
+		}
+
+		name := measurement
+		if key := unescape(kv[0]); key != "value" {
+			name = measurement + "_" + key
+		}
+		m := database.CustomMetric{
+			Timestamp: timestamp.Format(time.RFC3339Nano),
+			Name:      name,
+			Labels:    labels,
+			Value:     value,
+		}
+		if err := Validate(m); err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// parseFieldValue returns false for string fields, which cannot be stored.
+func parseFieldValue(raw string) (float64, bool, error) {
+	if strings.HasPrefix(raw, `"`) {
+		return 0, false, nil
+	}
+	switch raw {
+	case "t", "T", "true", "True", "TRUE":
+		return 1, true, nil
+	case "f", "F", "false", "False", "FALSE":
+		return 0, true, nil
+	}
+
+	raw = strings.TrimSuffix(strings.TrimSuffix(raw, "i"), "u")
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid value %q", raw)
+	}
+	return value, true, nil
+}
+
+// splitUnescaped splits on sep, ignoring separators escaped with a backslash
+// or inside double quotes.
+func splitUnescaped(s string, sep byte) []string {
+	var parts []string
+	inQuotes := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			inQuotes = !inQuotes
+		case sep:
+			if !inQuotes {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// Validate checks that a sample can be stored. NaN and infinite values are
+// rejected: SQLite stores NaN as NULL and JSON can't encode either.
+func Validate(m database.CustomMetric) error {
+	if math.IsNaN(m.Value) || math.IsInf(m.Value, 0) {
+		return fmt.Errorf("value %v is not a finite number", m.Value)
+	}
+	if m.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(m.Name) > maxNameLength {
+		return fmt.Errorf("name longer than %d characters", maxNameLength)
+	}
+	if len(m.Labels) > maxLabels {
+		return fmt.Errorf("more than %d labels", maxLabels)
+	}
+	return nil
+}
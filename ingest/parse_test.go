@@ -0,0 +1,145 @@
+package ingest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+func TestParseLineProtocol(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		body      string
+		precision string
+		want      []database.CustomMetric
+	}{
+		{
+			name: "value field",
+			body: "queue_depth,queue=emails value=42 1760522400000000000",
+			want: []database.CustomMetric{
+				{Timestamp: "2025-10-15T10:00:00Z", Name: "queue_depth", Labels: map[string]string{"queue": "emails"}, Value: 42},
+			},
+		},
+		{
+			name: "field types",
+			body: `jobs,host=web-1 done=12i,failed=3u,running=t,idle=F,ratio=0.25,last="ok, really" 1760522400000000000`,
+			want: []database.CustomMetric{
+				{Timestamp: "2025-10-15T10:00:00Z", Name: "jobs_done", Labels: map[string]string{"host": "web-1"}, Value: 12},
+				{Timestamp: "2025-10-15T10:00:00Z", Name: "jobs_failed", Labels: map[string]string{"host": "web-1"}, Value: 3},
+				{Timestamp: "2025-10-15T10:00:00Z", Name: "jobs_running", Labels: map[string]string{"host": "web-1"}, Value: 1},
+				{Timestamp: "2025-10-15T10:00:00Z", Name: "jobs_idle", Labels: map[string]string{"host": "web-1"}, Value: 0},
+				{Timestamp: "2025-10-15T10:00:00Z", Name: "jobs_ratio", Labels: map[string]string{"host": "web-1"}, Value: 0.25},
+			},
+		},
+		{
+			name: "escaping",
+			body: `disk\ usage,mount\ point=/var\ lib,path=a\,b\=c used\ bytes=1 1760522400000000000`,
+			want: []database.CustomMetric{
+				{Timestamp: "2025-10-15T10:00:00Z", Name: "disk usage_used bytes", Labels: map[string]string{"mount point": "/var lib", "path": "a,b=c"}, Value: 1},
+			},
+		},
+		{
+			name:      "seconds",
+			body:      "temp value=21.5 1760522400",
+			precision: "s",
+			want:      []database.CustomMetric{{Timestamp: "2025-10-15T10:00:00Z", Name: "temp", Labels: map[string]string{}, Value: 21.5}},
+		},
+		{
+			name:      "milliseconds",
+			body:      "temp value=21.5 1760522400123",
+			precision: "ms",
+			want:      []database.CustomMetric{{Timestamp: "2025-10-15T10:00:00.123Z", Name: "temp", Labels: map[string]string{}, Value: 21.5}},
+		},
+		{
+			name:      "microseconds",
+			body:      "temp value=21.5 1760522400123456",
+			precision: "us",
+			want:      []database.CustomMetric{{Timestamp: "2025-10-15T10:00:00.123456Z", Name: "temp", Labels: map[string]string{}, Value: 21.5}},
+		},
+		{
+			name: "comments and blank lines",
+			body: "# a comment\n\ntemp value=1 1760522400000000001\n",
+			want: []database.CustomMetric{{Timestamp: "2025-10-15T10:00:00.000000001Z", Name: "temp", Labels: map[string]string{}, Value: 1}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseLineProtocol([]byte(tc.body), tc.precision)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got\n%+v\nwant\n%+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseLineProtocolDefaultsToNow(t *testing.T) {
+	got, err := ParseLineProtocol([]byte("temp value=1"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Timestamp == "" {
+		t.Errorf("got %+v, want one sample timestamped now", got)
+	}
+}
+
+func TestParseLineProtocolRejectsInvalidInput(t *testing.T) {
+	labels := make([]string, maxLabels+1)
+	for i := range labels {
+		labels[i] = "l" + strings.Repeat("x", i) + "=v"
+	}
+
+	for _, tc := range []struct {
+		name      string
+		body      string
+		precision string
+	}{
+		{"no fields", "temp", ""},
+		{"invalid tag", "temp,host value=1", ""},
+		{"invalid field", "temp value", ""},
+		{"invalid value", "temp value=hot", ""},
+		{"invalid timestamp", "temp value=1 yesterday", ""},
+		{"invalid precision", "temp value=1", "h"},
+		{"infinite", "temp value=Inf", ""},
+		{"negative infinite", "temp value=-inf", ""},
+		{"not a number", "temp value=NaN", ""},
+		{"long name", strings.Repeat("t", maxNameLength+1) + " value=1", ""},
+		{"too many labels", "temp," + strings.Join(labels, ",") + " value=1", ""},
+	} {
+		if got, err := ParseLineProtocol([]byte(tc.body), tc.precision); err == nil {
+			t.Errorf("%s: got %+v, want an error", tc.name, got)
+		}
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	got, err := ParseJSON([]byte(`[
+		{"name": "deploys", "value": 1, "labels": {"app": "api"}, "timestamp": 1760522400.5},
+		{"name": "deploys", "value": 2, "timestamp": "2025-10-15T12:00:00+02:00"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []database.CustomMetric{
+		{Timestamp: "2025-10-15T10:00:00.5Z", Name: "deploys", Labels: map[string]string{"app": "api"}, Value: 1},
+		{Timestamp: "2025-10-15T10:00:00Z", Name: "deploys", Value: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%+v\nwant\n%+v", got, want)
+	}
+
+	for _, body := range []string{
+		`{"name": "deploys"}`,
+		`{"value": 1}`,
+		`{"name": "deploys", "value": 1, "timestamp": "yesterday"}`,
+		`{"name": "deploys", "value": 1e999}`,
+		`[{"name": "deploys", "value": 1}`,
+	} {
+		if got, err := ParseJSON([]byte(body)); err == nil {
+			t.Errorf("%s: got %+v, want an error", body, got)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package ingest
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+// Series holds the bucketed values of one label set, aligned with
+// SeriesResponse.Timestamps. Buckets without samples are nil.
+type Series struct {
+	Labels map[string]string `json:"labels"`
+	Values []*float64        `json:"values"`
+}
+
+type SeriesResponse struct {
+	Name       string   `json:"name"`
+	Start      string   `json:"start"`
+	End        string   `json:"end"`
+	Step       int64    `json:"step"`
+	Timestamps []string `json:"timestamps"`
+	Series     []Series `json:"series"`
+}
+
+// BuildSeries averages the samples of every label set into the buckets of
+// the given range.
+func BuildSeries(name string, metrics []database.CustomMetric, tr database.TimeRange) SeriesResponse {
+	n := tr.Buckets()
+
+	type accumulator struct {
+		labels map[string]string
+		sums   []float64
+		counts []int
+	}
+	byLabels := make(map[string]*accumulator)
+
+	for _, m := range metrics {
+		ts, err := time.Parse(time.RFC3339Nano, m.Timestamp)
+		if err != nil {
+			continue
+		}
+		idx := tr.BucketIndex(ts)
+		if idx < 0 {
+			continue
+		}
+
+		keyJSON, _ := json.Marshal(m.Labels)
+		key := string(keyJSON)
+		acc, ok := byLabels[key]
+		if !ok {
+			labels := m.Labels
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			acc = &accumulator{labels: labels, sums: make([]float64, n), counts: make([]int, n)}
+			byLabels[key] = acc
+		}
+		acc.sums[idx] += m.Value
+		acc.counts[idx]++
+	}
+
+	keys := make([]string, 0, len(byLabels))
+	for key := range byLabels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	series := make([]Series, 0, len(keys))
+	for _, key := range keys {
+		acc := byLabels[key]
+		values := make([]*float64, n)
+		for i, sum := range acc.sums {
+			if acc.counts[i] == 0 {
+				continue
+			}
+			avg := sum / float64(acc.counts[i])
+			values[i] = &avg
+		}
+		series = append(series, Series{Labels: acc.labels, Values: values})
+	}
+
+	return SeriesResponse{
+		Name:       name,
+		Start:      tr.Start.Format(time.RFC3339Nano),
+		End:        tr.End.Format(time.RFC3339Nano),
+		Step:       int64(tr.Step / time.Second),
+		Timestamps: tr.Timestamps(),
+		Series:     series,
+	}
+}
@@ -0,0 +1,330 @@
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/logging"
+	"github.com/mauriciogm/dokploy/apps/monitoring/telemetry"
+)
+
+var logger = logging.New("statsd")
+
+const (
+	defaultStatsdFlushInterval = 10
+
+	// A read error that persists is retried after a delay doubling from
+	// minReadBackoff up to maxReadBackoff, instead of spinning.
+	minReadBackoff = 10 * time.Millisecond
+	maxReadBackoff = time.Second
+)
+
+type timerStats struct {
+	labels        map[string]string
+	name          string
+	count         int
+	sum, min, max float64
+}
+
+type statsdValue struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+// StatsdListener receives StatsD packets over UDP and stores the aggregated
+// values of every flush interval as custom metrics. Counters are summed,
+// gauges keep their last value, timers are stored as <name>.count, .mean,
+// .min and .max, and sets as the number of unique values. DogStatsD tags
+// ("|#key:value,...") become labels.
+type StatsdListener struct {
+	db       *database.DB
+	conn     net.PacketConn
+	mu       sync.Mutex
+	counters map[string]*statsdValue
+	gauges   map[string]*statsdValue
+	timers   map[string]*timerStats
+	sets     map[string]map[string]bool
+	setInfo  map[string]*statsdValue
+	stopChan chan struct{}
+	readWG   sync.WaitGroup
+	flushWG  sync.WaitGroup
+}
+
+func NewStatsdListener(db *database.DB) (*StatsdListener, error) {
+	if err := db.InitCustomMetricsTable(); err != nil {
+		return nil, fmt.Errorf("failed to initialize custom metrics table: %v", err)
+	}
+
+	l := &StatsdListener{
+		db:       db,
+		stopChan: make(chan struct{}),
+	}
+	l.reset()
+	return l, nil
+}
+
+func (l *StatsdListener) reset() {
+	l.counters = make(map[string]*statsdValue)
+	l.timers = make(map[string]*timerStats)
+	l.sets = make(map[string]map[string]bool)
+	l.setInfo = make(map[string]*statsdValue)
+	if l.gauges == nil {
+		l.gauges = make(map[string]*statsdValue)
+	}
+}
+
+func (l *StatsdListener) Start() error {
+	cfg := config.GetMetricsConfig().Ingest
+	if cfg.StatsdAddress == "" {
+		return nil
+	}
+
+	conn, err := net.ListenPacket("udp", cfg.StatsdAddress)
+	if err != nil {
+		return fmt.Errorf("error listening on %s: %v", cfg.StatsdAddress, err)
+	}
+	l.conn = conn
+
+	flushInterval := cfg.StatsdFlushInterval
+	if flushInterval <= 0 {
+		flushInterval = defaultStatsdFlushInterval
+	}
+
+	l.readWG.Add(1)
+	go l.read()
+
+	ticker := time.NewTicker(time.Duration(flushInterval) * time.Second)
+	l.flushWG.Add(1)
+	go func() {
+		defer l.flushWG.Done()
+		for {
+			select {
+			case <-ticker.C:
+				l.flush()
+			case <-l.stopChan:
+				ticker.Stop()
+				l.flush()
+				return
+			}
+		}
+	}()
+
+	logger.Info("StatsD listener started", "address", conn.LocalAddr().String())
+	return nil
+}
+
+// Stop closes the socket, waits for the packets already read to be handled
+// and stores the values aggregated since the last flush.
+func (l *StatsdListener) Stop() {
+	if l.conn != nil {
+		l.conn.Close()
+	}
+	l.readWG.Wait()
+	close(l.stopChan)
+	l.flushWG.Wait()
+}
+
+func (l *StatsdListener) read() {
+	defer l.readWG.Done()
+
+	buf := make([]byte, 65535)
+	backoff := minReadBackoff
+	for {
+		n, _, err := l.conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			logger.Error("Error reading StatsD packet", "error", err, "retry_in", backoff)
+			time.Sleep(backoff)
+			backoff *= 2
+			if backoff > maxReadBackoff {
+				backoff = maxReadBackoff
+			}
+			continue
+		}
+		backoff = minReadBackoff
+
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			if line = strings.TrimSpace(line); line == "" {
+				continue
+			}
+			if err := l.handle(line); err != nil {
+				logger.Warn("Invalid StatsD line", "line", line, "error", err)
+			}
+		}
+	}
+}
+
+// handle parses one "name:value|type[|@rate][|#tags]" line.
+func (l *StatsdListener) handle(line string) error {
+	colon := strings.Index(line, ":")
+	if colon <= 0 {
+		return fmt.Errorf("missing value")
+	}
+	name := line[:colon]
+	parts := strings.Split(line[colon+1:], "|")
+	if len(parts) < 2 {
+		return fmt.Errorf("missing type")
+	}
+
+	rawValue, metricType := parts[0], parts[1]
+	sampleRate := 1.0
+	labels := map[string]string{}
+	for _, extra := range parts[2:] {
+		switch {
+		case strings.HasPrefix(extra, "@"):
+			rate, err := strconv.ParseFloat(extra[1:], 64)
+			if err != nil || !(rate > 0) || math.IsInf(rate, 0) {
+				return fmt.Errorf("invalid sample rate %q", extra)
+			}
+			sampleRate = rate
+		case strings.HasPrefix(extra, "#"):
+			for _, tag := range strings.Split(extra[1:], ",") {
+				kv := strings.SplitN(tag, ":", 2)
+				if len(kv) == 2 {
+					labels[kv[0]] = kv[1]
+				} else if kv[0] != "" {
+					labels[kv[0]] = ""
+				}
+			}
+		}
+	}
+
+	// Set members are counted, not parsed.
+	var value float64
+	if metricType != "s" {
+		var err error
+		if value, err = strconv.ParseFloat(rawValue, 64); err != nil {
+			return fmt.Errorf("invalid value %q", rawValue)
+		}
+	}
+	if err := Validate(database.CustomMetric{Name: name, Labels: labels, Value: value}); err != nil {
+		return err
+	}
+	key := seriesKey(name, labels)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if metricType == "s" {
+		if l.sets[key] == nil {
+			l.sets[key] = make(map[string]bool)
+			l.setInfo[key] = &statsdValue{name: name, labels: labels}
+		}
+		l.sets[key][rawValue] = true
+		return nil
+	}
+
+	switch metricType {
+	case "c":
+		c, ok := l.counters[key]
+		if !ok {
+			c = &statsdValue{name: name, labels: labels}
+			l.counters[key] = c
+		}
+		c.value += value / sampleRate
+	case "g":
+		g, ok := l.gauges[key]
+		if !ok {
+			g = &statsdValue{name: name, labels: labels}
+			l.gauges[key] = g
+		}
+		if strings.HasPrefix(rawValue, "+") || strings.HasPrefix(rawValue, "-") {
+			g.value += value
+		} else {
+			g.value = value
+		}
+	case "ms", "h", "d":
+		t, ok := l.timers[key]
+		if !ok {
+			t = &timerStats{name: name, labels: labels, min: value, max: value}
+			l.timers[key] = t
+		}
+		t.count++
+		t.sum += value
+		if value < t.min {
+			t.min = value
+		}
+		if value > t.max {
+			t.max = value
+		}
+	default:
+		return fmt.Errorf("unsupported type %q", metricType)
+	}
+	return nil
+}
+
+func (l *StatsdListener) flush() {
+	timestamp := time.Now().UTC().Format(time.RFC3339Nano)
+
+	l.mu.Lock()
+	var metrics []database.CustomMetric
+	add := func(name string, labels map[string]string, value float64) {
+		m := database.CustomMetric{
+			Timestamp: timestamp,
+			Name:      name,
+			Labels:    labels,
+			Value:     value,
+		}
+		// Sums can overflow and suffixes lengthen names.
+		if err := Validate(m); err != nil {
+			logger.Warn("Dropping StatsD metric", "name", name, "error", err)
+			return
+		}
+		metrics = append(metrics, m)
+	}
+	for _, c := range l.counters {
+		add(c.name, c.labels, c.value)
+	}
+	for _, g := range l.gauges {
+		add(g.name, g.labels, g.value)
+	}
+	for _, t := range l.timers {
+		add(t.name+".count", t.labels, float64(t.count))
+		add(t.name+".mean", t.labels, t.sum/float64(t.count))
+		add(t.name+".min", t.labels, t.min)
+		add(t.name+".max", t.labels, t.max)
+	}
+	for key, values := range l.sets {
+		info := l.setInfo[key]
+		add(info.name, info.labels, float64(len(values)))
+	}
+	l.reset()
+	l.mu.Unlock()
+
+	if len(metrics) == 0 {
+		return
+	}
+	cycle := telemetry.StartCycle("statsd")
+	if err := l.db.SaveCustomMetrics(metrics); err != nil {
+		logger.Error("Error saving StatsD metrics", "error", err)
+		cycle.Error()
+	}
+	cycle.Done()
+}
+
+func seriesKey(name string, labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(name)
+	for _, k := range keys {
+		b.WriteString("|" + k + "=" + labels[k])
+	}
+	return b.String()
+}
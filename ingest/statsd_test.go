@@ -0,0 +1,146 @@
+package ingest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+func testListener(t *testing.T) *StatsdListener {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "monitoring.db")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := database.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	l, err := NewStatsdListener(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+// flushed flushes the listener and returns the values stored per series, as
+// name{labels}.
+func flushed(t *testing.T, l *StatsdListener, names ...string) map[string]float64 {
+	t.Helper()
+	l.flush()
+
+	now := time.Now()
+	values := map[string]float64{}
+	for _, name := range names {
+		metrics, err := l.db.GetCustomMetricsInRange(name, nil, now.Add(-time.Hour), now.Add(time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range metrics {
+			values[seriesKey(m.Name, m.Labels)] = m.Value
+		}
+	}
+	return values
+}
+
+func TestStatsdTypes(t *testing.T) {
+	l := testListener(t)
+	for _, line := range []string{
+		"requests:1|c",
+		"requests:2|c|@0.5",
+		"requests:1|c|#route:/api,method:GET",
+		"temperature:20|g",
+		"temperature:+5|g",
+		"temperature:-2|g",
+		"latency:10|ms",
+		"latency:30|ms",
+		"size:5|h",
+		"users:alice|s",
+		"users:bob|s",
+		"users:alice|s",
+	} {
+		if err := l.handle(line); err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+	}
+
+	got := flushed(t, l, "requests", "temperature", "latency.count", "latency.mean", "latency.min", "latency.max", "size.count", "users")
+	want := map[string]float64{
+		"requests":                       5,
+		"requests|method=GET|route=/api": 1,
+		"temperature":                    23,
+		"latency.count":                  2,
+		"latency.mean":                   20,
+		"latency.min":                    10,
+		"latency.max":                    30,
+		"size.count":                     1,
+		"users":                          2,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestStatsdFlushResetsAllButGauges(t *testing.T) {
+	l := testListener(t)
+	for _, line := range []string{"requests:1|c", "temperature:20|g"} {
+		if err := l.handle(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.flush()
+
+	var names []string
+	for _, g := range l.gauges {
+		names = append(names, g.name)
+	}
+	sort.Strings(names)
+	if len(l.counters) != 0 || !reflect.DeepEqual(names, []string{"temperature"}) {
+		t.Errorf("got %d counters and gauges %v after a flush, want no counter and the gauge kept", len(l.counters), names)
+	}
+}
+
+func TestStatsdRejectsInvalidLines(t *testing.T) {
+	l := testListener(t)
+	for _, line := range []string{
+		"requests",
+		":1|c",
+		"requests:1",
+		"requests:one|c",
+		"requests:1|x",
+		"requests:1|c|@0",
+		"requests:1|c|@-1",
+		"requests:1|c|@NaN",
+		"requests:1|c|@rate",
+		"temperature:Inf|g",
+		"temperature:-Inf|g",
+		"temperature:NaN|g",
+		"latency:+Inf|ms",
+	} {
+		if err := l.handle(line); err == nil {
+			t.Errorf("%s: got no error", line)
+		}
+	}
+	if got := flushed(t, l, "requests", "temperature", "latency.count"); len(got) != 0 {
+		t.Errorf("stored %v from invalid lines", got)
+	}
+}
+
+func TestStatsdDropsOverflowingSums(t *testing.T) {
+	l := testListener(t)
+	for _, line := range []string{"bytes:1e308|c", "bytes:1e308|c", "requests:1|c"} {
+		if err := l.handle(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := flushed(t, l, "bytes", "requests"), map[string]float64{"requests": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want the infinite sum dropped", got)
+	}
+}
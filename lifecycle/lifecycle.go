@@ -0,0 +1,60 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/logging"
+)
+
+var logger = logging.New("lifecycle")
+
+type component struct {
+	name string
+	stop func()
+}
+
+// Group stops the components of the agent in the reverse order they were
+// added, so a component is stopped before the ones it depends on.
+type Group struct {
+	mu         sync.Mutex
+	components []component
+}
+
+func (g *Group) Add(name string, stop func()) {
+	g.mu.Lock()
+	g.components = append(g.components, component{name: name, stop: stop})
+	g.mu.Unlock()
+}
+
+// Shutdown stops every component, one at a time. When ctx expires the
+// components not stopped yet are abandoned and reported in the error.
+func (g *Group) Shutdown(ctx context.Context) error {
+	g.mu.Lock()
+	components := g.components
+	g.components = nil
+	g.mu.Unlock()
+
+	for i := len(components) - 1; i >= 0; i-- {
+		c := components[i]
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			c.stop()
+		}()
+
+		select {
+		case <-done:
+			logger.Info("Stopped", "name", c.name)
+		case <-ctx.Done():
+			pending := make([]string, 0, i+1)
+			for j := i; j >= 0; j-- {
+				pending = append(pending, components[j].name)
+			}
+			return fmt.Errorf("shutdown timed out waiting for %s", strings.Join(pending, ", "))
+		}
+	}
+	return nil
+}
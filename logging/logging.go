@@ -0,0 +1,205 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+)
+
+var (
+	// output is the handler every component logger writes to. It is replaced
+	// by Init, so loggers created before it are configured too.
+	output atomic.Pointer[slog.Handler]
+
+	level = new(slog.LevelVar)
+
+	mu         sync.RWMutex
+	components = map[string]slog.Level{}
+)
+
+func init() {
+	var h slog.Handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+	output.Store(&h)
+}
+
+// Init configures the format, levels and redacted secrets from the
+// configuration and routes the standard log package through slog.
+func Init(cfg *config.Config) error {
+	if err := SetLevels(cfg.Logging.Level, cfg.Logging.Components); err != nil {
+		return err
+	}
+	return initOutput(os.Stderr, cfg.Logging.Format, secrets(cfg))
+}
+
+func initOutput(w io.Writer, format string, secrets []string) error {
+	opts := &slog.HandlerOptions{
+		// Levels are checked by the component loggers.
+		Level:       slog.LevelDebug,
+		ReplaceAttr: newRedactor(secrets).replaceAttr,
+	}
+
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case "", "text":
+		h = slog.NewTextHandler(w, opts)
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("invalid logging.format %q, expected text or json", format)
+	}
+	output.Store(&h)
+
+	// This also sends the output of the standard log package, still used by
+	// some libraries, through the handler.
+	slog.SetDefault(New("std"))
+	return nil
+}
+
+// New returns the logger of a component. Its records carry a component
+// attribute and are filtered by the component's level.
+func New(component string) *slog.Logger {
+	return slog.New(&handler{component: component})
+}
+
+// Levels is the runtime level configuration.
+type Levels struct {
+	Level      string            `json:"level"`
+	Components map[string]string `json:"components"`
+}
+
+// GetLevels returns the current global and per-component levels.
+func GetLevels() Levels {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	result := Levels{
+		Level:      strings.ToLower(level.Level().String()),
+		Components: make(map[string]string, len(components)),
+	}
+	for name, l := range components {
+		result.Components[name] = strings.ToLower(l.String())
+	}
+	return result
+}
+
+// SetLevels replaces the global level and the per-component overrides. An
+// empty global level means info.
+func SetLevels(global string, overrides map[string]string) error {
+	globalLevel := slog.LevelInfo
+	if global != "" {
+		if err := globalLevel.UnmarshalText([]byte(global)); err != nil {
+			return fmt.Errorf("invalid level %q", global)
+		}
+	}
+
+	parsed := make(map[string]slog.Level, len(overrides))
+	for name, value := range overrides {
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(value)); err != nil {
+			return fmt.Errorf("invalid level %q for %s", value, name)
+		}
+		parsed[name] = l
+	}
+
+	mu.Lock()
+	level.Set(globalLevel)
+	components = parsed
+	mu.Unlock()
+	return nil
+}
+
+func enabled(component string, l slog.Level) bool {
+	mu.RLock()
+	min, ok := components[component]
+	mu.RUnlock()
+	if !ok {
+		min = level.Level()
+	}
+	return l >= min
+}
+
+// handler adds the component to the records and forwards them to the
+// current output, replaying the attributes and groups added with WithAttrs
+// and WithGroup.
+type handler struct {
+	component string
+	ops       []func(slog.Handler) slog.Handler
+}
+
+func (h *handler) Enabled(_ context.Context, l slog.Level) bool {
+	return enabled(h.component, l)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	out := (*output.Load()).WithAttrs([]slog.Attr{slog.String("component", h.component)})
+	for _, op := range h.ops {
+		out = op(out)
+	}
+	return out.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(out slog.Handler) slog.Handler { return out.WithAttrs(attrs) })
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return h.with(func(out slog.Handler) slog.Handler { return out.WithGroup(name) })
+}
+
+func (h *handler) with(op func(slog.Handler) slog.Handler) slog.Handler {
+	ops := make([]func(slog.Handler) slog.Handler, len(h.ops), len(h.ops)+1)
+	copy(ops, h.ops)
+	return &handler{component: h.component, ops: append(ops, op)}
+}
+
+// secrets returns the configured values that must never be logged.
+func secrets(cfg *config.Config) []string {
+	values := []string{
+		cfg.Server.Token,
+		cfg.Server.UrlCallback,
+		cfg.Push.Token,
+		cfg.Exporters.RemoteWrite.BearerToken,
+		cfg.Exporters.RemoteWrite.Password,
+		cfg.Exporters.Influx.Token,
+		cfg.Exporters.Influx.Password,
+		cfg.Reports.SMTP.Password,
+	}
+	for _, agent := range cfg.Aggregator.Agents {
+		values = append(values, agent.Token)
+	}
+	headerMaps := []map[string]string{
+		cfg.Push.Headers,
+		cfg.Exporters.RemoteWrite.Headers,
+		cfg.Exporters.OTLP.Headers,
+		cfg.Exporters.Influx.Headers,
+	}
+	// Webhook URLs such as Slack's embed their credentials.
+	for _, digest := range cfg.Reports.Digests {
+		values = append(values, digest.Webhook)
+		headerMaps = append(headerMaps, digest.Headers)
+	}
+	for _, headers := range headerMaps {
+		for _, v := range headers {
+			values = append(values, v)
+		}
+	}
+
+	var result []string
+	for _, v := range values {
+		// Very short values would redact unrelated text.
+		if len(v) >= 4 {
+			result = append(result, v)
+		}
+	}
+	// Longest first, so a secret containing another is replaced whole.
+	sort.Slice(result, func(i, j int) bool { return len(result[i]) > len(result[j]) })
+	return result
+}
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	// sensitiveKeys are attribute keys whose values are always redacted.
+	sensitiveKeys = []string{"token", "password", "secret", "authorization", "apikey", "api_key", "webhook", "callback"}
+
+	// tokenPattern matches Authorization header values and token query or
+	// form parameters such as access_token=..., but not prose like "invalid
+	// token format".
+	tokenPattern    = regexp.MustCompile(`(?i)(\bbearer\s+|\b[a-z_]*token=)[A-Za-z0-9._~+/=-]+`)
+	userinfoPattern = regexp.MustCompile(`(://)[^/@\s:]+:[^/@\s]+@`)
+)
+
+// redactor removes secrets from log records: attributes with sensitive keys,
+// the configured secret values, bearer tokens and URL credentials anywhere
+// in the message or in string and error values.
+type redactor struct {
+	replacer *strings.Replacer
+}
+
+func newRedactor(secrets []string) *redactor {
+	r := &redactor{}
+	if len(secrets) > 0 {
+		pairs := make([]string, 0, 2*len(secrets))
+		for _, s := range secrets {
+			pairs = append(pairs, s, redacted)
+		}
+		r.replacer = strings.NewReplacer(pairs...)
+	}
+	return r
+}
+
+func (r *redactor) replaceAttr(_ []string, a slog.Attr) slog.Attr {
+	if a.Key != slog.MessageKey && isSensitiveKey(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindDuration:
+		// Readable in both formats, JSON would otherwise get nanoseconds.
+		return slog.String(a.Key, a.Value.Duration().String())
+	case slog.KindString:
+		return slog.String(a.Key, r.redact(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, r.redact(err.Error()))
+		}
+	}
+	return a
+}
+
+func (r *redactor) redact(s string) string {
+	if r.replacer != nil {
+		s = r.replacer.Replace(s)
+	}
+	s = tokenPattern.ReplaceAllString(s, "${1}"+redacted)
+	return userinfoPattern.ReplaceAllString(s, "${1}"+redacted+"@")
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range sensitiveKeys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,176 +1,284 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/joho/godotenv"
+	"github.com/mauriciogm/dokploy/apps/monitoring/aggregator"
+	"github.com/mauriciogm/dokploy/apps/monitoring/api"
+	"github.com/mauriciogm/dokploy/apps/monitoring/certs"
+	"github.com/mauriciogm/dokploy/apps/monitoring/checks"
+	"github.com/mauriciogm/dokploy/apps/monitoring/cli"
 	"github.com/mauriciogm/dokploy/apps/monitoring/config"
 	"github.com/mauriciogm/dokploy/apps/monitoring/containers"
+	"github.com/mauriciogm/dokploy/apps/monitoring/dashboard"
 	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/exporters"
+	"github.com/mauriciogm/dokploy/apps/monitoring/health"
+	"github.com/mauriciogm/dokploy/apps/monitoring/ingest"
+	"github.com/mauriciogm/dokploy/apps/monitoring/lifecycle"
+	"github.com/mauriciogm/dokploy/apps/monitoring/logging"
 	"github.com/mauriciogm/dokploy/apps/monitoring/middleware"
 	"github.com/mauriciogm/dokploy/apps/monitoring/monitoring"
+	"github.com/mauriciogm/dokploy/apps/monitoring/probes"
+	"github.com/mauriciogm/dokploy/apps/monitoring/push"
+	"github.com/mauriciogm/dokploy/apps/monitoring/reports"
+	"github.com/mauriciogm/dokploy/apps/monitoring/scrape"
 )
 
+// defaultShutdownTimeout fits in the 10 second grace period docker stop gives
+// before killing the container.
+const defaultShutdownTimeout = 8
+
+var logger = logging.New("main")
+
 func main() {
 	godotenv.Load()
 
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
+
 	// Get configuration
 	cfg := config.GetMetricsConfig()
-	token := cfg.Server.Token
-	//METRICS_URL_CALLBACK := cfg.Server.UrlCallback
-	log.Printf("Environment variables:")
-	log.Printf("METRICS_CONFIG: %s", os.Getenv("METRICS_CONFIG"))
+	if err := logging.Init(cfg); err != nil {
+		fatal("Invalid logging configuration", err)
+	}
 
-	//if token == "" || METRICS_URL_CALLBACK == "" {
-	//	log.Fatal("token and urlCallback are required in the configuration")
-	//}
-	if token == "" {
-		log.Fatal("token is required in the configuration")
+	if cfg.Server.Token == "" {
+		fatal("Invalid configuration", errors.New("token is required"))
 	}
 
 	db, err := database.InitDB()
 	if err != nil {
-		log.Fatal(err)
+		fatal("Failed to open database", err)
 	}
 
+	// Components are stopped in reverse order on shutdown, the database last.
+	components := &lifecycle.Group{}
+	components.Add("database", func() {
+		if err := db.Close(); err != nil {
+			logger.Error("Error closing database", "error", err)
+		}
+	})
+
 	monitoring.InitNetworkMonitor()
+	if err := monitoring.InitAlertStore(db); err != nil {
+		fatal("Failed to initialize alert store", err)
+	}
+	if err := db.InitAPITokensTable(); err != nil {
+		fatal("Failed to initialize API tokens table", err)
+	}
 
 	// Iniciar el sistema de limpieza de métricas
 	cleanupCron, err := database.StartMetricsCleanup(db.DB, cfg.Server.RetentionDays, cfg.Server.CronJob)
 	if err != nil {
-		log.Fatalf("Error starting metrics cleanup system: %v", err)
+		fatal("Error starting metrics cleanup system", err)
 	}
-	defer cleanupCron.Stop()
+	components.Add("metrics cleanup", func() {
+		<-cleanupCron.Stop().Done()
+	})
 
-	app := fiber.New()
+	rowCounter := database.NewRowCounter(db)
+	if err := rowCounter.Start(); err != nil {
+		fatal("Failed to start row counter", err)
+	}
+	components.Add("row counter", rowCounter.Stop)
+
+	healthChecker, err := health.NewChecker(db)
+	if err != nil {
+		fatal("Failed to create health checker", err)
+	}
+
+	app := fiber.New(fiber.Config{
+		// The startup banner bypasses the structured logger.
+		DisableStartupMessage: true,
+	})
 
+	app.Use(middleware.AccessLog())
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
 		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
+		// Lets browser clients notice they use a deprecated route.
+		ExposeHeaders: "Deprecation, Link",
 	}))
 
-	app.Get("/health", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"status": "ok",
-		})
-	})
-
-	app.Use(func(c *fiber.Ctx) error {
-		if c.Path() == "/health" {
-			return c.Next()
-		}
-		return middleware.AuthMiddleware()(c)
-	})
+	app.Use(dashboard.Prefix, dashboard.Handler())
 
-	app.Get("/metrics", func(c *fiber.Ctx) error {
-		limit := c.Query("limit", "50")
-
-		var metrics []monitoring.SystemMetrics
-		if limit == "all" {
-			dbMetrics, err := db.GetAllMetrics()
-			log.Println(err)
-			if err != nil {
-				return c.Status(500).JSON(fiber.Map{
-					"error": "Failed to fetch metrics",
-				})
-			}
-			for _, m := range dbMetrics {
-				item := monitoring.ConvertToSystemMetrics(m)
-				item.Bandwidth = cfg.Server.Bandwidth
-				metrics = append(metrics)
-			}
-		} else {
-			n, err := strconv.Atoi(limit)
-			if err != nil {
-				n = 50
-			}
-			dbMetrics, err := db.GetLastNMetrics(n)
-			if err != nil {
-				log.Println(err)
-				return c.Status(500).JSON(fiber.Map{
-					"error": "Failed to fetch metrics",
-				})
-			}
-			for _, m := range dbMetrics {
-				item := monitoring.ConvertToSystemMetrics(m)
-				item.Bandwidth = cfg.Server.Bandwidth
-				metrics = append(metrics, item)
-			}
-		}
-
-		return c.JSON(metrics)
-	})
+	if err := exporters.Start(); err != nil {
+		fatal("Failed to start exporters", err)
+	}
+	components.Add("exporters", exporters.Stop)
 
 	containerMonitor, err := containers.NewContainerMonitor(db)
 	if err != nil {
-		log.Fatalf("Failed to create container monitor: %v", err)
+		fatal("Failed to create container monitor", err)
 	}
 	if err := containerMonitor.Start(); err != nil {
-		log.Fatalf("Failed to start container monitor: %v", err)
+		fatal("Failed to start container monitor", err)
 	}
-	defer containerMonitor.Stop()
+	components.Add("container monitor", containerMonitor.Stop)
 
-	app.Get("/metrics/containers", func(c *fiber.Ctx) error {
-		limit := c.Query("limit", "50")
-		appName := c.Query("appName", "")
+	logMonitor, err := containers.NewLogMonitor(db)
+	if err != nil {
+		fatal("Failed to create container log monitor", err)
+	}
+	if err := logMonitor.Start(); err != nil {
+		fatal("Failed to start container log monitor", err)
+	}
+	components.Add("container log monitor", logMonitor.Stop)
 
-		if appName == "" {
-			return c.JSON([]database.ContainerMetric{})
-		}
+	probeMonitor, err := probes.NewProbeMonitor(db)
+	if err != nil {
+		fatal("Failed to create probe monitor", err)
+	}
+	if err := probeMonitor.Start(); err != nil {
+		fatal("Failed to start probe monitor", err)
+	}
+	components.Add("probe monitor", probeMonitor.Stop)
 
-		var metrics []database.ContainerMetric
-		var err error
-
-		if limit == "all" {
-			metrics, err = db.GetAllMetricsContainer(appName)
-		} else {
-			limitNum, parseErr := strconv.Atoi(limit)
-			if parseErr != nil {
-				limitNum = 50
-			}
-			metrics, err = db.GetLastNContainerMetrics(appName, limitNum)
-		}
+	certificateMonitor, err := certs.NewCertificateMonitor(db)
+	if err != nil {
+		fatal("Failed to create certificate monitor", err)
+	}
+	if err := certificateMonitor.Start(); err != nil {
+		fatal("Failed to start certificate monitor", err)
+	}
+	components.Add("certificate monitor", certificateMonitor.Stop)
 
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{
-				"error": "Error getting container metrics: " + err.Error(),
-			})
-		}
+	statsdListener, err := ingest.NewStatsdListener(db)
+	if err != nil {
+		fatal("Failed to create StatsD listener", err)
+	}
+	if err := statsdListener.Start(); err != nil {
+		fatal("Failed to start StatsD listener", err)
+	}
+	components.Add("StatsD listener", statsdListener.Stop)
 
-		return c.JSON(metrics)
-	})
+	checkRunner, err := checks.NewCheckRunner(db)
+	if err != nil {
+		fatal("Failed to create check runner", err)
+	}
+	if err := checkRunner.Start(); err != nil {
+		fatal("Failed to start check runner", err)
+	}
+	components.Add("check runner", checkRunner.Stop)
 
-	go func() {
-		refreshRate := cfg.Server.RefreshRate
-		duration := time.Duration(refreshRate) * time.Second
-
-		log.Printf("Refreshing server metrics every %v", duration)
-		ticker := time.NewTicker(duration)
-		defer ticker.Stop()
-
-		for range ticker.C {
-			metrics := monitoring.GetServerMetrics()
-			//log.Printf("%+v", metrics)
-			if err := db.SaveMetric(metrics); err != nil {
-				log.Printf("Error saving metrics: %v", err)
-			}
-
-			if err := monitoring.CheckThresholds(metrics); err != nil {
-				log.Printf("Error checking thresholds: %v", err)
-			}
-		}
-	}()
+	scraper, err := scrape.NewScraper(db)
+	if err != nil {
+		fatal("Failed to create scraper", err)
+	}
+	if err := scraper.Start(); err != nil {
+		fatal("Failed to start scraper", err)
+	}
+	components.Add("scraper", scraper.Stop)
+
+	pusher, err := push.NewPusher(db)
+	if err != nil {
+		fatal("Failed to create pusher", err)
+	}
+	if err := pusher.Start(); err != nil {
+		fatal("Failed to start pusher", err)
+	}
+	components.Add("pusher", pusher.Stop)
+
+	fleet, err := aggregator.NewAggregator(db)
+	if err != nil {
+		fatal("Failed to create aggregator", err)
+	}
+	if err := fleet.Start(); err != nil {
+		fatal("Failed to start aggregator", err)
+	}
+	components.Add("aggregator", fleet.Stop)
+
+	digests, err := reports.NewScheduler(db)
+	if err != nil {
+		fatal("Failed to create digest scheduler", err)
+	}
+	if err := digests.Start(); err != nil {
+		fatal("Failed to start digest scheduler", err)
+	}
+	components.Add("digest scheduler", digests.Stop)
+
+	server := &api.Server{
+		DB:           db,
+		Config:       cfg,
+		Health:       healthChecker,
+		LogMonitor:   logMonitor,
+		ProbeMonitor: probeMonitor,
+		CheckRunner:  checkRunner,
+		Scraper:      scraper,
+		Pusher:       pusher,
+		Aggregator:   fleet,
+		Digests:      digests,
+		RowCounter:   rowCounter,
+	}
+	if err := server.Register(app, middleware.AuthMiddleware(db)); err != nil {
+		fatal("Failed to register API routes", err)
+	}
+
+	serverMonitor := monitoring.NewServerMonitor(db)
+	if err := serverMonitor.Start(); err != nil {
+		fatal("Failed to start server monitor", err)
+	}
+	components.Add("server monitor", serverMonitor.Stop)
 
 	port := cfg.Server.Port
 	if port == 0 {
 		port = 3001
 	}
 
-	log.Printf("Server starting on port %d", port)
-	log.Fatal(app.Listen(":" + strconv.Itoa(port)))
+	shutdownTimeout := cfg.Server.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = defaultShutdownTimeout
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	listenErr := make(chan error, 1)
+	go func() {
+		logger.Info("Server starting", "port", port)
+		listenErr <- app.Listen(":" + strconv.Itoa(port))
+	}()
+
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+		logger.Info("Shutting down", "timeout", time.Duration(shutdownTimeout)*time.Second)
+	case err := <-listenErr:
+		logger.Error("Server error", "error", err)
+		exitCode = 1
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(shutdownTimeout)*time.Second)
+	defer cancel()
+
+	// Stop accepting connections and wait for the requests in flight before
+	// stopping the collectors they read from.
+	if err := app.ShutdownWithContext(shutdownCtx); err != nil {
+		logger.Error("Error shutting down server", "error", err)
+	}
+	if err := components.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Error during shutdown", "error", err)
+		exitCode = 1
+	}
+
+	logger.Info("Shutdown complete")
+	os.Exit(exitCode)
+}
+
+// fatal logs a startup error and exits.
+func fatal(msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mauriciogm/dokploy/apps/monitoring/logging"
+)
+
+var accessLogger = logging.New("http")
+
+// AccessLog logs every request with its status and duration. Health checks
+// are logged at debug level so probes don't flood the log; client and server
+// errors are logged as warnings and errors.
+func AccessLog() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var fe *fiber.Error
+			if errors.As(err, &fe) {
+				status = fe.Code
+			}
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		case strings.HasPrefix(c.Path(), "/health"):
+			level = slog.LevelDebug
+		}
+
+		accessLogger.Log(c.UserContext(), level, "Request",
+			"method", c.Method(),
+			"path", c.Path(),
+			"status", status,
+			"duration_ms", float64(time.Since(start).Microseconds())/1000,
+			"ip", c.IP(),
+		)
+		return err
+	}
+}
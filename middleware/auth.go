@@ -5,9 +5,15 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/logging"
 )
 
-func AuthMiddleware() fiber.Handler {
+var authLogger = logging.New("http")
+
+// AuthMiddleware accepts server.token and the tokens created with the
+// `token create` command.
+func AuthMiddleware(db *database.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		expectedToken := config.GetMetricsConfig().Server.Token
 
@@ -28,7 +34,18 @@ func AuthMiddleware() fiber.Handler {
 		// Extract the token
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 
-		if token != expectedToken {
+		if token == expectedToken {
+			return c.Next()
+		}
+
+		valid, err := db.ValidAPIToken(token)
+		if err != nil {
+			authLogger.Error("Error checking API token", "error", err)
+			return c.Status(500).JSON(fiber.Map{
+				"error": "Error checking token",
+			})
+		}
+		if !valid {
 			return c.Status(401).JSON(fiber.Map{
 				"error": "Invalid token",
 			})
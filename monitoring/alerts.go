@@ -0,0 +1,112 @@
+package monitoring
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+var (
+	activeMu sync.Mutex
+	active   = map[string]*database.ActiveAlert{}
+	// alertStore, when set, keeps a copy of the active alerts for the
+	// commands that read the database instead of the API, and the history
+	// of the alerts for the reports.
+	alertStore *database.DB
+)
+
+// InitAlertStore persists the active alerts and their history to db. The
+// alerts left by a previous run are cleared and resolved: the collectors
+// raise them again if their condition still holds.
+func InitAlertStore(db *database.DB) error {
+	if err := db.InitActiveAlertsTable(); err != nil {
+		return fmt.Errorf("failed to initialize active alerts table: %v", err)
+	}
+	if err := db.InitAlertHistoryTable(); err != nil {
+		return fmt.Errorf("failed to initialize alert history table: %v", err)
+	}
+	if err := db.ClearActiveAlerts(); err != nil {
+		return fmt.Errorf("failed to clear active alerts: %v", err)
+	}
+	if err := db.ResolveAlertHistory("", time.Now()); err != nil {
+		return fmt.Errorf("failed to resolve alert history: %v", err)
+	}
+
+	activeMu.Lock()
+	alertStore = db
+	activeMu.Unlock()
+	return nil
+}
+
+// RaiseAlert marks the condition identified by key as firing. The collectors
+// call it whenever they observe the condition, whether or not they send an
+// alert for it, and ResolveAlert once it clears.
+func RaiseAlert(key string, alert AlertPayload) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	activeMu.Lock()
+	defer activeMu.Unlock()
+
+	a, ok := active[key]
+	if !ok {
+		a = &database.ActiveAlert{Key: key, Since: now}
+		active[key] = a
+	}
+	a.Type = alert.Type
+	a.Message = alert.Message
+	a.Value = alert.Value
+	a.Threshold = alert.Threshold
+	a.LastSeen = now
+
+	if alertStore != nil {
+		if err := alertStore.SaveActiveAlert(*a); err != nil {
+			logger.Error("Error saving active alert", "key", key, "error", err)
+		}
+		if !ok {
+			if err := alertStore.SaveAlertStart(*a); err != nil {
+				logger.Error("Error saving alert history", "key", key, "error", err)
+			}
+		}
+	}
+}
+
+// ResolveAlert clears the condition identified by key, if it was firing.
+func ResolveAlert(key string) {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+
+	if _, ok := active[key]; !ok {
+		return
+	}
+	delete(active, key)
+
+	if alertStore != nil {
+		if err := alertStore.DeleteActiveAlert(key); err != nil {
+			logger.Error("Error deleting active alert", "key", key, "error", err)
+		}
+		if err := alertStore.ResolveAlertHistory(key, time.Now()); err != nil {
+			logger.Error("Error saving alert history", "key", key, "error", err)
+		}
+	}
+}
+
+// ActiveAlerts returns the firing alerts, oldest first.
+func ActiveAlerts() []database.ActiveAlert {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+
+	result := make([]database.ActiveAlert, 0, len(active))
+	for _, a := range active {
+		result = append(result, *a)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Since != result[j].Since {
+			return result[i].Since < result[j].Since
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
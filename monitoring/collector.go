@@ -0,0 +1,77 @@
+package monitoring
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/exporters"
+	"github.com/mauriciogm/dokploy/apps/monitoring/logging"
+	"github.com/mauriciogm/dokploy/apps/monitoring/telemetry"
+)
+
+var logger = logging.New("server")
+
+// ServerMonitor stores the server metrics every refresh interval and checks
+// them against the alert thresholds.
+type ServerMonitor struct {
+	db       *database.DB
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+func NewServerMonitor(db *database.DB) *ServerMonitor {
+	return &ServerMonitor{
+		db:       db,
+		stopChan: make(chan struct{}),
+	}
+}
+
+func (sm *ServerMonitor) Start() error {
+	refreshRate := config.GetMetricsConfig().Server.RefreshRate
+	if refreshRate <= 0 {
+		refreshRate = 60
+	}
+	duration := time.Duration(refreshRate) * time.Second
+
+	logger.Info("Refreshing server metrics", "interval", duration)
+	telemetry.Expect("server", duration)
+	ticker := time.NewTicker(duration)
+	sm.wg.Add(1)
+	go func() {
+		defer sm.wg.Done()
+		for {
+			select {
+			case <-ticker.C:
+				sm.collect()
+			case <-sm.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop waits for the collection in progress to be stored.
+func (sm *ServerMonitor) Stop() {
+	close(sm.stopChan)
+	sm.wg.Wait()
+}
+
+func (sm *ServerMonitor) collect() {
+	cycle := telemetry.StartCycle("server")
+	metrics := GetServerMetrics()
+	if err := sm.db.SaveMetric(metrics); err != nil {
+		logger.Error("Error saving metrics", "error", err)
+		cycle.Error()
+	}
+	cycle.Done()
+	exporters.Publish(ServerSamples(metrics))
+
+	if err := CheckThresholds(metrics); err != nil {
+		logger.Error("Error checking thresholds", "error", err)
+	}
+}
@@ -0,0 +1,43 @@
+package monitoring
+
+import (
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/exporters"
+)
+
+// ServerSamples converts a server metric to exporter samples in base units.
+func ServerSamples(metric database.ServerMetric) []exporters.Sample {
+	ts, err := time.Parse(time.RFC3339Nano, metric.Timestamp)
+	if err != nil {
+		ts = time.Now()
+	}
+
+	const gib = 1024 * 1024 * 1024
+	const mib = 1024 * 1024
+
+	values := []struct {
+		name  string
+		value float64
+	}{
+		{"server_cpu_usage_percent", metric.CPU},
+		{"server_cpu_cores", float64(metric.CPUCores)},
+		{"server_memory_usage_percent", metric.MemUsed},
+		{"server_memory_used_bytes", metric.MemUsedGB * gib},
+		{"server_memory_total_bytes", metric.MemTotal * gib},
+		{"server_disk_usage_percent", metric.DiskUsed},
+		{"server_disk_total_bytes", metric.TotalDisk * gib},
+		{"server_network_receive_bytes_total", metric.NetworkIn * mib},
+		{"server_network_transmit_bytes_total", metric.NetworkOut * mib},
+		{"server_network_receive_bytes_per_second", metric.DownloadRate * mib},
+		{"server_network_transmit_bytes_per_second", metric.UploadRate * mib},
+		{"server_uptime_seconds", float64(metric.Uptime)},
+	}
+
+	samples := make([]exporters.Sample, len(values))
+	for i, v := range values {
+		samples[i] = exporters.Sample{Name: v.name, Value: v.value, Timestamp: ts}
+	}
+	return samples
+}
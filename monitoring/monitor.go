@@ -14,6 +14,7 @@ import (
 
 	"github.com/mauriciogm/dokploy/apps/monitoring/config"
 	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/telemetry"
 	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/disk"
 	"github.com/shirou/gopsutil/v3/host"
@@ -211,16 +212,15 @@ func CheckThresholds(metrics database.ServerMetric) error {
 	callbackURL := cfg.Server.UrlCallback
 	metricsToken := cfg.Server.Token
 
-	// log.Printf("CPU threshold: %.2f%%", cpuThreshold)
-	// log.Printf("Current CPU usage: %.2f%%", metrics.CPU)
-	// log.Printf("Memory threshold: %.2f%%", memThreshold)
-	// log.Printf("Callback URL: %s", callbackURL)
-	// log.Printf("Metrics token: %s", metricsToken)
+	logger.Debug("Checking thresholds",
+		"cpu", metrics.CPU, "cpu_threshold", cpuThreshold,
+		"memory", metrics.MemUsed, "memory_threshold", memThreshold)
 
 	if cpuThreshold == 0 && memThreshold == 0 {
 		return nil
 	}
 
+	var alerts []AlertPayload
 	if cpuThreshold > 0 && metrics.CPU > cpuThreshold {
 		alert := AlertPayload{
 			ServerType: cfg.Server.ServerType,
@@ -231,9 +231,10 @@ func CheckThresholds(metrics database.ServerMetric) error {
 			Timestamp:  metrics.Timestamp,
 			Token:      metricsToken,
 		}
-		if err := sendAlert(callbackURL, alert); err != nil {
-			return fmt.Errorf("failed to send CPU alert: %v", err)
-		}
+		RaiseAlert("cpu", alert)
+		alerts = append(alerts, alert)
+	} else {
+		ResolveAlert("cpu")
 	}
 
 	if memThreshold > 0 && metrics.MemUsed > memThreshold {
@@ -246,15 +247,43 @@ func CheckThresholds(metrics database.ServerMetric) error {
 			Timestamp:  metrics.Timestamp,
 			Token:      metricsToken,
 		}
+		RaiseAlert("memory", alert)
+		alerts = append(alerts, alert)
+	} else {
+		ResolveAlert("memory")
+	}
+
+	for _, alert := range alerts {
 		if err := sendAlert(callbackURL, alert); err != nil {
-			return fmt.Errorf("failed to send memory alert: %v", err)
+			return fmt.Errorf("failed to send %s alert: %v", alert.Type, err)
 		}
 	}
 
 	return nil
 }
 
+// SendAlert fills in the server type, token and timestamp of an alert and
+// delivers it to the configured callback URL.
+func SendAlert(alert AlertPayload) error {
+	cfg := config.GetMetricsConfig()
+	if alert.ServerType == "" {
+		alert.ServerType = cfg.Server.ServerType
+	}
+	if alert.Timestamp == "" {
+		alert.Timestamp = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	alert.Token = cfg.Server.Token
+
+	return sendAlert(cfg.Server.UrlCallback, alert)
+}
+
 func sendAlert(callbackURL string, payload AlertPayload) error {
+	err := postAlert(callbackURL, payload)
+	telemetry.ObserveAlert(payload.Type, err)
+	return err
+}
+
+func postAlert(callbackURL string, payload AlertPayload) error {
 	if callbackURL == "" {
 		return fmt.Errorf("callback URL is not set")
 	}
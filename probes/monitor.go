@@ -0,0 +1,229 @@
+package probes
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/logging"
+	"github.com/mauriciogm/dokploy/apps/monitoring/monitoring"
+	"github.com/mauriciogm/dokploy/apps/monitoring/telemetry"
+)
+
+var logger = logging.New("probes")
+
+const (
+	defaultInterval         = 60
+	defaultFailureThreshold = 3
+)
+
+type ProbeStatus struct {
+	Name                string                `json:"name"`
+	Type                string                `json:"type"`
+	Target              string                `json:"target"`
+	Up                  bool                  `json:"up"`
+	ConsecutiveFailures int                   `json:"consecutiveFailures"`
+	LastResult          *database.ProbeResult `json:"lastResult"`
+	Uptime24h           *float64              `json:"uptime24h"`
+	Uptime7d            *float64              `json:"uptime7d"`
+}
+
+type probeState struct {
+	last     *database.ProbeResult
+	failures int
+	alerted  bool
+}
+
+// ProbeMonitor runs the configured probes on their own intervals, stores the
+// results and alerts when a probe fails several times in a row.
+type ProbeMonitor struct {
+	db       *database.DB
+	probes   []config.ProbeConfig
+	mu       sync.Mutex
+	state    map[string]*probeState
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	// ctx is cancelled on Stop to abort the running probes.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewProbeMonitor(db *database.DB) (*ProbeMonitor, error) {
+	if err := db.InitProbeResultsTable(); err != nil {
+		return nil, fmt.Errorf("failed to initialize probe results table: %v", err)
+	}
+
+	probes := config.GetMetricsConfig().Probes
+	state := make(map[string]*probeState, len(probes))
+	for _, p := range probes {
+		if p.Name == "" {
+			return nil, fmt.Errorf("probe for %q has no name", Target(p))
+		}
+		if _, ok := state[p.Name]; ok {
+			return nil, fmt.Errorf("duplicate probe name %q", p.Name)
+		}
+		if Target(p) == "" {
+			return nil, fmt.Errorf("probe %q has no url or address", p.Name)
+		}
+		state[p.Name] = &probeState{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ProbeMonitor{
+		db:       db,
+		probes:   probes,
+		state:    state,
+		stopChan: make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+func (pm *ProbeMonitor) Start() error {
+	for _, p := range pm.probes {
+		interval := p.Interval
+		if interval <= 0 {
+			interval = defaultInterval
+		}
+		telemetry.Expect("probes", time.Duration(interval)*time.Second)
+		pm.wg.Add(1)
+		go pm.run(p, time.Duration(interval)*time.Second)
+	}
+
+	if len(pm.probes) > 0 {
+		logger.Info("Started uptime probes", "count", len(pm.probes))
+	}
+	return nil
+}
+
+func (pm *ProbeMonitor) Stop() {
+	close(pm.stopChan)
+	pm.cancel()
+	pm.wg.Wait()
+}
+
+func (pm *ProbeMonitor) run(probe config.ProbeConfig, interval time.Duration) {
+	defer pm.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	pm.runOnce(probe)
+	for {
+		select {
+		case <-ticker.C:
+			pm.runOnce(probe)
+		case <-pm.stopChan:
+			return
+		}
+	}
+}
+
+func (pm *ProbeMonitor) runOnce(probe config.ProbeConfig) {
+	cycle := telemetry.StartCycle("probes")
+	result := Check(pm.ctx, probe)
+	if pm.ctx.Err() != nil {
+		// Interrupted by Stop, the result says nothing about the target.
+		return
+	}
+
+	if err := pm.db.SaveProbeResult(result); err != nil {
+		logger.Error("Error saving probe result", "probe", probe.Name, "error", err)
+		cycle.Error()
+	}
+	cycle.Done()
+
+	threshold := probe.FailureThreshold
+	if threshold <= 0 {
+		threshold = defaultFailureThreshold
+	}
+
+	pm.mu.Lock()
+	state := pm.state[probe.Name]
+	state.last = &result
+	if result.Success {
+		if state.alerted {
+			logger.Info("Probe recovered", "probe", probe.Name, "failures", state.failures)
+		}
+		state.failures = 0
+		state.alerted = false
+		pm.mu.Unlock()
+		monitoring.ResolveAlert("probe:" + probe.Name)
+		return
+	}
+
+	state.failures++
+	failures := state.failures
+	shouldAlert := failures >= threshold && !state.alerted
+	if shouldAlert {
+		state.alerted = true
+	}
+	pm.mu.Unlock()
+
+	if failures < threshold {
+		return
+	}
+
+	alert := monitoring.AlertPayload{
+		Type:      "Probe",
+		Value:     float64(failures),
+		Threshold: float64(threshold),
+		Message:   fmt.Sprintf("Probe %s (%s) failed %d consecutive times: %s", probe.Name, result.Target, failures, result.Error),
+		Timestamp: result.Timestamp,
+	}
+	monitoring.RaiseAlert("probe:"+probe.Name, alert)
+	if !shouldAlert {
+		return
+	}
+	if err := monitoring.SendAlert(alert); err != nil {
+		logger.Error("Error sending probe alert", "probe", probe.Name, "error", err)
+	}
+}
+
+// Statuses returns the current state and uptime of every configured probe.
+func (pm *ProbeMonitor) Statuses() ([]ProbeStatus, error) {
+	now := time.Now()
+	result := make([]ProbeStatus, 0, len(pm.probes))
+
+	for _, p := range pm.probes {
+		pm.mu.Lock()
+		state := pm.state[p.Name]
+		status := ProbeStatus{
+			Name:                p.Name,
+			Type:                probeType(p),
+			Target:              Target(p),
+			ConsecutiveFailures: state.failures,
+			LastResult:          state.last,
+		}
+		pm.mu.Unlock()
+
+		if status.LastResult != nil {
+			status.Up = status.LastResult.Success
+		}
+
+		var err error
+		if status.Uptime24h, err = pm.uptime(p.Name, now.Add(-24*time.Hour)); err != nil {
+			return nil, err
+		}
+		if status.Uptime7d, err = pm.uptime(p.Name, now.Add(-7*24*time.Hour)); err != nil {
+			return nil, err
+		}
+
+		result = append(result, status)
+	}
+
+	return result, nil
+}
+
+func (pm *ProbeMonitor) uptime(name string, since time.Time) (*float64, error) {
+	uptime, err := pm.db.GetProbeUptime(name, since)
+	if err != nil {
+		return nil, err
+	}
+	if uptime < 0 {
+		return nil, nil
+	}
+	return &uptime, nil
+}
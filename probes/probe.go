@@ -0,0 +1,130 @@
+package probes
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+	// maxBodySize bounds how much of a response is searched for BodyContains.
+	maxBodySize = 1024 * 1024
+)
+
+// Check runs a single probe and returns its result. It does not store the
+// result or raise alerts, so it can be run against any server.
+func Check(ctx context.Context, probe config.ProbeConfig) database.ProbeResult {
+	timeout := defaultTimeout
+	if probe.Timeout > 0 {
+		timeout = time.Duration(probe.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result := database.ProbeResult{
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Name:      probe.Name,
+		Type:      probeType(probe),
+		Target:    Target(probe),
+	}
+
+	start := time.Now()
+	var err error
+	switch result.Type {
+	case "http":
+		result.StatusCode, err = checkHTTP(ctx, probe)
+	case "tcp":
+		err = checkTCP(ctx, probe.Address)
+	default:
+		err = fmt.Errorf("unknown probe type %q", probe.Type)
+	}
+	result.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Success = true
+	return result
+}
+
+// Target returns the URL or address checked by a probe.
+func Target(probe config.ProbeConfig) string {
+	if probeType(probe) == "http" {
+		return probe.URL
+	}
+	return probe.Address
+}
+
+// probeType returns "http" or "tcp", or the configured type when unknown.
+// "https" is accepted as an alias of "http": the scheme of the URL decides
+// whether TLS is used.
+func probeType(probe config.ProbeConfig) string {
+	if probe.Type != "" {
+		if t := strings.ToLower(probe.Type); t != "https" {
+			return t
+		}
+		return "http"
+	}
+	if probe.URL != "" {
+		return "http"
+	}
+	return "tcp"
+}
+
+func checkHTTP(ctx context.Context, probe config.ProbeConfig) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, probe.URL, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: probe.InsecureSkipVerify},
+			DisableKeepAlives: true,
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if probe.ExpectedStatus != 0 {
+		if resp.StatusCode != probe.ExpectedStatus {
+			return resp.StatusCode, fmt.Errorf("unexpected status %d (expected %d)", resp.StatusCode, probe.ExpectedStatus)
+		}
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	if probe.BodyContains != "" {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+		if err != nil {
+			return resp.StatusCode, fmt.Errorf("error reading body: %v", err)
+		}
+		if !strings.Contains(string(body), probe.BodyContains) {
+			return resp.StatusCode, fmt.Errorf("body does not contain %q", probe.BodyContains)
+		}
+	}
+
+	return resp.StatusCode, nil
+}
+
+func checkTCP(ctx context.Context, address string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
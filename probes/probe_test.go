@@ -0,0 +1,327 @@
+package probes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/monitoring"
+)
+
+// callback records the alerts sent to server.urlCallback.
+var callback struct {
+	mu     sync.Mutex
+	alerts []monitoring.AlertPayload
+}
+
+func TestMain(m *testing.M) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			JSON monitoring.AlertPayload `json:"json"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		callback.mu.Lock()
+		callback.alerts = append(callback.alerts, body.JSON)
+		callback.mu.Unlock()
+	}))
+
+	os.Setenv("METRICS_CONFIG", fmt.Sprintf(`{"server":{"token":"t","urlCallback":%q}}`, server.URL))
+	code := m.Run()
+	server.Close()
+	os.Exit(code)
+}
+
+func sentAlerts() []monitoring.AlertPayload {
+	callback.mu.Lock()
+	defer callback.mu.Unlock()
+	return append([]monitoring.AlertPayload(nil), callback.alerts...)
+}
+
+func TestCheckHTTPStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+		case "/redirect":
+			w.WriteHeader(http.StatusFound)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		path     string
+		expected int
+		success  bool
+		status   int
+	}{
+		{"/ok", 0, true, 200},
+		{"/redirect", 0, true, 302},
+		{"/missing", 0, false, 404},
+		{"/error", 0, false, 500},
+		{"/missing", 404, true, 404},
+		{"/ok", 204, false, 200},
+	} {
+		probe := config.ProbeConfig{Name: "web", URL: server.URL + tc.path, ExpectedStatus: tc.expected}
+		result := Check(context.Background(), probe)
+		if result.Success != tc.success || result.StatusCode != tc.status {
+			t.Errorf("%s expecting %d: got success %v with status %d (%s), want %v with %d",
+				tc.path, tc.expected, result.Success, result.StatusCode, result.Error, tc.success, tc.status)
+		}
+		if result.Type != "http" || result.Target != probe.URL {
+			t.Errorf("%s: got type %q and target %q", tc.path, result.Type, result.Target)
+		}
+	}
+}
+
+func TestCheckHTTPBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"ok","database":"up"}`)
+	}))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		contains string
+		success  bool
+	}{
+		{`"database":"up"`, true},
+		{`"database":"down"`, false},
+	} {
+		result := Check(context.Background(), config.ProbeConfig{Name: "web", URL: server.URL, BodyContains: tc.contains})
+		if result.Success != tc.success {
+			t.Errorf("body containing %s: got success %v (%s), want %v", tc.contains, result.Success, result.Error, tc.success)
+		}
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	start := time.Now()
+	result := Check(context.Background(), config.ProbeConfig{Name: "slow", URL: server.URL, Timeout: 1})
+	if result.Success {
+		t.Fatal("got success from a server that does not answer")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("the probe took %s with a timeout of 1s", elapsed)
+	}
+	if !strings.Contains(result.Error, "deadline exceeded") {
+		t.Errorf("got error %q, want a timeout", result.Error)
+	}
+}
+
+func TestCheckHTTPSAlias(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	probe := config.ProbeConfig{Name: "tls", Type: "HTTPS", URL: server.URL}
+	if result := Check(context.Background(), probe); result.Success || result.Type != "http" {
+		t.Errorf("got success %v with type %q for a self-signed certificate, want a failed http probe", result.Success, result.Type)
+	}
+
+	probe.InsecureSkipVerify = true
+	if result := Check(context.Background(), probe); !result.Success || result.Target != server.URL {
+		t.Errorf("got success %v (%s) for target %q with insecureSkipVerify", result.Success, result.Error, result.Target)
+	}
+}
+
+func TestCheckTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	address := listener.Addr().String()
+
+	probe := config.ProbeConfig{Name: "db", Address: address}
+	result := Check(context.Background(), probe)
+	if !result.Success || result.Type != "tcp" || result.Target != address {
+		t.Errorf("got success %v (%s) with type %q and target %q, want a successful tcp probe of %s",
+			result.Success, result.Error, result.Type, result.Target, address)
+	}
+
+	listener.Close()
+	if result := Check(context.Background(), probe); result.Success {
+		t.Error("got success after the listener was closed")
+	}
+}
+
+func TestFailureThreshold(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "monitoring.db")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := database.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.InitProbeResultsTable(); err != nil {
+		t.Fatal(err)
+	}
+
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	probe := config.ProbeConfig{Name: "threshold", URL: server.URL, FailureThreshold: 2}
+	pm := &ProbeMonitor{
+		db:     db,
+		probes: []config.ProbeConfig{probe},
+		state:  map[string]*probeState{probe.Name: {}},
+		ctx:    context.Background(),
+	}
+	key := "probe:" + probe.Name
+	firing := func() bool {
+		for _, a := range monitoring.ActiveAlerts() {
+			if a.Key == key {
+				return true
+			}
+		}
+		return false
+	}
+	before := len(sentAlerts())
+
+	for i, want := range []struct {
+		failures int
+		sent     int
+		firing   bool
+	}{
+		{1, 0, false},
+		{2, 1, true},
+		// Further failures keep the alert firing without sending it again.
+		{3, 1, true},
+	} {
+		pm.runOnce(probe)
+		statuses, err := pm.Statuses()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := statuses[0].ConsecutiveFailures; got != want.failures {
+			t.Errorf("failure %d: got %d consecutive failures, want %d", i+1, got, want.failures)
+		}
+		if got := len(sentAlerts()) - before; got != want.sent {
+			t.Errorf("failure %d: got %d alerts sent, want %d", i+1, got, want.sent)
+		}
+		if firing() != want.firing {
+			t.Errorf("failure %d: got firing %v, want %v", i+1, firing(), want.firing)
+		}
+	}
+
+	if alerts := sentAlerts(); len(alerts) > before {
+		alert := alerts[before]
+		if alert.Type != "Probe" || alert.Value != 2 || alert.Threshold != 2 {
+			t.Errorf("got alert %+v, want a Probe alert with value 2 and threshold 2", alert)
+		}
+	}
+
+	healthy.Store(true)
+	pm.runOnce(probe)
+	statuses, err := pm.Statuses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses[0].ConsecutiveFailures != 0 || !statuses[0].Up || firing() {
+		t.Errorf("after recovering: got %d failures, up %v and firing %v", statuses[0].ConsecutiveFailures, statuses[0].Up, firing())
+	}
+	if statuses[0].Uptime24h == nil || *statuses[0].Uptime24h != 25 {
+		t.Errorf("got 24h uptime %v, want 25%% after 3 failures and a success", statuses[0].Uptime24h)
+	}
+
+	// A new failure starts counting again.
+	healthy.Store(false)
+	pm.runOnce(probe)
+	if got := len(sentAlerts()) - before; got != 1 {
+		t.Errorf("got %d alerts sent after a single new failure, want still 1", got)
+	}
+}
+
+func TestStopAbortsRunningProbes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "monitoring.db")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := database.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.InitProbeResultsTable(); err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	probe := config.ProbeConfig{Name: "slow", URL: server.URL, Timeout: 10}
+	ctx, cancel := context.WithCancel(context.Background())
+	pm := &ProbeMonitor{
+		db:       db,
+		probes:   []config.ProbeConfig{probe},
+		state:    map[string]*probeState{probe.Name: {}},
+		stopChan: make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	if err := pm.Start(); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	start := time.Now()
+	pm.Stop()
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Stop took %s with a probe in progress", elapsed)
+	}
+	results, err := db.GetLastNProbeResults(probe.Name, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Errorf("got %+v, want the interrupted probe not stored", results)
+	}
+}
@@ -0,0 +1,95 @@
+package push
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+// MaxBatchSize bounds the decoded size of a batch, so a small gzip body
+// cannot expand into an unbounded amount of memory.
+const MaxBatchSize = 32 * 1024 * 1024
+
+var ErrBatchTooLarge = errors.New("batch is larger than 32 MB")
+
+// Cursor is the position of the last row included in a batch for each table.
+type Cursor struct {
+	Server     int64 `json:"server"`
+	Containers int64 `json:"containers"`
+}
+
+// Batch is the payload sent to the push endpoint.
+type Batch struct {
+	Host       string                     `json:"host"`
+	Cursor     Cursor                     `json:"cursor"`
+	Server     []database.ServerMetric    `json:"server"`
+	Containers []database.ContainerMetric `json:"containers"`
+}
+
+func (b *Batch) Empty() bool {
+	return len(b.Server) == 0 && len(b.Containers) == 0
+}
+
+// BuildBatch reads up to limit rows of each table stored after the cursor.
+func BuildBatch(db *database.DB, host string, after Cursor, limit int) (*Batch, error) {
+	server, serverCursor, err := db.GetServerMetricsAfter(after.Server, limit)
+	if err != nil {
+		return nil, err
+	}
+	containers, containersCursor, err := db.GetContainerMetricsAfter(after.Containers, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Batch{
+		Host:       host,
+		Cursor:     Cursor{Server: serverCursor, Containers: containersCursor},
+		Server:     server,
+		Containers: containers,
+	}, nil
+}
+
+// Encode returns the gzip compressed JSON encoding of a batch.
+func Encode(batch *Batch) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gz).Encode(batch); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode reads a batch, gzip compressed or not. It returns ErrBatchTooLarge
+// when the batch is over MaxBatchSize once decompressed.
+func Decode(data []byte) (*Batch, error) {
+	var reader io.Reader = bytes.NewReader(data)
+	if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, MaxBatchSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxBatchSize {
+		return nil, ErrBatchTooLarge
+	}
+
+	var batch Batch
+	if err := json.Unmarshal(data, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
@@ -0,0 +1,63 @@
+package push
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	batch := &Batch{
+		Host:   "web-1",
+		Cursor: Cursor{Server: 12, Containers: 40},
+		Server: []database.ServerMetric{
+			{Timestamp: "2026-10-15T10:00:00Z", CPU: 12.5, MemUsed: 40, Uptime: 3600},
+		},
+		Containers: []database.ContainerMetric{
+			{Timestamp: "2026-10-15T10:00:00Z", CPU: 1.5, Name: "app-api-1", Memory: database.MemoryMetric{Percentage: 20}},
+		},
+	}
+
+	data, err := Encode(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[0] != 0x1f || data[1] != 0x8b {
+		t.Fatalf("the encoded batch is not gzip compressed")
+	}
+	got, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, batch) {
+		t.Errorf("got\n%+v\nwant\n%+v", got, batch)
+	}
+
+	plain, err := json.Marshal(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = Decode(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, batch) {
+		t.Errorf("uncompressed: got\n%+v\nwant\n%+v", got, batch)
+	}
+}
+
+func TestDecodeRejectsLargeBatches(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(`{"host":"` + strings.Repeat("a", MaxBatchSize) + `"}`))
+	gz.Close()
+
+	if _, err := Decode(buf.Bytes()); err != ErrBatchTooLarge {
+		t.Errorf("got error %v decoding %d compressed bytes, want ErrBatchTooLarge", err, buf.Len())
+	}
+}
@@ -0,0 +1,332 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/logging"
+	"github.com/mauriciogm/dokploy/apps/monitoring/telemetry"
+)
+
+var logger = logging.New("push")
+
+const (
+	defaultInterval       = 60
+	defaultBatchSize      = 500
+	defaultBufferDir      = "./push-buffer"
+	defaultMaxBufferFiles = 1000
+	// maxBatchesPerCycle bounds how much backlog is sent in one interval.
+	maxBatchesPerCycle = 20
+)
+
+type Status struct {
+	Enabled     bool   `json:"enabled"`
+	URL         string `json:"url"`
+	Host        string `json:"host"`
+	Cursor      Cursor `json:"cursor"`
+	Buffered    int    `json:"buffered"`
+	LastSuccess string `json:"lastSuccess,omitempty"`
+	LastError   string `json:"lastError,omitempty"`
+}
+
+// Pusher periodically sends the rows stored since the last push to a central
+// endpoint. Batches that cannot be delivered are kept in an on-disk spool
+// and retried first on the next cycle; the cursor only moves forward once a
+// batch has been sent or spooled.
+type Pusher struct {
+	db        *database.DB
+	client    *http.Client
+	spool     *spool
+	host      string
+	batchSize int
+
+	mu          sync.Mutex
+	lastSuccess string
+	lastError   string
+	// cycle measures the push in progress.
+	cycle *telemetry.Cycle
+
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	// ctx is cancelled on Stop to abort the request in progress.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewPusher(db *database.DB) (*Pusher, error) {
+	if err := db.InitCursorsTable(); err != nil {
+		return nil, fmt.Errorf("failed to initialize cursors table: %v", err)
+	}
+
+	cfg := config.GetMetricsConfig().Push
+	host := cfg.Host
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	bufferDir := cfg.BufferDir
+	if bufferDir == "" {
+		bufferDir = defaultBufferDir
+	}
+	maxFiles := cfg.MaxBufferFiles
+	if maxFiles <= 0 {
+		maxFiles = defaultMaxBufferFiles
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Pusher{
+		db:        db,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		spool:     &spool{dir: bufferDir, maxFiles: maxFiles},
+		host:      host,
+		batchSize: batchSize,
+		stopChan:  make(chan struct{}),
+		ctx:       ctx,
+		cancel:    cancel,
+	}, nil
+}
+
+func (p *Pusher) Start() error {
+	cfg := config.GetMetricsConfig().Push
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.URL == "" {
+		return fmt.Errorf("push.url is required when push is enabled")
+	}
+	if err := p.spool.init(); err != nil {
+		return fmt.Errorf("error creating push buffer directory: %v", err)
+	}
+
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	telemetry.ExpectRemote("push", time.Duration(interval)*time.Second)
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		for {
+			select {
+			case <-ticker.C:
+				p.push()
+			case <-p.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	logger.Info("Pushing metrics", "url", cfg.URL, "interval", time.Duration(interval)*time.Second)
+	return nil
+}
+
+func (p *Pusher) Stop() {
+	close(p.stopChan)
+	p.cancel()
+	p.wg.Wait()
+}
+
+func (p *Pusher) Status() (Status, error) {
+	cfg := config.GetMetricsConfig().Push
+	cursor, err := p.cursor()
+	if err != nil {
+		return Status{}, err
+	}
+
+	status := Status{
+		Enabled: cfg.Enabled,
+		URL:     cfg.URL,
+		Host:    p.host,
+		Cursor:  cursor,
+	}
+	if cfg.Enabled {
+		files, err := p.spool.files()
+		if err != nil {
+			return Status{}, err
+		}
+		status.Buffered = len(files)
+	}
+
+	p.mu.Lock()
+	status.LastSuccess = p.lastSuccess
+	status.LastError = p.lastError
+	p.mu.Unlock()
+	return status, nil
+}
+
+// Export returns the rows stored after the given cursor, for aggregators
+// that pull from this agent instead of receiving pushes.
+func (p *Pusher) Export(after Cursor, limit int) (*Batch, error) {
+	if limit <= 0 || limit > p.batchSize {
+		limit = p.batchSize
+	}
+	return BuildBatch(p.db, p.host, after, limit)
+}
+
+func (p *Pusher) push() {
+	p.cycle = telemetry.StartCycle("push")
+	defer p.cycle.Done()
+
+	online := p.drainSpool()
+	if p.ctx.Err() != nil {
+		return
+	}
+
+	cursor, err := p.cursor()
+	if err != nil {
+		p.fail(fmt.Errorf("error reading push cursor: %v", err))
+		return
+	}
+
+	for i := 0; i < maxBatchesPerCycle; i++ {
+		batch, err := BuildBatch(p.db, p.host, cursor, p.batchSize)
+		if err != nil {
+			p.fail(fmt.Errorf("error building push batch: %v", err))
+			return
+		}
+		if batch.Empty() {
+			return
+		}
+
+		data, err := Encode(batch)
+		if err != nil {
+			p.fail(fmt.Errorf("error encoding push batch: %v", err))
+			return
+		}
+
+		if online {
+			if err := p.send(data); err != nil {
+				if p.ctx.Err() != nil {
+					// Interrupted by Stop, the cursor didn't move so the
+					// batch is sent on the next start.
+					return
+				}
+				p.fail(err)
+				online = false
+			} else {
+				p.succeed()
+			}
+		}
+		if !online {
+			if err := p.spool.write(data); err != nil {
+				p.fail(fmt.Errorf("error buffering push batch: %v", err))
+				return
+			}
+		}
+
+		cursor = batch.Cursor
+		if err := p.saveCursor(cursor); err != nil {
+			p.fail(fmt.Errorf("error saving push cursor: %v", err))
+			return
+		}
+	}
+}
+
+// drainSpool sends the buffered batches in order and reports whether the
+// endpoint accepted all of them.
+func (p *Pusher) drainSpool() bool {
+	files, err := p.spool.files()
+	if err != nil {
+		p.fail(fmt.Errorf("error reading push buffer: %v", err))
+		return false
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			p.fail(fmt.Errorf("error reading buffered batch: %v", err))
+			return false
+		}
+		if err := p.send(data); err != nil {
+			if p.ctx.Err() == nil {
+				p.fail(err)
+			}
+			return false
+		}
+		if err := os.Remove(file); err != nil {
+			p.fail(fmt.Errorf("error removing buffered batch: %v", err))
+			return false
+		}
+		p.succeed()
+	}
+	return true
+}
+
+func (p *Pusher) send(data []byte) error {
+	cfg := config.GetMetricsConfig()
+	req, err := http.NewRequestWithContext(p.ctx, http.MethodPost, cfg.Push.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+
+	token := cfg.Push.Token
+	if token == "" {
+		token = cfg.Server.Token
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	for k, v := range cfg.Push.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("push endpoint returned %s: %s", resp.Status, string(body))
+	}
+	return nil
+}
+
+func (p *Pusher) cursor() (Cursor, error) {
+	server, err := p.db.GetCursor(database.PushServerCursor)
+	if err != nil {
+		return Cursor{}, err
+	}
+	containers, err := p.db.GetCursor(database.PushContainersCursor)
+	if err != nil {
+		return Cursor{}, err
+	}
+	return Cursor{Server: server, Containers: containers}, nil
+}
+
+func (p *Pusher) saveCursor(cursor Cursor) error {
+	if err := p.db.SaveCursor(database.PushServerCursor, cursor.Server); err != nil {
+		return err
+	}
+	return p.db.SaveCursor(database.PushContainersCursor, cursor.Containers)
+}
+
+func (p *Pusher) succeed() {
+	p.mu.Lock()
+	p.lastSuccess = time.Now().UTC().Format(time.RFC3339Nano)
+	p.lastError = ""
+	p.mu.Unlock()
+}
+
+func (p *Pusher) fail(err error) {
+	logger.Error("Error pushing metrics", "error", err)
+	p.cycle.Error()
+	p.mu.Lock()
+	p.lastError = err.Error()
+	p.mu.Unlock()
+}
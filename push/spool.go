@@ -0,0 +1,60 @@
+package push
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// spool keeps encoded batches on disk while the push endpoint is unreachable.
+// Files are named after their creation time so they sort in send order.
+type spool struct {
+	dir      string
+	maxFiles int
+}
+
+func (s *spool) init() error {
+	return os.MkdirAll(s.dir, 0o755)
+}
+
+// write stores a batch, dropping the oldest ones when the spool is full.
+func (s *spool) write(data []byte) error {
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	for len(files) >= s.maxFiles {
+		if err := os.Remove(files[0]); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+
+	name := filepath.Join(s.dir, fmt.Sprintf("%020d.json.gz", time.Now().UnixNano()))
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+// files returns the spooled batches, oldest first.
+func (s *spool) files() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json.gz") {
+			continue
+		}
+		files = append(files, filepath.Join(s.dir, entry.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
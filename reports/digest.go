@@ -0,0 +1,97 @@
+package reports
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"math"
+	"text/template"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+//go:embed templates
+var templates embed.FS
+
+var funcs = map[string]interface{}{
+	"num": func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"seconds": func(v float64) string {
+		return (time.Duration(math.Round(v)) * time.Second).String()
+	},
+}
+
+var (
+	textTemplate = template.Must(template.New("digest.txt").Funcs(funcs).ParseFS(templates, "templates/digest.txt"))
+	htmlTemplate = htmltemplate.Must(htmltemplate.New("digest.html").Funcs(funcs).ParseFS(templates, "templates/digest.html"))
+)
+
+// Charts holds the sparklines of the host metrics over the digest range.
+type Charts struct {
+	CPU      htmltemplate.HTML
+	Memory   htmltemplate.HTML
+	Disk     htmltemplate.HTML
+	Upload   htmltemplate.HTML
+	Download htmltemplate.HTML
+}
+
+// Digest is the summary of a range rendered for people, with sparklines of
+// the host metrics.
+type Digest struct {
+	*Summary
+	Name    string
+	Subject string
+	Charts  Charts
+}
+
+// BuildDigest computes the digest called name of the range from start to
+// end.
+func BuildDigest(db *database.DB, name string, start, end time.Time, top int) (*Digest, error) {
+	summary, err := Summarize(db, start, end, top)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := db.GetHostSamples(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	// Buckets narrower than the refresh interval would leave gaps between
+	// samples; twice as wide absorbs the jitter of the collector.
+	buckets := sparklineBuckets
+	if n := int(end.Sub(start) / (2 * refreshInterval())); n < buckets {
+		buckets = n
+	}
+	if buckets < 2 {
+		buckets = 2
+	}
+	series := func(value func(database.HostSample) float64) []float64 {
+		return bucketAverages(samples, start, end, buckets, value)
+	}
+	const layout = "2006-01-02 15:04 UTC"
+	return &Digest{
+		Summary: summary,
+		Name:    name,
+		Subject: fmt.Sprintf("%s report for %s: %s to %s", name, summary.Host, start.UTC().Format(layout), end.UTC().Format(layout)),
+		Charts: Charts{
+			CPU:      Sparkline(series(func(s database.HostSample) float64 { return s.CPU }), 100),
+			Memory:   Sparkline(series(func(s database.HostSample) float64 { return s.Memory }), 100),
+			Disk:     Sparkline(series(func(s database.HostSample) float64 { return s.Disk }), 100),
+			Upload:   Sparkline(series(func(s database.HostSample) float64 { return s.Upload }), 0),
+			Download: Sparkline(series(func(s database.HostSample) float64 { return s.Download }), 0),
+		},
+	}, nil
+}
+
+// Render returns the plain-text and HTML versions of the digest.
+func (d *Digest) Render() (string, string, error) {
+	var text, html bytes.Buffer
+	if err := textTemplate.Execute(&text, d); err != nil {
+		return "", "", fmt.Errorf("error rendering text digest: %v", err)
+	}
+	if err := htmlTemplate.Execute(&html, d); err != nil {
+		return "", "", fmt.Errorf("error rendering HTML digest: %v", err)
+	}
+	return text.String(), html.String(), nil
+}
@@ -0,0 +1,157 @@
+package reports
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/logging"
+)
+
+var logger = logging.New("reports")
+
+// Scheduler sends the configured digests on their cron schedules.
+type Scheduler struct {
+	db      *database.DB
+	smtp    config.SMTPConfig
+	digests []config.DigestConfig
+	client  *http.Client
+	cron    *cron.Cron
+}
+
+func NewScheduler(db *database.DB) (*Scheduler, error) {
+	cfg := config.GetMetricsConfig().Reports
+
+	s := &Scheduler{
+		db:      db,
+		smtp:    cfg.SMTP,
+		digests: cfg.Digests,
+		client:  &http.Client{Timeout: sendTimeout},
+		cron:    cron.New(),
+	}
+
+	names := make(map[string]bool, len(cfg.Digests))
+	for _, d := range cfg.Digests {
+		if d.Name == "" {
+			return nil, fmt.Errorf("digest scheduled at %q has no name", d.Schedule)
+		}
+		if names[d.Name] {
+			return nil, fmt.Errorf("duplicate digest name %q", d.Name)
+		}
+		names[d.Name] = true
+
+		if len(d.To) == 0 && d.Webhook == "" {
+			return nil, fmt.Errorf("digest %q has no recipients or webhook", d.Name)
+		}
+		if len(d.To) > 0 && (cfg.SMTP.Host == "" || cfg.SMTP.From == "") {
+			return nil, fmt.Errorf("digest %q is sent by email but reports.smtp.host or reports.smtp.from is not set", d.Name)
+		}
+		if d.Range != "" {
+			if _, err := database.ParseDuration(d.Range); err != nil {
+				return nil, fmt.Errorf("digest %q: invalid range: %v", d.Name, err)
+			}
+		}
+
+		d := d
+		if _, err := s.cron.AddFunc(d.Schedule, func() { s.send(d) }); err != nil {
+			return nil, fmt.Errorf("digest %q: invalid schedule %q: %v", d.Name, d.Schedule, err)
+		}
+	}
+
+	return s, nil
+}
+
+func (s *Scheduler) Start() error {
+	if len(s.digests) == 0 {
+		return nil
+	}
+	s.cron.Start()
+	for _, d := range s.digests {
+		logger.Info("Scheduled digest", "name", d.Name, "schedule", d.Schedule)
+	}
+	return nil
+}
+
+// Stop waits for the digests being sent.
+func (s *Scheduler) Stop() {
+	<-s.cron.Stop().Done()
+}
+
+func (s *Scheduler) Has(name string) bool {
+	for _, d := range s.digests {
+		if d.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Send builds a digest now and delivers it to its recipients and webhook.
+func (s *Scheduler) Send(name string) error {
+	for _, d := range s.digests {
+		if d.Name == name {
+			return s.deliver(d, time.Now())
+		}
+	}
+	return fmt.Errorf("unknown digest %q", name)
+}
+
+func (s *Scheduler) send(d config.DigestConfig) {
+	if err := s.deliver(d, time.Now()); err != nil {
+		logger.Error("Error sending digest", "name", d.Name, "error", err)
+		return
+	}
+	logger.Info("Digest sent", "name", d.Name)
+}
+
+func (s *Scheduler) deliver(d config.DigestConfig, now time.Time) error {
+	period, err := s.period(d, now)
+	if err != nil {
+		return err
+	}
+
+	digest, err := BuildDigest(s.db, d.Name, now.Add(-period), now, d.Top)
+	if err != nil {
+		return fmt.Errorf("error building digest: %v", err)
+	}
+	text, html, err := digest.Render()
+	if err != nil {
+		return err
+	}
+
+	// Both deliveries are attempted even when one fails.
+	var errs []error
+	if len(d.To) > 0 {
+		if err := sendMail(s.smtp, d.To, digest.Subject, text, html); err != nil {
+			errs = append(errs, fmt.Errorf("email: %v", err))
+		}
+	}
+	if d.Webhook != "" {
+		payload := WebhookPayload{Name: d.Name, Subject: digest.Subject, Text: text, HTML: html, Summary: digest.Summary}
+		if err := postWebhook(s.client, d.Webhook, d.Headers, payload); err != nil {
+			errs = append(errs, fmt.Errorf("webhook: %v", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// period returns the range covered by a digest: its range when set,
+// otherwise the time between two runs of its schedule, so a daily digest
+// covers the last day and a weekly one the last week.
+func (s *Scheduler) period(d config.DigestConfig, now time.Time) (time.Duration, error) {
+	if d.Range != "" {
+		return database.ParseDuration(d.Range)
+	}
+
+	schedule, err := cron.ParseStandard(d.Schedule)
+	if err != nil {
+		return 0, err
+	}
+	next := schedule.Next(now)
+	return schedule.Next(next).Sub(next), nil
+}
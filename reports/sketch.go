@@ -0,0 +1,66 @@
+package reports
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// sketchAccuracy is the relative error of the percentiles estimated by a
+	// sketch.
+	sketchAccuracy = 0.01
+	// Values up to sketchMinValue are counted as 0.
+	sketchMinValue = 1e-9
+)
+
+var sketchGamma = (1 + sketchAccuracy) / (1 - sketchAccuracy)
+
+// sketch estimates the percentiles of a stream of non-negative values in
+// bounded memory. Values are counted in buckets whose bounds grow
+// geometrically by sketchGamma, so the estimate of a percentile is within
+// sketchAccuracy of the nearest-rank sample, however many samples there are.
+type sketch struct {
+	buckets map[int]int
+	zeros   int
+	n       int
+}
+
+func newSketch() *sketch {
+	return &sketch{buckets: map[int]int{}}
+}
+
+func (s *sketch) add(v float64) {
+	s.n++
+	if v <= sketchMinValue {
+		s.zeros++
+		return
+	}
+	// Bucket i holds the values in (gamma^(i-1), gamma^i].
+	s.buckets[int(math.Ceil(math.Log(v)/math.Log(sketchGamma)))]++
+}
+
+// percentile returns the estimate of the nearest-rank percentile p.
+func (s *sketch) percentile(p float64) float64 {
+	if s.n == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(s.n)))
+	if rank <= s.zeros {
+		return 0
+	}
+
+	indexes := make([]int, 0, len(s.buckets))
+	for i := range s.buckets {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	seen := s.zeros
+	for _, i := range indexes {
+		seen += s.buckets[i]
+		if seen >= rank {
+			return 2 * math.Pow(sketchGamma, float64(i)) / (sketchGamma + 1)
+		}
+	}
+	return 2 * math.Pow(sketchGamma, float64(indexes[len(indexes)-1])) / (sketchGamma + 1)
+}
@@ -0,0 +1,46 @@
+package reports
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSketchPercentiles(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, tc := range []struct {
+		name  string
+		value func() float64
+	}{
+		{"percent", func() float64 { return r.Float64() * 100 }},
+		{"rate", func() float64 { return r.ExpFloat64() * 50 }},
+		{"mostly idle", func() float64 {
+			if r.Intn(10) > 0 {
+				return 0
+			}
+			return r.Float64()
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newSketch()
+			values := make([]float64, 100000)
+			for i := range values {
+				values[i] = tc.value()
+				s.add(values[i])
+			}
+			sort.Float64s(values)
+
+			for _, p := range []float64{1, 50, 95, 99, 100} {
+				want := values[int(math.Ceil(p/100*float64(len(values))))-1]
+				got := s.percentile(p)
+				if math.Abs(got-want) > want*sketchAccuracy+sketchMinValue {
+					t.Errorf("p%v: got %v, want %v within %v%%", p, got, want, sketchAccuracy*100)
+				}
+			}
+			if len(s.buckets) > 2000 {
+				t.Errorf("got %d buckets for %d samples", len(s.buckets), len(values))
+			}
+		})
+	}
+}
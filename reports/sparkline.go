@@ -0,0 +1,95 @@
+package reports
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+const (
+	sparklineWidth   = 240
+	sparklineHeight  = 40
+	sparklineBuckets = 120
+)
+
+// bucketAverages averages the samples of the range from start to end into n
+// buckets of equal width; buckets without samples are NaN.
+func bucketAverages(samples []database.HostSample, start, end time.Time, n int, value func(database.HostSample) float64) []float64 {
+	sums := make([]float64, n)
+	counts := make([]int, n)
+	width := end.Sub(start) / time.Duration(n)
+	if width <= 0 {
+		width = 1
+	}
+	for _, s := range samples {
+		i := int(s.Timestamp.Sub(start) / width)
+		if i < 0 || i >= n {
+			continue
+		}
+		sums[i] += value(s)
+		counts[i]++
+	}
+
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = math.NaN()
+		if counts[i] > 0 {
+			values[i] = sums[i] / float64(counts[i])
+		}
+	}
+	return values
+}
+
+// Sparkline renders values as an inline SVG line scaled from 0 to max, or to
+// the largest value when max is 0. NaN values leave a gap in the line, and
+// round caps draw the values between two gaps as dots. It returns an empty
+// string when there is nothing to draw.
+func Sparkline(values []float64, max float64) template.HTML {
+	if len(values) < 2 {
+		return ""
+	}
+	if max <= 0 {
+		for _, v := range values {
+			if v > max {
+				max = v
+			}
+		}
+		if max <= 0 {
+			max = 1
+		}
+	}
+
+	var path strings.Builder
+	step := float64(sparklineWidth) / float64(len(values)-1)
+	gap := true
+	for i, v := range values {
+		if math.IsNaN(v) {
+			gap = true
+			continue
+		}
+		if v > max {
+			v = max
+		}
+		cmd := "L"
+		if gap {
+			cmd = "M"
+		}
+		gap = false
+		x := float64(i) * step
+		// One pixel of margin so the stroke is not cut at the edges.
+		y := 1 + (1-v/max)*(sparklineHeight-2)
+		fmt.Fprintf(&path, "%s%.1f %.1f", cmd, x, y)
+	}
+	if path.Len() == 0 {
+		return ""
+	}
+
+	return template.HTML(fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+
+			`<path d="%s" fill="none" stroke="#2563eb" stroke-width="1.5" stroke-linejoin="round" stroke-linecap="round"/></svg>`,
+		sparklineWidth, sparklineHeight, sparklineWidth, sparklineHeight, path.String()))
+}
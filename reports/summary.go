@@ -0,0 +1,258 @@
+package reports
+
+import (
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+)
+
+const (
+	DefaultTop = 5
+
+	defaultRefreshRate = 60
+	// A sample counts for the time until the next one, but no longer than
+	// maxGapIntervals refresh intervals: a longer gap means the agent was down.
+	maxGapIntervals = 3
+)
+
+// Stats summarizes the samples of a metric. Percentiles are estimates within
+// 1% of the nearest-rank sample, and never above the maximum.
+type Stats struct {
+	Avg     float64 `json:"avg"`
+	P50     float64 `json:"p50"`
+	P95     float64 `json:"p95"`
+	P99     float64 `json:"p99"`
+	Max     float64 `json:"max"`
+	Samples int     `json:"samples"`
+}
+
+// Threshold tells how long a metric stayed above its alert threshold.
+// PercentAbove is relative to the time covered by samples, not to the whole
+// range.
+type Threshold struct {
+	Metric       string  `json:"metric"`
+	Threshold    float64 `json:"threshold"`
+	SecondsAbove float64 `json:"secondsAbove"`
+	PercentAbove float64 `json:"percentAbove"`
+}
+
+type AlertCounts struct {
+	Total  int            `json:"total"`
+	ByType map[string]int `json:"byType"`
+}
+
+type TopContainers struct {
+	ByCPU    []database.ContainerUsage `json:"byCpu"`
+	ByMemory []database.ContainerUsage `json:"byMemory"`
+}
+
+// Summary is the report of a time range computed from the stored history.
+// Host metrics are nil when there is no sample in the range; upload and
+// download are in MB/s.
+type Summary struct {
+	Host          string                 `json:"host"`
+	Start         string                 `json:"start"`
+	End           string                 `json:"end"`
+	CPU           *Stats                 `json:"cpu"`
+	Memory        *Stats                 `json:"memory"`
+	Disk          *Stats                 `json:"disk"`
+	Upload        *Stats                 `json:"upload"`
+	Download      *Stats                 `json:"download"`
+	Thresholds    []Threshold            `json:"thresholds"`
+	Alerts        AlertCounts            `json:"alerts"`
+	Probes        []database.ProbeUptime `json:"probes"`
+	TopContainers TopContainers          `json:"topContainers"`
+}
+
+// Summarize computes the report of the range from start to end, listing the
+// top containers by CPU and by memory.
+func Summarize(db *database.DB, start, end time.Time, top int) (*Summary, error) {
+	cfg := config.GetMetricsConfig()
+	if top <= 0 {
+		top = DefaultTop
+	}
+
+	host := cfg.Push.Host
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	summary := &Summary{
+		Host:       host,
+		Start:      start.UTC().Format(time.RFC3339Nano),
+		End:        end.UTC().Format(time.RFC3339Nano),
+		Thresholds: []Threshold{},
+	}
+
+	if err := summarizeHost(db, summary, start, end); err != nil {
+		return nil, err
+	}
+
+	counts, err := db.CountAlerts(start, end)
+	if err != nil {
+		return nil, err
+	}
+	summary.Alerts.ByType = counts
+	for _, n := range counts {
+		summary.Alerts.Total += n
+	}
+
+	if summary.Probes, err = db.GetProbeUptimes(start, end); err != nil {
+		return nil, err
+	}
+
+	usage, err := db.GetContainerUsage(start, end)
+	if err != nil {
+		return nil, err
+	}
+	summary.TopContainers.ByCPU = topBy(usage, top, func(u database.ContainerUsage) float64 { return u.AvgCPU })
+	summary.TopContainers.ByMemory = topBy(usage, top, func(u database.ContainerUsage) float64 { return u.AvgMemoryPercent })
+
+	return summary, nil
+}
+
+// summarizeHost fills the host metrics and thresholds of the summary. Averages
+// and maximums are computed by the database, and the samples are read once,
+// without being kept, for the percentiles and the time above the thresholds.
+func summarizeHost(db *database.DB, summary *Summary, start, end time.Time) error {
+	cfg := config.GetMetricsConfig()
+
+	stats, err := db.GetHostStats(start, end)
+	if err != nil {
+		return err
+	}
+	if stats.Samples == 0 {
+		return nil
+	}
+
+	metrics := []struct {
+		stats *Stats
+		value func(database.HostSample) float64
+	}{
+		{&Stats{}, func(s database.HostSample) float64 { return s.CPU }},
+		{&Stats{}, func(s database.HostSample) float64 { return s.Memory }},
+		{&Stats{}, func(s database.HostSample) float64 { return s.Disk }},
+		{&Stats{}, func(s database.HostSample) float64 { return s.Upload }},
+		{&Stats{}, func(s database.HostSample) float64 { return s.Download }},
+	}
+	summary.CPU, summary.Memory, summary.Disk, summary.Upload, summary.Download =
+		metrics[0].stats, metrics[1].stats, metrics[2].stats, metrics[3].stats, metrics[4].stats
+
+	interval := refreshInterval()
+	var thresholds []*thresholdTimer
+	if t := cfg.Server.Thresholds.CPU; t > 0 {
+		thresholds = append(thresholds, newThresholdTimer("cpu", float64(t), interval, metrics[0].value))
+	}
+	if t := cfg.Server.Thresholds.Memory; t > 0 {
+		thresholds = append(thresholds, newThresholdTimer("memory", float64(t), interval, metrics[1].value))
+	}
+
+	sketches := make([]*sketch, len(metrics))
+	for i := range sketches {
+		sketches[i] = newSketch()
+	}
+	err = db.EachHostSample(start, end, func(s database.HostSample) error {
+		for i, m := range metrics {
+			sketches[i].add(m.value(s))
+		}
+		for _, t := range thresholds {
+			t.add(s)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, m := range metrics {
+		max := m.value(stats.Max)
+		*m.stats = Stats{
+			Avg:     m.value(stats.Avg),
+			P50:     math.Min(sketches[i].percentile(50), max),
+			P95:     math.Min(sketches[i].percentile(95), max),
+			P99:     math.Min(sketches[i].percentile(99), max),
+			Max:     max,
+			Samples: stats.Samples,
+		}
+	}
+	for _, t := range thresholds {
+		summary.Thresholds = append(summary.Thresholds, t.result())
+	}
+	return nil
+}
+
+// refreshInterval returns the interval between host samples.
+func refreshInterval() time.Duration {
+	refreshRate := config.GetMetricsConfig().Server.RefreshRate
+	if refreshRate <= 0 {
+		refreshRate = defaultRefreshRate
+	}
+	return time.Duration(refreshRate) * time.Second
+}
+
+// thresholdTimer measures the time a metric stays above a threshold from
+// samples given oldest first.
+type thresholdTimer struct {
+	Threshold
+	interval time.Duration
+	value    func(database.HostSample) float64
+
+	last           *database.HostSample
+	covered, above time.Duration
+}
+
+func newThresholdTimer(metric string, threshold float64, interval time.Duration, value func(database.HostSample) float64) *thresholdTimer {
+	return &thresholdTimer{Threshold: Threshold{Metric: metric, Threshold: threshold}, interval: interval, value: value}
+}
+
+func (t *thresholdTimer) add(s database.HostSample) {
+	if t.last != nil {
+		d := s.Timestamp.Sub(t.last.Timestamp)
+		if d > maxGapIntervals*t.interval {
+			d = maxGapIntervals * t.interval
+		}
+		t.count(*t.last, d)
+	}
+	t.last = &s
+}
+
+// count adds the time d a sample counts for.
+func (t *thresholdTimer) count(s database.HostSample, d time.Duration) {
+	t.covered += d
+	// Alerts fire above the threshold, not at it.
+	if t.value(s) > t.Threshold.Threshold {
+		t.above += d
+	}
+}
+
+// result returns the time above the threshold, the last sample counting for
+// one interval.
+func (t *thresholdTimer) result() Threshold {
+	if t.last != nil {
+		t.count(*t.last, t.interval)
+		t.last = nil
+	}
+	r := t.Threshold
+	r.SecondsAbove = t.above.Seconds()
+	if t.covered > 0 {
+		r.PercentAbove = float64(t.above) / float64(t.covered) * 100
+	}
+	return r
+}
+
+func topBy(usage []database.ContainerUsage, n int, value func(database.ContainerUsage) float64) []database.ContainerUsage {
+	sorted := append([]database.ContainerUsage{}, usage...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if value(sorted[i]) != value(sorted[j]) {
+			return value(sorted[i]) > value(sorted[j])
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
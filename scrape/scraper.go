@@ -0,0 +1,369 @@
+package scrape
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/config"
+	"github.com/mauriciogm/dokploy/apps/monitoring/containers"
+	"github.com/mauriciogm/dokploy/apps/monitoring/database"
+	"github.com/mauriciogm/dokploy/apps/monitoring/logging"
+	"github.com/mauriciogm/dokploy/apps/monitoring/telemetry"
+)
+
+var logger = logging.New("scrape")
+
+// Container labels used for discovery, following the prometheus.io
+// annotation convention.
+const (
+	scrapeLabel = "prometheus.io/scrape"
+	portLabel   = "prometheus.io/port"
+	pathLabel   = "prometheus.io/path"
+	schemeLabel = "prometheus.io/scheme"
+	jobLabel    = "prometheus.io/job"
+)
+
+const (
+	defaultInterval = 30
+	defaultTimeout  = 10
+	maxScrapeSize   = 10 * 1024 * 1024
+)
+
+type Target struct {
+	Job    string            `json:"job"`
+	URL    string            `json:"url"`
+	Labels map[string]string `json:"labels"`
+}
+
+type TargetStatus struct {
+	Target
+	Up             bool    `json:"up"`
+	LastScrape     string  `json:"lastScrape,omitempty"`
+	DurationMs     float64 `json:"durationMs"`
+	SamplesScraped int     `json:"samplesScraped"`
+	SamplesStored  int     `json:"samplesStored"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// Scraper collects Prometheus metrics from static targets and from containers
+// labeled prometheus.io/scrape=true, and stores the selected series as custom
+// metrics together with the target labels.
+type Scraper struct {
+	db       *database.DB
+	docker   *containers.DockerClient
+	client   *http.Client
+	allow    []*regexp.Regexp
+	mu       sync.Mutex
+	status   map[string]*TargetStatus
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	// ctx is cancelled on Stop to abort the scrapes in progress.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewScraper(db *database.DB) (*Scraper, error) {
+	if err := db.InitCustomMetricsTable(); err != nil {
+		return nil, fmt.Errorf("failed to initialize custom metrics table: %v", err)
+	}
+
+	cfg := config.GetMetricsConfig().Scrape
+	allow := make([]*regexp.Regexp, 0, len(cfg.Metrics))
+	for _, pattern := range cfg.Metrics {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid scrape metric pattern %q: %v", pattern, err)
+		}
+		allow = append(allow, re)
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scraper{
+		db:       db,
+		docker:   containers.NewDockerClient(),
+		client:   &http.Client{Timeout: time.Duration(timeout) * time.Second},
+		allow:    allow,
+		status:   make(map[string]*TargetStatus),
+		stopChan: make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+func (s *Scraper) Start() error {
+	cfg := config.GetMetricsConfig().Scrape
+	if !cfg.Discovery && len(cfg.Targets) == 0 {
+		return nil
+	}
+
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	if len(s.allow) == 0 {
+		logger.Warn("No scrape.metrics patterns configured, only up and scrape_duration_seconds are stored")
+	}
+
+	telemetry.Expect("scrape", time.Duration(interval)*time.Second)
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.scrapeAll()
+		for {
+			select {
+			case <-ticker.C:
+				s.scrapeAll()
+			case <-s.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (s *Scraper) Stop() {
+	close(s.stopChan)
+	s.cancel()
+	s.wg.Wait()
+}
+
+// Targets returns the status of the targets of the last scrape.
+func (s *Scraper) Targets() []TargetStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]TargetStatus, 0, len(s.status))
+	for _, status := range s.status {
+		result = append(result, *status)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Job != result[j].Job {
+			return result[i].Job < result[j].Job
+		}
+		return result[i].URL < result[j].URL
+	})
+	return result
+}
+
+func (s *Scraper) scrapeAll() {
+	cycle := telemetry.StartCycle("scrape")
+	defer cycle.Done()
+
+	targets := s.discover()
+
+	var wg sync.WaitGroup
+	statuses := make([]*TargetStatus, len(targets))
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target Target) {
+			defer wg.Done()
+			statuses[i] = s.scrapeTarget(target, cycle)
+		}(i, target)
+	}
+	wg.Wait()
+
+	s.mu.Lock()
+	s.status = make(map[string]*TargetStatus, len(statuses))
+	for _, status := range statuses {
+		s.status[status.Job+"|"+status.URL] = status
+	}
+	s.mu.Unlock()
+}
+
+// discover returns the static targets and the containers that opted in to
+// scraping through their labels.
+func (s *Scraper) discover() []Target {
+	cfg := config.GetMetricsConfig().Scrape
+
+	var targets []Target
+	for _, t := range cfg.Targets {
+		labels := map[string]string{}
+		for k, v := range t.Labels {
+			labels[k] = v
+		}
+		job := t.Job
+		if job == "" {
+			job = t.URL
+		}
+		targets = append(targets, Target{Job: job, URL: t.URL, Labels: labels})
+	}
+
+	if !cfg.Discovery {
+		return targets
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Second)
+	defer cancel()
+
+	list, err := s.docker.ListContainers(ctx)
+	if err != nil {
+		logger.Error("Error listing containers for scrape discovery", "error", err)
+		return targets
+	}
+
+	for _, c := range list {
+		if c.Labels[scrapeLabel] != "true" {
+			continue
+		}
+		name := c.Name()
+
+		port := c.Labels[portLabel]
+		if port == "" {
+			for _, p := range c.Ports {
+				if p.Type == "tcp" {
+					port = strconv.Itoa(p.PrivatePort)
+					break
+				}
+			}
+		}
+		ip := c.IPAddress()
+		if port == "" || ip == "" {
+			logger.Warn("Skipping scrape target without address or port", "container", name)
+			continue
+		}
+
+		scheme := c.Labels[schemeLabel]
+		if scheme == "" {
+			scheme = "http"
+		}
+		path := c.Labels[pathLabel]
+		if path == "" {
+			path = "/metrics"
+		}
+
+		service := containers.ServiceIdentity(name, c.Labels)
+		job := c.Labels[jobLabel]
+		if job == "" {
+			job = service
+		}
+
+		id := c.ID
+		if len(id) > 12 {
+			id = id[:12]
+		}
+
+		targets = append(targets, Target{
+			Job: job,
+			URL: scheme + "://" + ip + ":" + port + path,
+			Labels: map[string]string{
+				"container":    name,
+				"container_id": id,
+				"service":      service,
+			},
+		})
+	}
+
+	return targets
+}
+
+func (s *Scraper) scrapeTarget(target Target, cycle *telemetry.Cycle) *TargetStatus {
+	status := &TargetStatus{Target: target}
+	start := time.Now()
+	timestamp := start.UTC().Format(time.RFC3339Nano)
+	status.LastScrape = timestamp
+
+	samples, err := s.fetch(target.URL)
+	status.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+	if s.ctx.Err() != nil {
+		// Interrupted by Stop, the target is not known to be down.
+		return status
+	}
+
+	baseLabels := map[string]string{"job": target.Job, "instance": target.URL}
+	for k, v := range target.Labels {
+		baseLabels[k] = v
+	}
+
+	up := 0.0
+	if err != nil {
+		status.Error = err.Error()
+	} else {
+		status.Up = true
+		up = 1
+	}
+	status.SamplesScraped = len(samples)
+
+	metrics := []database.CustomMetric{
+		{Timestamp: timestamp, Name: "up", Labels: baseLabels, Value: up},
+		{Timestamp: timestamp, Name: "scrape_duration_seconds", Labels: baseLabels, Value: status.DurationMs / 1000},
+	}
+	for _, sample := range samples {
+		if !s.selected(sample.Name) {
+			continue
+		}
+
+		labels := make(map[string]string, len(sample.Labels)+len(baseLabels))
+		for k, v := range sample.Labels {
+			labels[k] = v
+		}
+		// Target labels win, as with Prometheus' honor_labels: false.
+		for k, v := range baseLabels {
+			labels[k] = v
+		}
+
+		ts := timestamp
+		if sample.TimestampMs != 0 {
+			ts = time.UnixMilli(sample.TimestampMs).UTC().Format(time.RFC3339Nano)
+		}
+		metrics = append(metrics, database.CustomMetric{
+			Timestamp: ts,
+			Name:      sample.Name,
+			Labels:    labels,
+			Value:     sample.Value,
+		})
+	}
+	status.SamplesStored = len(metrics) - 2
+
+	if err := s.db.SaveCustomMetrics(metrics); err != nil {
+		logger.Error("Error saving scraped metrics", "target", target.URL, "error", err)
+		cycle.Error()
+	}
+	return status
+}
+
+func (s *Scraper) fetch(url string) ([]Sample, error) {
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/plain;version=0.0.4")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return ParseText(io.LimitReader(resp.Body, maxScrapeSize))
+}
+
+// selected reports whether a series should be stored. Series are only
+// stored when listed in scrape.metrics: an exporter can expose thousands of
+// series, which would quickly fill the database.
+func (s *Scraper) selected(name string) bool {
+	for _, re := range s.allow {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package telemetry
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const prefix = "dokploy_agent_"
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheus writes the snapshot in the Prometheus text exposition
+// format.
+func WritePrometheus(w io.Writer, s Snapshot) error {
+	p := &promWriter{w: bufio.NewWriter(w)}
+
+	names := sortedKeys(s.Collectors)
+	p.header("collector_duration_seconds", "histogram", "Duration of the collection cycles.")
+	for _, name := range names {
+		p.histogram("collector_duration_seconds", "collector", name, s.Collectors[name].Duration)
+	}
+	p.header("collector_errors_total", "counter", "Errors during the collection cycles.")
+	for _, name := range names {
+		p.sample("collector_errors_total", "collector", name, float64(s.Collectors[name].Errors))
+	}
+	p.header("collector_skipped_total", "counter", "Cycles skipped because the previous one was still running.")
+	for _, name := range names {
+		p.sample("collector_skipped_total", "collector", name, float64(s.Collectors[name].Skipped))
+	}
+
+	tables := sortedKeys(s.Database.Writes)
+	p.header("db_write_duration_seconds", "histogram", "Latency of the database writes.")
+	for _, table := range tables {
+		p.histogram("db_write_duration_seconds", "table", table, s.Database.Writes[table].Latency)
+	}
+	p.header("db_write_errors_total", "counter", "Failed database writes.")
+	for _, table := range tables {
+		p.sample("db_write_errors_total", "table", table, float64(s.Database.Writes[table].Errors))
+	}
+	p.header("db_size_bytes", "gauge", "Size of the database file.")
+	p.sample("db_size_bytes", "", "", float64(s.Database.FileSizeBytes))
+	p.header("db_rows", "gauge", "Rows stored per table.")
+	for _, table := range sortedKeys(s.Database.Rows) {
+		p.sample("db_rows", "table", table, float64(s.Database.Rows[table]))
+	}
+
+	alertTypes := sortedKeys(s.Alerts)
+	p.header("alerts_sent_total", "counter", "Alerts delivered to the callback URL.")
+	for _, t := range alertTypes {
+		p.sample("alerts_sent_total", "type", t, float64(s.Alerts[t].Sent))
+	}
+	p.header("alert_failures_total", "counter", "Alerts that could not be delivered.")
+	for _, t := range alertTypes {
+		p.sample("alert_failures_total", "type", t, float64(s.Alerts[t].Failed))
+	}
+
+	exporterNames := sortedKeys(s.Exporters)
+	p.header("exporter_pending_segments", "gauge", "WAL segments waiting to be sent.")
+	for _, name := range exporterNames {
+		p.sample("exporter_pending_segments", "exporter", name, float64(s.Exporters[name].PendingSegments))
+	}
+	p.header("exporter_sent_samples_total", "counter", "Samples delivered by the exporter.")
+	for _, name := range exporterNames {
+		p.sample("exporter_sent_samples_total", "exporter", name, float64(s.Exporters[name].SentSamples))
+	}
+	p.header("exporter_failed_batches_total", "counter", "Batches that failed and will be retried.")
+	for _, name := range exporterNames {
+		p.sample("exporter_failed_batches_total", "exporter", name, float64(s.Exporters[name].FailedBatches))
+	}
+	p.header("exporter_rejected_samples_total", "counter", "Samples rejected by the endpoint.")
+	for _, name := range exporterNames {
+		p.sample("exporter_rejected_samples_total", "exporter", name, float64(s.Exporters[name].RejectedSamples))
+	}
+	p.header("exporter_dropped_samples_total", "counter", "Samples dropped because the queue was full.")
+	for _, name := range exporterNames {
+		p.sample("exporter_dropped_samples_total", "exporter", name, float64(s.Exporters[name].DroppedSamples))
+	}
+
+	p.header("goroutines", "gauge", "Number of goroutines.")
+	p.sample("goroutines", "", "", float64(s.Runtime.Goroutines))
+	p.header("heap_alloc_bytes", "gauge", "Bytes of allocated heap objects.")
+	p.sample("heap_alloc_bytes", "", "", float64(s.Runtime.HeapAllocBytes))
+	p.header("heap_inuse_bytes", "gauge", "Bytes in in-use heap spans.")
+	p.sample("heap_inuse_bytes", "", "", float64(s.Runtime.HeapInuseBytes))
+	p.header("gc_cycles_total", "counter", "Completed GC cycles.")
+	p.sample("gc_cycles_total", "", "", float64(s.Runtime.GCCycles))
+	p.header("uptime_seconds", "gauge", "Seconds since the agent started.")
+	p.sample("uptime_seconds", "", "", s.Runtime.UptimeSeconds)
+
+	if p.err != nil {
+		return p.err
+	}
+	return p.w.Flush()
+}
+
+type promWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (p *promWriter) printf(format string, args ...interface{}) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintf(p.w, format, args...)
+	}
+}
+
+func (p *promWriter) header(name, kind, help string) {
+	p.printf("# HELP %s%s %s\n# TYPE %s%s %s\n", prefix, name, help, prefix, name, kind)
+}
+
+func (p *promWriter) sample(name, label, value string, v float64) {
+	p.printf("%s%s%s %s\n", prefix, name, labels(label, value, ""), formatFloat(v))
+}
+
+func (p *promWriter) histogram(name, label, value string, h Histogram) {
+	for _, b := range h.Buckets {
+		p.printf("%s%s_bucket%s %d\n", prefix, name, labels(label, value, formatFloat(b.UpperBound)), b.Count)
+	}
+	p.printf("%s%s_bucket%s %d\n", prefix, name, labels(label, value, "+Inf"), h.Count)
+	p.printf("%s%s_sum%s %s\n", prefix, name, labels(label, value, ""), formatFloat(h.Sum))
+	p.printf("%s%s_count%s %d\n", prefix, name, labels(label, value, ""), h.Count)
+}
+
+func labels(label, value, le string) string {
+	var parts []string
+	if label != "" {
+		parts = append(parts, label+`="`+labelEscaper.Replace(value)+`"`)
+	}
+	if le != "" {
+		parts = append(parts, `le="`+le+`"`)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,340 @@
+package telemetry
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mauriciogm/dokploy/apps/monitoring/exporters"
+)
+
+var (
+	// collectorBuckets are the upper bounds in seconds of the collection
+	// duration histograms.
+	collectorBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+	// writeBuckets are the upper bounds in seconds of the database write
+	// latency histograms.
+	writeBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+)
+
+var (
+	mu         sync.Mutex
+	started    = time.Now()
+	collectors = make(map[string]*collectorStats)
+	writes     = make(map[string]*writeStats)
+	alerts     = make(map[string]*AlertStats)
+)
+
+type Bucket struct {
+	UpperBound float64 `json:"le"`
+	Count      int64   `json:"count"`
+}
+
+// Histogram counts observations in cumulative buckets, like a Prometheus
+// histogram.
+type Histogram struct {
+	Buckets []Bucket `json:"buckets"`
+	Sum     float64  `json:"sum"`
+	Count   int64    `json:"count"`
+}
+
+func newHistogram(bounds []float64) *Histogram {
+	h := &Histogram{Buckets: make([]Bucket, len(bounds))}
+	for i, b := range bounds {
+		h.Buckets[i].UpperBound = b
+	}
+	return h
+}
+
+func (h *Histogram) observe(v float64) {
+	for i := range h.Buckets {
+		if v <= h.Buckets[i].UpperBound {
+			h.Buckets[i].Count++
+		}
+	}
+	h.Sum += v
+	h.Count++
+}
+
+func (h *Histogram) copy() Histogram {
+	c := *h
+	c.Buckets = append([]Bucket(nil), h.Buckets...)
+	return c
+}
+
+type collectorStats struct {
+	duration    *Histogram
+	errors      int64
+	skipped     int64
+	lastSuccess time.Time
+	// interval is how often the collector is expected to succeed, zero when
+	// it runs on demand.
+	interval time.Duration
+	expected time.Time
+	// remote collectors fail when a remote endpoint does.
+	remote bool
+}
+
+type writeStats struct {
+	latency *Histogram
+	errors  int64
+}
+
+func collector(name string) *collectorStats {
+	s, ok := collectors[name]
+	if !ok {
+		s = &collectorStats{duration: newHistogram(collectorBuckets)}
+		collectors[name] = s
+	}
+	return s
+}
+
+// Cycle measures one collection cycle of a collector.
+type Cycle struct {
+	collector string
+	start     time.Time
+	errors    int64
+}
+
+// StartCycle starts measuring a collection cycle. Done must be called when
+// the cycle ends.
+func StartCycle(collector string) *Cycle {
+	return &Cycle{collector: collector, start: time.Now()}
+}
+
+// Error counts an error of the cycle, such as a failed command or write. It
+// can be called from several goroutines.
+func (c *Cycle) Error() {
+	atomic.AddInt64(&c.errors, 1)
+}
+
+// Done records the duration of the cycle. A cycle without errors updates
+// the last successful collection time.
+func (c *Cycle) Done() {
+	end := time.Now()
+
+	mu.Lock()
+	defer mu.Unlock()
+	s := collector(c.collector)
+	s.duration.observe(end.Sub(c.start).Seconds())
+	errors := atomic.LoadInt64(&c.errors)
+	s.errors += errors
+	if errors == 0 {
+		s.lastSuccess = end
+	}
+}
+
+// SkipCycle counts a cycle that did not run because the previous one was
+// still in progress.
+func SkipCycle(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	collector(name).skipped++
+}
+
+// Expect declares that a collector should succeed at least every interval,
+// so health checks can tell when it stops collecting. The longest interval
+// declared for a collector wins.
+func Expect(name string, interval time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+	s := collector(name)
+	if interval > s.interval {
+		s.interval = interval
+	}
+	if s.expected.IsZero() {
+		s.expected = time.Now()
+	}
+}
+
+// ExpectRemote is Expect for a collector that delivers to or pulls from
+// other servers, such as the pusher, whose cycles fail while they are
+// unreachable even though the agent itself works.
+func ExpectRemote(name string, interval time.Duration) {
+	Expect(name, interval)
+	mu.Lock()
+	defer mu.Unlock()
+	collector(name).remote = true
+}
+
+// Expectation is the schedule of a collector declared with Expect.
+type Expectation struct {
+	Collector string
+	Interval  time.Duration
+	// Since is when the collector was declared.
+	Since       time.Time
+	LastSuccess time.Time
+	// Remote is set for the collectors declared with ExpectRemote.
+	Remote bool
+}
+
+// Expectations returns the collectors declared with Expect, sorted by name.
+func Expectations() []Expectation {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var result []Expectation
+	for _, name := range sortedKeys(collectors) {
+		s := collectors[name]
+		if s.interval == 0 {
+			continue
+		}
+		result = append(result, Expectation{
+			Collector:   name,
+			Interval:    s.interval,
+			Since:       s.expected,
+			LastSuccess: s.lastSuccess,
+			Remote:      s.remote,
+		})
+	}
+	return result
+}
+
+// ObserveWrite records the latency of a database write to table.
+func ObserveWrite(table string, d time.Duration, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	s, ok := writes[table]
+	if !ok {
+		s = &writeStats{latency: newHistogram(writeBuckets)}
+		writes[table] = s
+	}
+	s.latency.observe(d.Seconds())
+	if err != nil {
+		s.errors++
+	}
+}
+
+// ObserveAlert counts an alert sent to the callback URL, or the failure to
+// send it.
+func ObserveAlert(alertType string, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	s, ok := alerts[alertType]
+	if !ok {
+		s = &AlertStats{}
+		alerts[alertType] = s
+	}
+	if err != nil {
+		s.Failed++
+	} else {
+		s.Sent++
+	}
+}
+
+// Storage reports the size of the database.
+type Storage interface {
+	FileSize() (int64, error)
+	RowCounts() (map[string]int64, error)
+}
+
+type CollectorStats struct {
+	Duration    Histogram `json:"duration"`
+	Errors      int64     `json:"errors"`
+	Skipped     int64     `json:"skipped"`
+	LastSuccess string    `json:"lastSuccess,omitempty"`
+}
+
+type WriteStats struct {
+	Latency Histogram `json:"latency"`
+	Errors  int64     `json:"errors"`
+}
+
+type AlertStats struct {
+	Sent   int64 `json:"sent"`
+	Failed int64 `json:"failed"`
+}
+
+type DatabaseStats struct {
+	FileSizeBytes int64                 `json:"fileSizeBytes"`
+	Rows          map[string]int64      `json:"rows"`
+	Writes        map[string]WriteStats `json:"writes"`
+	Error         string                `json:"error,omitempty"`
+}
+
+type RuntimeStats struct {
+	Goroutines     int     `json:"goroutines"`
+	HeapAllocBytes uint64  `json:"heapAllocBytes"`
+	HeapInuseBytes uint64  `json:"heapInuseBytes"`
+	GCCycles       uint32  `json:"gcCycles"`
+	UptimeSeconds  float64 `json:"uptimeSeconds"`
+}
+
+// Snapshot is the state of the agent's internal metrics.
+type Snapshot struct {
+	Timestamp  string                          `json:"timestamp"`
+	Collectors map[string]CollectorStats       `json:"collectors"`
+	Database   DatabaseStats                   `json:"database"`
+	Alerts     map[string]AlertStats           `json:"alerts"`
+	Exporters  map[string]exporters.QueueStats `json:"exporters"`
+	Runtime    RuntimeStats                    `json:"runtime"`
+}
+
+// Collect returns the current internal metrics. Database size errors are
+// reported in the snapshot instead of failing it.
+func Collect(storage Storage) Snapshot {
+	now := time.Now()
+	snapshot := Snapshot{
+		Timestamp:  now.UTC().Format(time.RFC3339Nano),
+		Collectors: make(map[string]CollectorStats),
+		Database: DatabaseStats{
+			Rows:   map[string]int64{},
+			Writes: make(map[string]WriteStats),
+		},
+		Alerts:    make(map[string]AlertStats),
+		Exporters: exporters.Stats(),
+	}
+
+	mu.Lock()
+	for name, s := range collectors {
+		stats := CollectorStats{
+			Duration: s.duration.copy(),
+			Errors:   s.errors,
+			Skipped:  s.skipped,
+		}
+		if !s.lastSuccess.IsZero() {
+			stats.LastSuccess = s.lastSuccess.UTC().Format(time.RFC3339Nano)
+		}
+		snapshot.Collectors[name] = stats
+	}
+	for table, s := range writes {
+		snapshot.Database.Writes[table] = WriteStats{Latency: s.latency.copy(), Errors: s.errors}
+	}
+	for alertType, s := range alerts {
+		snapshot.Alerts[alertType] = *s
+	}
+	mu.Unlock()
+
+	if size, err := storage.FileSize(); err != nil {
+		snapshot.Database.Error = err.Error()
+	} else {
+		snapshot.Database.FileSizeBytes = size
+	}
+	if rows, err := storage.RowCounts(); err != nil {
+		snapshot.Database.Error = err.Error()
+	} else {
+		snapshot.Database.Rows = rows
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	snapshot.Runtime = RuntimeStats{
+		Goroutines:     runtime.NumGoroutine(),
+		HeapAllocBytes: mem.HeapAlloc,
+		HeapInuseBytes: mem.HeapInuse,
+		GCCycles:       mem.NumGC,
+		UptimeSeconds:  now.Sub(started).Seconds(),
+	}
+	return snapshot
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}